@@ -0,0 +1,205 @@
+// Command admin performs operator tasks directly against account storage and
+// the course dataset. It needs no running server and no network access.
+//
+//	go run ./cmd/admin users
+//	go run ./cmd/admin -storage bolt:data/boiler.db disable someone@purdue.edu
+//	go run ./cmd/admin -data purdue_courses_fall_2025.json stats
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"purdue_schedule/internal/auth"
+	"purdue_schedule/internal/data"
+	"purdue_schedule/internal/share"
+	"purdue_schedule/internal/storage"
+)
+
+const usage = `usage: admin [flags] <command> [args]
+
+commands:
+  users               list accounts
+  verify <email>      mark an account verified
+  disable <email>     block sign-in and end the account's sessions
+  enable <email>      allow sign-in again
+  delete <email>      delete an account, its sessions, code, friend links and share links (-shares)
+  export <email>      print everything stored about an account, share links included, as JSON
+  purge               delete expired sessions and used or expired codes
+  stats               summarize the course dataset (-data)
+
+flags:
+`
+
+func main() {
+	var storageSpec, sharesDir, jsonPath string
+	var asJSON bool
+	flag.StringVar(&storageSpec, "storage", "file:data", "Account storage: file:<dir> or bolt:<db file>")
+	flag.StringVar(&sharesDir, "shares", "data/shares", "Directory holding share link snapshots, as given to the server")
+	flag.StringVar(&jsonPath, "data", "purdue_courses_fall_2025.json", "Path to courses JSON file, for stats")
+	flag.BoolVar(&asJSON, "json", false, "Print users and stats as JSON")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	log.SetFlags(0)
+
+	cmd, args := args[0], args[1:]
+	if cmd == "stats" {
+		stats(jsonPath, asJSON)
+		return
+	}
+
+	store, err := storage.Open(storageSpec)
+	if err != nil {
+		log.Fatalf("failed to open account storage: %v", err)
+	}
+	defer store.Close()
+	svc := auth.NewService(store, nil)
+
+	email := func() string {
+		if len(args) != 1 {
+			log.Fatalf("%s takes one email address", cmd)
+		}
+		return args[0]
+	}
+
+	switch cmd {
+	case "users":
+		users(svc, asJSON)
+	case "verify":
+		u, err := svc.MarkVerified(email())
+		check(err)
+		fmt.Printf("verified %s\n", u.Email)
+	case "disable", "enable":
+		u, err := svc.SetDisabled(email(), cmd == "disable")
+		check(err)
+		fmt.Printf("%sd %s\n", cmd, u.Email)
+	case "delete":
+		addr := email()
+		u, err := svc.User(addr)
+		check(err)
+		shares, err := share.NewStore(sharesDir)
+		check(err)
+		owned, err := shares.ByOwner(u.Id)
+		check(err)
+		// Shares go first: once the account is gone nothing names their owner
+		for _, s := range owned {
+			check(shares.Delete(s.Id))
+		}
+		check(svc.DeleteAccount(addr))
+		fmt.Printf("deleted %s (share links: %d)\n", addr, len(owned))
+	case "export":
+		export, err := svc.ExportUser(email())
+		check(err)
+		shares, err := share.NewStore(sharesDir)
+		check(err)
+		owned, err := shares.ByOwner(export.User.Id)
+		check(err)
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		check(enc.Encode(struct {
+			*auth.Export
+			Shares []*share.Share `json:"shares"`
+		}{export, owned}))
+	case "purge":
+		sessions, otps, err := svc.Sweep()
+		check(err)
+		fmt.Printf("deleted %d expired sessions and %d codes\n", sessions, otps)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func check(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func users(svc *auth.Service, asJSON bool) {
+	list, err := svc.Users()
+	check(err)
+	if asJSON {
+		out := make([]auth.PublicUser, 0, len(list))
+		for _, u := range list {
+			out = append(out, auth.Public(u))
+		}
+		check(json.NewEncoder(os.Stdout).Encode(out))
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "EMAIL\tNAME\tCREATED\tSTATUS\tSCHEDULES\tFRIENDS")
+	for _, u := range list {
+		status := "verified"
+		switch {
+		case u.Disabled:
+			status = "disabled"
+		case !u.IsVerified:
+			status = "pending"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\n", u.Email, u.Name, u.CreatedAt.Local().Format(time.DateOnly), status, len(u.Schedules), len(u.Friends))
+	}
+	check(tw.Flush())
+}
+
+func stats(jsonPath string, asJSON bool) {
+	store, err := data.LoadStore(jsonPath)
+	if err != nil {
+		log.Fatalf("failed to load data: %v", err)
+	}
+	st := store.Stats()
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		check(enc.Encode(st))
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "courses\t%d\n", st.Courses)
+	fmt.Fprintf(tw, "sections\t%d\n", st.Sections)
+	fmt.Fprintf(tw, "meetings\t%d\n", st.Meetings)
+	fmt.Fprintf(tw, "sections without meetings\t%d\n", st.SectionsWithoutMeetings)
+	fmt.Fprintf(tw, "unscheduled meetings (TBA)\t%d\n", st.UnscheduledMeetings)
+	fmt.Fprintf(tw, "scheduled meetings without a building\t%d\n", st.UnknownBuildingMeetings)
+	fmt.Fprintf(tw, "buildings\t%d\n", len(st.Buildings))
+
+	fmt.Fprintln(tw, "\nCAMPUS\tMEETINGS")
+	campuses := make([]string, 0, len(st.MeetingsByCampus))
+	for id := range st.MeetingsByCampus {
+		campuses = append(campuses, id)
+	}
+	sort.Slice(campuses, func(i, j int) bool { return st.MeetingsByCampus[campuses[i]] > st.MeetingsByCampus[campuses[j]] })
+	for _, id := range campuses {
+		name := store.CampusName(id)
+		if name == "" {
+			name = "(none)"
+		}
+		fmt.Fprintf(tw, "%s\t%d\n", name, st.MeetingsByCampus[id])
+	}
+
+	fmt.Fprintln(tw, "\nSECTION TYPE\tSECTIONS")
+	types := make([]string, 0, len(st.SectionTypes))
+	for t := range st.SectionTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		fmt.Fprintf(tw, "%s\t%d\n", t, st.SectionTypes[t])
+	}
+	check(tw.Flush())
+}
@@ -0,0 +1,68 @@
+// Command diff compares two course dumps and reports added and removed
+// courses and sections and per-section time, day, room, instructor and
+// type changes.
+//
+//	go run ./cmd/diff purdue_courses_old.json purdue_courses_fall_2025.json
+//	go run ./cmd/diff -json old.json new.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"purdue_schedule/internal/data"
+)
+
+func main() {
+	var asJSON, fetch bool
+	flag.BoolVar(&asJSON, "json", false, "Print the diff as JSON instead of a summary")
+	flag.BoolVar(&fetch, "subjects", true, "Fetch subject abbreviations so courses read \"CS 18000\" rather than \"18000\"")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: diff [flags] <old.json> <new.json>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	log.SetFlags(0)
+
+	old := load(flag.Arg(0), fetch)
+	next := load(flag.Arg(1), fetch)
+	diff := data.Diff(old, next)
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(diff); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if diff.Empty() {
+		fmt.Println("No changes.")
+		return
+	}
+	for _, line := range diff.Summary() {
+		fmt.Println(line)
+	}
+	fmt.Printf("\n%d courses added, %d removed; %d sections added, %d removed, %d changed\n",
+		len(diff.CoursesAdded), len(diff.CoursesRemoved), len(diff.SectionsAdded), len(diff.SectionsRemoved), len(diff.SectionsChanged))
+}
+
+func load(path string, fetch bool) *data.Store {
+	store, err := data.LoadStore(path)
+	if err != nil {
+		log.Fatalf("failed to load %s: %v", path, err)
+	}
+	if fetch {
+		if err := store.MaybeFetchSubjects(); err != nil {
+			log.Printf("warning: failed to fetch subject names: %v", err)
+		}
+	}
+	return store
+}
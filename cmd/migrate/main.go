@@ -0,0 +1,43 @@
+// Command migrate copies accounts, sessions and one-time codes between
+// storage backends, e.g. from the data/ directory into a bolt database:
+//
+//	go run ./cmd/migrate -from file:data -to bolt:data/boiler.db
+package main
+
+import (
+	"flag"
+	"log"
+
+	"purdue_schedule/internal/storage"
+)
+
+func main() {
+	var from, to string
+	flag.StringVar(&from, "from", "file:data", "Source storage spec")
+	flag.StringVar(&to, "to", "", "Destination storage spec, e.g. bolt:data/boiler.db")
+	flag.Parse()
+
+	if to == "" {
+		log.Fatal("-to is required")
+	}
+	if from == to {
+		log.Fatal("-from and -to must differ")
+	}
+
+	src, err := storage.Open(from)
+	if err != nil {
+		log.Fatalf("failed to open %s: %v", from, err)
+	}
+	defer src.Close()
+	dst, err := storage.Open(to)
+	if err != nil {
+		log.Fatalf("failed to open %s: %v", to, err)
+	}
+	defer dst.Close()
+
+	users, sessions, otps, err := storage.Copy(dst, src)
+	if err != nil {
+		log.Fatalf("migration failed after %d users, %d sessions, %d codes: %v", users, sessions, otps, err)
+	}
+	log.Printf("copied %d users, %d sessions, %d codes from %s to %s", users, sessions, otps, from, to)
+}
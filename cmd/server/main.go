@@ -1,17 +1,29 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
+	_ "time/tzdata" // quiet hours use IANA zones even where the host has none
 
 	"purdue_schedule/internal/api"
+	"purdue_schedule/internal/auth"
+	"purdue_schedule/internal/changes"
 	"purdue_schedule/internal/data"
+	"purdue_schedule/internal/notify"
+	"purdue_schedule/internal/plan"
+	"purdue_schedule/internal/seats"
+	"purdue_schedule/internal/share"
+	"purdue_schedule/internal/storage"
 
 	"github.com/gorilla/mux"
 )
@@ -20,10 +32,41 @@ func main() {
 	var jsonPath string
 	var addr string
 	var staticDir string
+	var storageSpec, sharesDir string
+	var smtpAddr, smtpFrom, smtpUser string
+	var sweepInterval, refreshInterval time.Duration
+	var seatsPath, changesPath string
+	var watchInterval time.Duration
+	var webhookAllowPrivate, devMail bool
+	var term, prereqsPath, brandingPath string
+	extraCatalogs := map[string]string{}
 
 	flag.StringVar(&jsonPath, "data", "purdue_courses_fall_2025.json", "Path to courses JSON file")
 	flag.StringVar(&addr, "addr", ":8080", "HTTP listen address")
 	flag.StringVar(&staticDir, "static", "web", "Static assets directory to serve")
+	flag.StringVar(&storageSpec, "storage", "file:data", "Account storage: file:<dir> (data/users|sessions|otps layout) or bolt:<db file>")
+	flag.StringVar(&sharesDir, "shares", "data/shares", "Directory holding share link snapshots")
+	flag.StringVar(&smtpAddr, "smtp-addr", "", "SMTP relay host:port for verification email (logs mail when empty; see -dev-mail)")
+	flag.StringVar(&smtpFrom, "smtp-from", "BoilerSchedule <no-reply@localhost>", "From address for outgoing email")
+	flag.StringVar(&smtpUser, "smtp-user", "", "SMTP username; the password is read from SMTP_PASSWORD")
+	flag.BoolVar(&devMail, "dev-mail", false, "Without -smtp-addr, log verification codes in plaintext instead of redacted (development only)")
+	flag.DurationVar(&sweepInterval, "sweep-interval", 10*time.Minute, "How often expired sessions and codes are deleted")
+	flag.DurationVar(&refreshInterval, "refresh", 5*time.Minute, "How often to check the data file for changes and reload it (0 disables; SIGHUP always reloads)")
+	flag.StringVar(&seatsPath, "seats", "data/seats.jsonl", "Seat history file, appended on every dataset load")
+	flag.StringVar(&changesPath, "changes", "data/changes.jsonl", "Catalog change feed, appended when a reload changes courses or sections")
+	flag.DurationVar(&watchInterval, "watch-interval", 15*time.Minute, "How often watched sections and pending alerts are re-checked between reloads, to send notifications held by quiet hours (0 disables)")
+	flag.BoolVar(&webhookAllowPrivate, "webhook-allow-private", false, "Let notification webhooks reach loopback, private and link-local addresses")
+	flag.StringVar(&term, "term", "", "Term the -data file covers (named from the dataset's TermId when empty)")
+	flag.Func("catalog", `Catalog for another term as "Spring 2026=path.json", for plans of study (repeatable)`, func(v string) error {
+		name, path, ok := strings.Cut(v, "=")
+		if !ok || strings.TrimSpace(name) == "" || path == "" {
+			return fmt.Errorf(`want "Term=path.json"`)
+		}
+		extraCatalogs[strings.TrimSpace(name)] = path
+		return nil
+	})
+	flag.StringVar(&prereqsPath, "prereqs", "", "Prerequisites JSON file used to check plans of study (none when empty)")
+	flag.StringVar(&brandingPath, "branding", "", "JSON file with the title, subtitle, footer, logo and colors of schedule exports (built-in when empty)")
 	flag.Parse()
 
 	absJSON, err := filepath.Abs(jsonPath)
@@ -36,18 +79,165 @@ func main() {
 
 	log.Printf("loading data from %s", absJSON)
 	start := time.Now()
-	store, err := data.LoadStore(absJSON)
+	dataset, err := data.OpenDataset(absJSON)
 	if err != nil {
 		log.Fatalf("failed to load data: %v", err)
 	}
+	store := dataset.Store()
 	log.Printf("loaded %d courses in %s", store.CourseCount(), time.Since(start))
 
 	// Fetch subject mapping to enrich schedules with subject abbreviations
 	if err := store.MaybeFetchSubjects(); err != nil {
 		log.Printf("warning: failed to fetch subject names: %v", err)
 	}
+	termOverride := term != ""
+	if !termOverride {
+		if err := store.MaybeFetchTerm(); err != nil {
+			log.Printf("warning: failed to fetch term name: %v", err)
+		}
+		term = store.TermName()
+	}
+
+	catalogs := data.NewCatalogs()
+	if term != "" {
+		catalogs.Add(term, dataset)
+	} else {
+		log.Printf("warning: term %q of %s has no name; pass -term to serve it in plans of study", store.TermId(), absJSON)
+	}
+	for name, path := range extraCatalogs {
+		d, err := data.OpenDataset(path)
+		if err != nil {
+			log.Fatalf("failed to load %s catalog: %v", name, err)
+		}
+		if err := d.Store().MaybeFetchSubjects(); err != nil {
+			log.Printf("warning: failed to fetch subject names for %s: %v", name, err)
+		}
+		if refreshInterval > 0 {
+			go d.Watch(context.Background(), refreshInterval)
+		}
+		catalogs.Add(name, d)
+		log.Printf("loaded %s catalog: %d courses", name, d.Store().CourseCount())
+	}
+	var prereqs plan.Prereqs
+	if prereqsPath != "" {
+		if prereqs, err = plan.LoadPrereqs(prereqsPath); err != nil {
+			log.Fatalf("failed to load prerequisites: %v", err)
+		}
+		log.Printf("loaded prerequisites for %d courses", len(prereqs))
+	}
+	brand := api.DefaultBranding()
+	if brandingPath != "" {
+		if brand, err = api.LoadBranding(brandingPath); err != nil {
+			log.Fatalf("failed to load branding: %v", err)
+		}
+	}
+
+	seatHistory, err := seats.Open(seatsPath)
+	if err != nil {
+		log.Fatalf("failed to open seat history: %v", err)
+	}
+	defer seatHistory.Close()
+	recordSeats := func(s *data.Store) {
+		if n, err := seatHistory.Record(s, time.Now()); err != nil {
+			log.Printf("warning: failed to record seats: %v", err)
+		} else if n > 0 {
+			log.Printf("recorded seat changes for %d sections", n)
+		}
+	}
+	recordSeats(store)
+	changeLog, err := changes.Open(changesPath)
+	if err != nil {
+		log.Fatalf("failed to open change feed: %v", err)
+	}
+	defer changeLog.Close()
+	dataset.OnLoad(func(_, next *data.Store) {
+		// Names are carried over from the old store; fetch only if it had none
+		if err := next.MaybeFetchSubjects(); err != nil {
+			log.Printf("warning: failed to fetch subject names: %v", err)
+		}
+		if !termOverride {
+			if err := next.MaybeFetchTerm(); err != nil {
+				log.Printf("warning: failed to fetch term name: %v", err)
+			}
+		}
+	})
+	dataset.OnSwap(func(_, next *data.Store) {
+		recordSeats(next)
+		if !termOverride && next.TermName() != term {
+			// Plans look the served catalog up by its new name
+			if term = next.TermName(); term == "" {
+				log.Printf("warning: term %q of %s has no name; pass -term to serve it in plans of study", next.TermId(), absJSON)
+			}
+			catalogs.Rekey(dataset, term)
+		}
+	})
+	if refreshInterval > 0 {
+		go dataset.Watch(context.Background(), refreshInterval)
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if _, err := dataset.Reload(true); err != nil {
+				log.Printf("dataset reload failed: %v", err)
+				continue
+			}
+			log.Printf("reloaded %s: %d courses", absJSON, dataset.Store().CourseCount())
+		}
+	}()
+
+	accounts, err := storage.Open(storageSpec)
+	if err != nil {
+		log.Fatalf("failed to open account storage: %v", err)
+	}
+	defer accounts.Close()
+	var mailer auth.Mailer = auth.LogMailer{ShowCodes: devMail}
+	if smtpAddr != "" {
+		mailer = &auth.SMTPMailer{Addr: smtpAddr, From: smtpFrom, Username: smtpUser, Password: os.Getenv("SMTP_PASSWORD")}
+	} else if !devMail {
+		log.Printf("warning: no -smtp-addr; mail is logged with verification codes redacted, so nobody can verify (-dev-mail logs them)")
+	}
+	accountService := auth.NewService(accounts, mailer)
+	if !webhookAllowPrivate {
+		accountService.CheckWebhookHosts(notify.PublicHost)
+	}
+	authHandler := auth.NewHandler(accountService)
+	go accountService.RunSweeper(context.Background(), sweepInterval)
+
+	watcher := notify.NewWatcher(accountService, map[string]notify.Channel{
+		auth.ChannelEmail:   notify.Email{Mailer: mailer},
+		auth.ChannelWebhook: notify.Webhook{AllowPrivate: webhookAllowPrivate},
+	})
+	dataset.OnSwap(func(old, next *data.Store) {
+		diff := data.Diff(old, next)
+		if err := changeLog.Append(diff, time.Now()); err != nil {
+			log.Printf("warning: failed to record catalog changes: %v", err)
+		} else if !diff.Empty() {
+			log.Printf("catalog changed: %d courses added, %d removed; %d sections added, %d removed, %d changed",
+				len(diff.CoursesAdded), len(diff.CoursesRemoved), len(diff.SectionsAdded), len(diff.SectionsRemoved), len(diff.SectionsChanged))
+		}
+		if n, err := watcher.CheckSchedules(diff); err != nil {
+			log.Printf("schedule alert check failed: %v", err)
+		} else if n > 0 {
+			log.Printf("recorded %d schedule alerts", n)
+		}
+		if n, err := watcher.Check(next); err != nil {
+			log.Printf("watchlist check failed: %v", err)
+		} else if n > 0 {
+			log.Printf("notified %d users about watched sections", n)
+		}
+	})
+	if watchInterval > 0 {
+		go watcher.Run(context.Background(), watchInterval, dataset.Store)
+	}
+
+	shares, err := share.NewStore(sharesDir)
+	if err != nil {
+		log.Fatalf("failed to open shares directory: %v", err)
+	}
 
 	r := mux.NewRouter()
+	r.Use(authHandler.Middleware)
 
 	apiRouter := r.PathPrefix("/api").Subrouter()
 	apiRouter.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
@@ -55,17 +245,76 @@ func main() {
 		_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 	}).Methods(http.MethodGet)
 
-	handler := api.NewHandler(store)
+	handler := api.NewHandler(dataset, catalogs, accountService, shares, seatHistory, changeLog, prereqs, brand)
+	if termOverride {
+		handler.ServeTerm(term)
+	}
 	apiRouter.HandleFunc("/search", handler.HandleSearch).Methods(http.MethodGet, http.MethodOptions)
 	apiRouter.HandleFunc("/departments", handler.HandleDepartments).Methods(http.MethodGet, http.MethodOptions)
 	apiRouter.HandleFunc("/campuses", handler.HandleCampuses).Methods(http.MethodGet, http.MethodOptions)
 	apiRouter.HandleFunc("/course/{id}/sections", handler.HandleCourseSections).Methods(http.MethodGet, http.MethodOptions)
+	apiRouter.HandleFunc("/section/{id}/seats", handler.HandleSectionSeats).Methods(http.MethodGet, http.MethodOptions)
+	apiRouter.HandleFunc("/changes", handler.HandleChanges).Methods(http.MethodGet, http.MethodOptions)
 	apiRouter.HandleFunc("/schedule/pdf", handler.HandleSchedulePDF).Methods(http.MethodGet, http.MethodOptions)
 	apiRouter.HandleFunc("/schedule/html", handler.HandleScheduleHTML).Methods(http.MethodGet, http.MethodOptions)
 	apiRouter.HandleFunc("/schedule/svg", handler.HandleScheduleSVG).Methods(http.MethodGet, http.MethodOptions)
+	apiRouter.HandleFunc("/schedule/png", handler.HandleSchedulePNG).Methods(http.MethodGet, http.MethodOptions)
+	apiRouter.HandleFunc("/schedule/validate", handler.HandleValidateSchedule).Methods(http.MethodGet, http.MethodOptions)
+	apiRouter.HandleFunc("/schedule/worksheet", handler.HandleScheduleWorksheet).Methods(http.MethodGet, http.MethodOptions)
 	apiRouter.HandleFunc("/schedule/pdf-from-image", handler.HandlePDFFromImage).Methods(http.MethodPost, http.MethodOptions)
+
+	apiRouter.HandleFunc("/auth/signup", authHandler.HandleSignup).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/auth/verify", authHandler.HandleVerify).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/auth/resend", authHandler.HandleResend).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/auth/login", authHandler.HandleLogin).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/auth/logout", authHandler.HandleLogout).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/auth/reset/request", authHandler.HandleResetRequest).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/auth/reset/confirm", authHandler.HandleResetConfirm).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/auth/me", auth.RequireUser(authHandler.HandleMe)).Methods(http.MethodGet)
+
+	apiRouter.HandleFunc("/me/email", auth.RequireUser(authHandler.HandleEmailChange)).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/me/email/confirm", auth.RequireUser(authHandler.HandleEmailChangeConfirm)).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/me/sessions", auth.RequireUser(authHandler.HandleListSessions)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/me/sessions", auth.RequireUser(authHandler.HandleRevokeSessions)).Methods(http.MethodDelete)
+	apiRouter.HandleFunc("/me/sessions/{id}", auth.RequireUser(authHandler.HandleRevokeSession)).Methods(http.MethodDelete)
+
+	apiRouter.HandleFunc("/me/schedules", auth.RequireUser(handler.HandleListSchedules)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/me/schedules", auth.RequireUser(handler.HandleCreateSchedule)).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/me/schedules/{id}", auth.RequireUser(handler.HandleGetSchedule)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/me/schedules/{id}", auth.RequireUser(handler.HandleUpdateSchedule)).Methods(http.MethodPatch)
+	apiRouter.HandleFunc("/me/schedules/{id}", auth.RequireUser(handler.HandleDeleteSchedule)).Methods(http.MethodDelete)
+	apiRouter.HandleFunc("/me/schedules/{id}/duplicate", auth.RequireUser(handler.HandleDuplicateSchedule)).Methods(http.MethodPost)
+
+	apiRouter.HandleFunc("/me/friends", auth.RequireUser(handler.HandleListFriends)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/me/friends", auth.RequireUser(handler.HandleAddFriend)).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/me/friends/{id}/accept", auth.RequireUser(handler.HandleAcceptFriend)).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/me/friends/{id}", auth.RequireUser(handler.HandleRemoveFriend)).Methods(http.MethodDelete)
+	apiRouter.HandleFunc("/friends/schedules", auth.RequireUser(handler.HandleFriendSchedules)).Methods(http.MethodGet)
+
+	apiRouter.HandleFunc("/me/watches", auth.RequireUser(handler.HandleListWatches)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/me/watches", auth.RequireUser(handler.HandleAddWatch)).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/me/watches/{id}", auth.RequireUser(handler.HandleRemoveWatch)).Methods(http.MethodDelete)
+	apiRouter.HandleFunc("/me/notify", auth.RequireUser(handler.HandleGetNotifySettings)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/me/notify", auth.RequireUser(handler.HandleUpdateNotifySettings)).Methods(http.MethodPatch)
+	apiRouter.HandleFunc("/me/alerts", auth.RequireUser(handler.HandleListAlerts)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/me/alerts/read", auth.RequireUser(handler.HandleReadAlerts)).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/me/alerts/{id}", auth.RequireUser(handler.HandleDeleteAlert)).Methods(http.MethodDelete)
+
+	apiRouter.HandleFunc("/me/plan", auth.RequireUser(handler.HandleGetPlan)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/me/plan", auth.RequireUser(handler.HandleSavePlan)).Methods(http.MethodPut)
+	apiRouter.HandleFunc("/me/plan", auth.RequireUser(handler.HandleDeletePlan)).Methods(http.MethodDelete)
+
+	apiRouter.HandleFunc("/share", auth.RequireUser(handler.HandleCreateShare)).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/share/{id}", auth.RequireUser(handler.HandleUpdateShare)).Methods(http.MethodPatch)
+	apiRouter.HandleFunc("/share/{id}", auth.RequireUser(handler.HandleRevokeShare)).Methods(http.MethodDelete)
+	apiRouter.HandleFunc("/me/shares", auth.RequireUser(handler.HandleListShares)).Methods(http.MethodGet)
 	apiRouter.Methods(http.MethodOptions).HandlerFunc(handler.HandleOptions)
 
+	// Public read-only share pages
+	r.HandleFunc("/s/{id}", handler.HandleSharePage).Methods(http.MethodGet)
+	r.HandleFunc("/s/{id}/preview.svg", handler.HandleSharePreview).Methods(http.MethodGet)
+	r.HandleFunc("/s/{id}/preview.png", handler.HandleSharePreviewPNG).Methods(http.MethodGet)
+
 	// Serve static files
 	absStatic, _ := filepath.Abs(staticDir)
 	r.PathPrefix("/").Handler(http.FileServer(http.Dir(absStatic)))
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"purdue_schedule/internal/auth"
+
+	"github.com/gorilla/mux"
+)
+
+// AlertView is a schedule alert as returned to the user.
+type AlertView struct {
+	Id        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	SectionId string    `json:"sectionId"`
+	Crn       string    `json:"crn"`
+	Course    string    `json:"course"`
+	Schedules []string  `json:"schedules"`
+	Field     string    `json:"field"`
+	Old       string    `json:"old"`
+	New       string    `json:"new"`
+	Message   string    `json:"message"`
+	Read      bool      `json:"read"`
+}
+
+func viewAlert(a auth.Alert) AlertView {
+	return AlertView{
+		Id:        a.Id,
+		CreatedAt: a.CreatedAt,
+		SectionId: a.SectionId,
+		Crn:       a.Crn,
+		Course:    a.Course,
+		Schedules: a.Schedules,
+		Field:     a.Field,
+		Old:       a.Old,
+		New:       a.New,
+		Message:   a.Message,
+		Read:      a.Read,
+	}
+}
+
+// GET /api/me/alerts?unread=true
+func (h *Handler) HandleListAlerts(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	unread := r.URL.Query().Get("unread") == "true"
+	out := []AlertView{}
+	for _, a := range h.accounts.Alerts(u) {
+		if unread && a.Read {
+			continue
+		}
+		out = append(out, viewAlert(a))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+type readAlertsRequest struct {
+	Ids []string `json:"ids"`
+}
+
+// POST /api/me/alerts/read
+func (h *Handler) HandleReadAlerts(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	var req readAlertsRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+			return
+		}
+	}
+	n, err := h.accounts.MarkAlertsRead(u, req.Ids)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"read": n})
+}
+
+// DELETE /api/me/alerts/{id}
+func (h *Handler) HandleDeleteAlert(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	err := h.accounts.DeleteAlert(u, mux.Vars(r)["id"])
+	if errors.Is(err, auth.ErrAlertNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/jpeg" // logo formats
+	_ "image/png"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Branding is the title bar and footer of the exports. The server sets it
+// with -branding, a JSON file:
+//
+//	{"title": "BoilerSchedule",
+//	 "subtitle": "Purdue University Course Schedule - {term}",
+//	 "footer": "Made with BoilerSchedule\nNot affiliated with Purdue University",
+//	 "logo": "logo.png", "accent": "#CFB991", "bar": "#000000", "barText": "#CFB991"}
+//
+// {term} anywhere in the text is the term being served. Colors replace the
+// theme's; empty ones keep it. Requests override the text and colors with
+// title=, subtitle=, footer=, accent=, bar= and barText=, and hide the
+// logo with logo=none.
+type Branding struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle"`
+	Footer   string `json:"footer"` // lines separated by "\n"
+	Logo     string `json:"logo"`   // PNG or JPEG file, read at startup
+	Accent   string `json:"accent"`
+	Bar      string `json:"bar"`
+	BarText  string `json:"barText"`
+
+	logo       []byte
+	logoType   string // "PNG" or "JPG", as gofpdf names them
+	logoWidth  int    // pixels
+	logoHeight int
+}
+
+// DefaultBranding is used when the server has no -branding file.
+func DefaultBranding() Branding {
+	return Branding{
+		Title:    "BoilerSchedule",
+		Subtitle: "Purdue University Course Schedule - {term}",
+		Footer:   "Made with BoilerSchedule\nNot affiliated with Purdue University",
+	}
+}
+
+// LoadBranding reads a branding file over the defaults, and the logo it
+// names relative to it.
+func LoadBranding(path string) (Branding, error) {
+	b := DefaultBranding()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return b, err
+	}
+	if err := json.Unmarshal(raw, &b); err != nil {
+		return b, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, c := range []string{b.Accent, b.Bar, b.BarText} {
+		if c != "" && !hexColor.MatchString(c) {
+			return b, fmt.Errorf("%s: bad color %q (want #RRGGBB)", path, c)
+		}
+	}
+	if b.Logo != "" {
+		logo := b.Logo
+		if !strings.HasPrefix(logo, "/") {
+			logo = strings.TrimSuffix(path, baseName(path)) + logo
+		}
+		if b.logo, err = os.ReadFile(logo); err != nil {
+			return b, fmt.Errorf("%s: logo: %w", path, err)
+		}
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(b.logo))
+		if err != nil || cfg.Width == 0 || cfg.Height == 0 {
+			return b, fmt.Errorf("%s: logo %s is not a PNG or JPEG image", path, logo)
+		}
+		b.logoType = map[string]string{"png": "PNG", "jpeg": "JPG"}[format]
+		b.logoWidth, b.logoHeight = cfg.Width, cfg.Height
+	}
+	return b, nil
+}
+
+func baseName(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+var hexColor = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// forRequest applies a request's overrides and fills in the term.
+func (b Branding) forRequest(q url.Values, term string) (Branding, error) {
+	for _, f := range []struct {
+		param string
+		field *string
+		color bool
+	}{
+		{"title", &b.Title, false},
+		{"subtitle", &b.Subtitle, false},
+		{"footer", &b.Footer, false},
+		{"accent", &b.Accent, true},
+		{"bar", &b.Bar, true},
+		{"barText", &b.BarText, true},
+	} {
+		if !q.Has(f.param) {
+			continue
+		}
+		v := strings.TrimSpace(q.Get(f.param))
+		if f.color && v != "" {
+			if !strings.HasPrefix(v, "#") {
+				v = "#" + v
+			}
+			if !hexColor.MatchString(v) {
+				return b, fmt.Errorf("invalid %s %q (want a color like #CFB991)", f.param, q.Get(f.param))
+			}
+		}
+		*f.field = v
+	}
+	if q.Get("logo") == "none" {
+		b.logo = nil
+	}
+	for _, s := range []*string{&b.Title, &b.Subtitle, &b.Footer} {
+		*s = expandTerm(*s, term)
+	}
+	return b, nil
+}
+
+// expandTerm puts the term in for {term}. Without a term, a separator left
+// dangling before it goes too: "Course Schedule - {term}" becomes
+// "Course Schedule".
+func expandTerm(s, term string) string {
+	if term == "" {
+		for _, sep := range []string{" - {term}", ", {term}", " {term}"} {
+			s = strings.ReplaceAll(s, sep, "")
+		}
+	}
+	return strings.ReplaceAll(s, "{term}", term)
+}
+
+// Theme recolors t with the branding's colors.
+func (b Branding) Theme(t Theme) Theme {
+	if b.Accent != "" {
+		t.Accent = b.Accent
+	}
+	if b.Bar != "" {
+		t.Bar = b.Bar
+	}
+	if b.BarText != "" {
+		t.BarText = b.BarText
+	}
+	return t
+}
+
+// FooterLines splits the footer into its lines.
+func (b Branding) FooterLines() []string {
+	if strings.TrimSpace(b.Footer) == "" {
+		return nil
+	}
+	return strings.Split(b.Footer, "\n")
+}
+
+// LogoURI is the logo as a data URI, or "" without one.
+func (b Branding) LogoURI() string {
+	if b.logo == nil {
+		return ""
+	}
+	mime := "image/png"
+	if b.logoType == "JPG" {
+		mime = "image/jpeg"
+	}
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(b.logo)
+}
+
+// branding is the server's branding for this request.
+func (h *Handler) branding(q url.Values) (Branding, error) {
+	return h.brand.forRequest(q, h.term())
+}
+
+// exportOptions reads the grid options and branding of an export request,
+// with the branding's colors on the theme.
+func (h *Handler) exportOptions(q url.Values) (GridOptions, Branding, error) {
+	grid, err := gridOptionsFromQuery(q)
+	if err != nil {
+		return grid, Branding{}, err
+	}
+	brand, err := h.branding(q)
+	if err != nil {
+		return grid, brand, err
+	}
+	grid.Theme = brand.Theme(grid.Theme)
+	return grid, brand, nil
+}
+
+// term names the term the served catalog covers: -term when given, or else
+// the name of the dataset's TermId, which may change on reload.
+func (h *Handler) term() string {
+	if h.termName != "" {
+		return h.termName
+	}
+	return h.store().TermName()
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"purdue_schedule/internal/data"
+)
+
+// defaultChangesWindow applies when /api/changes is called without since.
+const defaultChangesWindow = 7 * 24 * time.Hour
+
+// ChangeEntry is one reload's diff with its plain-text summary.
+type ChangeEntry struct {
+	Time    time.Time `json:"time"`
+	Summary []string  `json:"summary"`
+	data.Changeset
+}
+
+// ChangesReport is the response of /api/changes.
+type ChangesReport struct {
+	Since   time.Time     `json:"since"`
+	Entries []ChangeEntry `json:"entries"`
+}
+
+// parseSince accepts an RFC 3339 timestamp or a date.
+func parseSince(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, s, time.Local)
+}
+
+// GET /api/changes?since=&format=json|text
+func (h *Handler) HandleChanges(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	since := time.Now().Add(-defaultChangesWindow)
+	if v := strings.TrimSpace(q.Get("since")); v != "" {
+		t, err := parseSince(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "since must be an RFC 3339 time or a YYYY-MM-DD date"})
+			return
+		}
+		since = t
+	}
+	report := ChangesReport{Since: since, Entries: []ChangeEntry{}}
+	if h.changes != nil {
+		for _, e := range h.changes.Since(since) {
+			report.Entries = append(report.Entries, ChangeEntry{Time: e.Time, Summary: e.Summary(), Changeset: e.Changeset})
+		}
+	}
+
+	if q.Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if len(report.Entries) == 0 {
+			fmt.Fprintf(w, "No catalog changes since %s.\n", since.Local().Format("Jan 2, 2006 3:04 PM"))
+			return
+		}
+		for i, e := range report.Entries {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "Changes loaded %s\n", e.Time.Local().Format("Jan 2, 2006 3:04 PM"))
+			for _, line := range e.Summary {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
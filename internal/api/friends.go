@@ -0,0 +1,335 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"purdue_schedule/internal/auth"
+	"purdue_schedule/internal/data"
+
+	"github.com/gorilla/mux"
+)
+
+// Overlay colors: the signed-in user first, then one per friend
+var overlayColors = []string{"#8E6F3E", "#4169E1", "#DC143C", "#228B22", "#4B0082", "#FF8C00", "#008B8B", "#8B4513"}
+
+// FriendView is a connection as shown to the user holding it.
+type FriendView struct {
+	Id     string    `json:"id"`
+	Name   string    `json:"name"`
+	Email  string    `json:"email"`
+	Status string    `json:"status"`
+	Since  time.Time `json:"since"`
+}
+
+// FriendScheduleMatch is one friend's visible schedule compared with ours.
+type FriendScheduleMatch struct {
+	Id             string              `json:"id"`
+	Name           string              `json:"name"`
+	Term           string              `json:"term"`
+	SharedSections []auth.SavedSection `json:"sharedSections"`
+	SharedCourses  []string            `json:"sharedCourses"`
+	sections       []data.SectionInfo  // resolved, for the overlay
+}
+
+// FriendSchedules groups a friend's visible schedules.
+type FriendSchedules struct {
+	Id        string                `json:"id"`
+	Name      string                `json:"name"`
+	Schedules []FriendScheduleMatch `json:"schedules"`
+}
+
+// SectionFriends answers "who else is in my section" for one of our sections.
+type SectionFriends struct {
+	auth.SavedSection
+	Friends       []string `json:"friends"`       // in this exact section
+	CourseFriends []string `json:"courseFriends"` // in another section of the course
+}
+
+// FriendSchedulesReport is the response of /api/friends/schedules.
+type FriendSchedulesReport struct {
+	Schedule SavedScheduleView `json:"schedule"`
+	Sections []SectionFriends  `json:"sections"`
+	Friends  []FriendSchedules `json:"friends"`
+}
+
+func writeFriendError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidEmail), errors.Is(err, auth.ErrSelfFriend):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, auth.ErrFriendNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, auth.ErrTooManyFriends):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		writeScheduleError(w, err)
+	}
+}
+
+func (h *Handler) viewFriend(f auth.Friend) (FriendView, bool) {
+	// An outgoing request shows only the address asked, so it reads the
+	// same whether or not an account has that address
+	if f.Status == auth.FriendOutgoing && f.Email != "" {
+		return FriendView{Id: f.UserId, Email: f.Email, Status: f.Status, Since: f.Since}, true
+	}
+	other, err := h.accounts.UserById(f.UserId)
+	if err != nil {
+		return FriendView{}, false
+	}
+	return FriendView{Id: other.Id, Name: other.Name, Email: other.Email, Status: f.Status, Since: f.Since}, true
+}
+
+// GET /api/me/friends
+func (h *Handler) HandleListFriends(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	out := make([]FriendView, 0, len(u.Friends))
+	for _, f := range h.accounts.Friends(u) {
+		if v, ok := h.viewFriend(f); ok {
+			out = append(out, v)
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+type friendRequest struct {
+	Email string `json:"email"`
+}
+
+// maxFriendBodyBytes comfortably holds any email address.
+const maxFriendBodyBytes = 4 << 10
+
+// POST /api/me/friends
+func (h *Handler) HandleAddFriend(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	var req friendRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxFriendBodyBytes)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	f, err := h.accounts.AddFriend(u, req.Email)
+	if err != nil {
+		writeFriendError(w, err)
+		return
+	}
+	v, _ := h.viewFriend(*f)
+	writeJSON(w, http.StatusOK, v)
+}
+
+// POST /api/me/friends/{id}/accept
+func (h *Handler) HandleAcceptFriend(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	f, err := h.accounts.AcceptFriend(u, mux.Vars(r)["id"])
+	if err != nil {
+		writeFriendError(w, err)
+		return
+	}
+	v, _ := h.viewFriend(*f)
+	writeJSON(w, http.StatusOK, v)
+}
+
+// DELETE /api/me/friends/{id}
+func (h *Handler) HandleRemoveFriend(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	if err := h.accounts.RemoveFriend(u, mux.Vars(r)["id"]); err != nil {
+		writeFriendError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pickSchedule returns the schedule with id, or the primary one when id is
+// empty.
+func pickSchedule(schedules []auth.SavedSchedule, id string) (auth.SavedSchedule, bool) {
+	for _, sc := range schedules {
+		if (id == "" && sc.Primary) || (id != "" && sc.Id == id) {
+			return sc, true
+		}
+	}
+	if id == "" && len(schedules) > 0 {
+		return schedules[0], true
+	}
+	return auth.SavedSchedule{}, false
+}
+
+// courseKey identifies a saved section's course: the catalog course id when
+// the section still exists, else the label saved with it.
+func (h *Handler) courseKey(s auth.SavedSection) string {
+	if c, ok := h.store().CourseBySectionId(s.Id); ok {
+		return c.Id
+	}
+	return s.Course
+}
+
+// compareSchedules lists what theirs has in common with mine.
+func (h *Handler) compareSchedules(mine, theirs auth.SavedSchedule) FriendScheduleMatch {
+	m := FriendScheduleMatch{
+		Id:             theirs.Id,
+		Name:           theirs.Name,
+		Term:           theirs.Term,
+		SharedSections: []auth.SavedSection{},
+		SharedCourses:  []string{},
+	}
+	mySections := make(map[string]bool, len(mine.Sections))
+	myCourses := make(map[string]bool, len(mine.Sections))
+	for _, s := range mine.Sections {
+		mySections[s.Id] = true
+		myCourses[h.courseKey(s)] = true
+	}
+	seen := make(map[string]bool)
+	for _, s := range theirs.Sections {
+		if mySections[s.Id] {
+			m.SharedSections = append(m.SharedSections, s)
+		}
+		if key := h.courseKey(s); myCourses[key] && !seen[key] {
+			seen[key] = true
+			m.SharedCourses = append(m.SharedCourses, s.Course)
+		}
+	}
+	ids := make([]string, 0, len(theirs.Sections))
+	for _, s := range theirs.Sections {
+		ids = append(ids, s.Id)
+	}
+	m.sections, _ = h.store().ResolveSections(ids)
+	return m
+}
+
+// sameTerm treats an unset term as matching anything.
+func sameTerm(a, b string) bool {
+	return a == "" || b == "" || strings.EqualFold(a, b)
+}
+
+// GET /api/friends/schedules?schedule=&format=json|svg&friends=&width=&height=&days=&theme=&clock=&step=&from=&to=&times=
+func (h *Handler) HandleFriendSchedules(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	q := r.URL.Query()
+	mine, ok := pickSchedule(h.accounts.Schedules(u), q.Get("schedule"))
+	if !ok {
+		writeScheduleError(w, auth.ErrScheduleNotFound)
+		return
+	}
+	friends, err := h.accounts.FriendUsers(u)
+	if err != nil {
+		writeFriendError(w, err)
+		return
+	}
+	only := make(map[string]bool)
+	for _, id := range strings.Split(q.Get("friends"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			only[id] = true
+		}
+	}
+
+	report := FriendSchedulesReport{
+		Schedule: viewSavedSchedule(h.store(), mine),
+		Sections: make([]SectionFriends, 0, len(mine.Sections)),
+		Friends:  []FriendSchedules{},
+	}
+	for _, f := range friends {
+		if len(only) > 0 && !only[f.Id] {
+			continue
+		}
+		fs := FriendSchedules{Id: f.Id, Name: f.Name, Schedules: []FriendScheduleMatch{}}
+		for _, sc := range f.Schedules {
+			if auth.Shared(sc) && sameTerm(mine.Term, sc.Term) {
+				fs.Schedules = append(fs.Schedules, h.compareSchedules(mine, sc))
+			}
+		}
+		if len(fs.Schedules) > 0 {
+			report.Friends = append(report.Friends, fs)
+		}
+	}
+
+	for _, s := range mine.Sections {
+		sf := SectionFriends{SavedSection: s, Friends: []string{}, CourseFriends: []string{}}
+		key := h.courseKey(s)
+		for _, fs := range report.Friends {
+			inSection, inCourse := false, false
+			for _, m := range fs.Schedules {
+				for _, shared := range m.SharedSections {
+					inSection = inSection || shared.Id == s.Id
+				}
+				for _, sec := range m.sections {
+					if c, ok := h.store().CourseBySectionId(sec.Id); ok && c.Id == key {
+						inCourse = true
+					}
+				}
+			}
+			switch {
+			case inSection:
+				sf.Friends = append(sf.Friends, fs.Name)
+			case inCourse:
+				sf.CourseFriends = append(sf.CourseFriends, fs.Name)
+			}
+		}
+		report.Sections = append(report.Sections, sf)
+	}
+
+	if q.Get("format") == "svg" {
+		h.writeFriendOverlay(w, r, u, report)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// writeFriendOverlay draws our schedule and each friend's best matching
+// schedule on one grid, one color per person.
+func (h *Handler) writeFriendOverlay(w http.ResponseWriter, r *http.Request, u *auth.User, report FriendSchedulesReport) {
+	var mine []data.SectionInfo
+	for _, s := range report.Schedule.Sections {
+		if s.Section != nil {
+			mine = append(mine, *s.Section)
+		}
+	}
+	layers := []SVGLayer{{Label: "You", Color: overlayColors[0], Sections: mine}}
+	for _, fs := range report.Friends {
+		if len(layers) == len(overlayColors) {
+			break
+		}
+		best := fs.Schedules[0]
+		for _, m := range fs.Schedules[1:] {
+			if len(m.SharedSections) > len(best.SharedSections) ||
+				(len(m.SharedSections) == len(best.SharedSections) && len(m.SharedCourses) > len(best.SharedCourses)) {
+				best = m
+			}
+		}
+		layers = append(layers, SVGLayer{Label: fs.Name, Color: overlayColors[len(layers)], Sections: best.sections})
+	}
+
+	courses := make(map[string]data.CourseSummary)
+	for _, l := range layers {
+		for _, sec := range l.Sections {
+			if c, ok := h.store().CourseBySectionId(sec.Id); ok {
+				if c.SubjectAbbr == "" {
+					c.SubjectAbbr = h.store().SubjectAbbr(c.SubjectId)
+				}
+				courses[sec.Id] = c
+			}
+		}
+	}
+
+	grid, err := gridOptionsFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	width, height := 1000, 700
+	if v, err := strconv.Atoi(r.URL.Query().Get("width")); err == nil && v > 0 && v <= 4000 {
+		width = v
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("height")); err == nil && v > 0 && v <= 4000 {
+		height = v
+	}
+	svg, err := GenerateSVGSchedule(nil, courses, grid, width, height, layers...)
+	if err != nil {
+		log.Printf("friends overlay for %s: %v", u.Id, err)
+		http.Error(w, "failed to generate SVG", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	_, _ = w.Write([]byte(svg.Content))
+}
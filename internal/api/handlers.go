@@ -8,13 +8,22 @@ import (
 	"fmt"
 	"image"
 	"image/png"
+	"log"
 	"net/http"
-	"os"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"purdue_schedule/internal/auth"
+	"purdue_schedule/internal/changes"
 	"purdue_schedule/internal/data"
+	"purdue_schedule/internal/layout"
+	"purdue_schedule/internal/plan"
+	"purdue_schedule/internal/seats"
+	"purdue_schedule/internal/share"
 
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
@@ -23,32 +32,64 @@ import (
 )
 
 type Handler struct {
-	store *data.Store
+	dataset  *data.Dataset
+	accounts *auth.Service
+	shares   *share.Store
+	seats    *seats.History
+	changes  *changes.Log
+	catalogs *data.Catalogs
+	prereqs  plan.Prereqs
+	brand    Branding
+	termName string
 }
 
-func NewHandler(store *data.Store) *Handler {
-	return &Handler{store: store}
+// NewHandler serves dataset as the current term. catalogs holds every term
+// with loaded data, dataset's included; prereqs may be nil. brand titles
+// the schedule exports.
+func NewHandler(dataset *data.Dataset, catalogs *data.Catalogs, accounts *auth.Service, shares *share.Store, seatHistory *seats.History, changeLog *changes.Log, prereqs plan.Prereqs, brand Branding) *Handler {
+	return &Handler{dataset: dataset, catalogs: catalogs, accounts: accounts, shares: shares, seats: seatHistory, changes: changeLog, prereqs: prereqs, brand: brand}
+}
+
+// ServeTerm names the served term, overriding the name of the dataset's
+// TermId; for -term.
+func (h *Handler) ServeTerm(name string) {
+	h.termName = name
+}
+
+// store is the catalog currently served; it changes when the dataset is
+// reloaded.
+func (h *Handler) store() *data.Store {
+	return h.dataset.Store()
 }
 
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(v)
 }
 
-// GET /api/search?q=
+// GET /api/search?q=&campus=&open=true
 func (h *Handler) HandleSearch(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query().Get("q")
-	campus := r.URL.Query().Get("campus")
-	if strings.TrimSpace(campus) != "" {
-		res := h.store.SearchCoursesByCampus(q, 50, campus)
-		writeJSON(w, http.StatusOK, res)
+	campus := strings.TrimSpace(r.URL.Query().Get("campus"))
+	store := h.store()
+	if r.URL.Query().Get("open") != "true" {
+		writeJSON(w, http.StatusOK, store.SearchCoursesByCampus(q, 50, campus))
 		return
 	}
-	res := h.store.SearchCourses(q, 50)
+	// Search without a limit, then keep the first 50 courses with a seat left
+	res := make([]data.CourseSummary, 0, 50)
+	for _, c := range store.SearchCoursesByCampus(q, 0, campus) {
+		if store.HasOpenSection(c.Id, campus) {
+			res = append(res, c)
+			if len(res) == 50 {
+				break
+			}
+		}
+	}
 	writeJSON(w, http.StatusOK, res)
 }
 
@@ -56,48 +97,61 @@ func (h *Handler) HandleSearch(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) HandleDepartments(w http.ResponseWriter, r *http.Request) {
 	campus := r.URL.Query().Get("campus")
 	if strings.TrimSpace(campus) != "" {
-		departments := h.store.GetAllDepartmentsByCampus(campus)
+		departments := h.store().GetAllDepartmentsByCampus(campus)
 		writeJSON(w, http.StatusOK, departments)
 		return
 	}
-	departments := h.store.GetAllDepartments()
+	departments := h.store().GetAllDepartments()
 	writeJSON(w, http.StatusOK, departments)
 }
 
-// GET /api/course/{id}/sections
+// GET /api/course/{id}/sections?campus=&open=true&term=
 func (h *Handler) HandleCourseSections(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 	campus := r.URL.Query().Get("campus")
+	store := h.store()
+	if term := strings.TrimSpace(r.URL.Query().Get("term")); term != "" {
+		if store = h.catalogs.Store(term); store == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "no catalog loaded for term " + term})
+			return
+		}
+	}
 	var secs []data.SectionInfo
 	if strings.TrimSpace(campus) != "" {
-		secs = h.store.SectionsByCourseCampus(id, campus)
+		secs = store.SectionsByCourseCampus(id, campus)
 	} else {
-		secs = h.store.SectionsByCourse(id)
+		secs = store.SectionsByCourse(id)
 	}
 	if secs == nil {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": "course not found"})
 		return
 	}
+	if r.URL.Query().Get("open") == "true" {
+		secs = data.OpenSections(secs)
+	}
 	writeJSON(w, http.StatusOK, secs)
 }
 
 // OPTIONS handler for CORS preflight
 func (h *Handler) HandleOptions(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 	w.WriteHeader(http.StatusOK)
 }
 
 // GET /api/campuses
 func (h *Handler) HandleCampuses(w http.ResponseWriter, r *http.Request) {
-	_ = h.store.MaybeFetchCampuses()
-	writeJSON(w, http.StatusOK, h.store.GetCampuses())
+	_ = h.store().MaybeFetchCampuses()
+	writeJSON(w, http.StatusOK, h.store().GetCampuses())
 }
 
-// PDFSectionInfo represents section information for PDF generation
+// PDFSectionInfo represents section information for PDF generation. Id,
+// when it names a catalog section, totals its credits and hours from the
+// catalog.
 type PDFSectionInfo struct {
+	Id       string       `json:"id,omitempty"`
 	Course   string       `json:"course"`
 	Title    string       `json:"title"`
 	CRN      string       `json:"crn"`
@@ -121,7 +175,7 @@ type ImagePDFRequest struct {
 	Sections    []PDFSectionInfo `json:"sections"`
 }
 
-// POST /api/schedule/pdf-from-image
+// POST /api/schedule/pdf-from-image?title=&subtitle=&footer=&accent=&logo=none&paper=letter|a4|legal&orientation=landscape|portrait
 func (h *Handler) HandlePDFFromImage(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
@@ -137,6 +191,21 @@ func (h *Handler) HandlePDFFromImage(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if len(req.Sections) > maxSectionIds {
+		http.Error(w, fmt.Sprintf("at most %d sections may be given", maxSectionIds), http.StatusBadRequest)
+		return
+	}
+	brand, err := h.branding(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	theme := brand.Theme(defaultTheme)
+	paper, err := paperOptionsFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Decode base64 image
 	imageData := req.ImageData
@@ -157,33 +226,59 @@ func (h *Handler) HandlePDFFromImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create PDF in landscape mode
-	pdf := gofpdf.New("L", "mm", "Letter", "")
+	// Create PDF on the requested paper, landscape Letter by default
+	pdf := paper.newPDF()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle(brand.Title, true)
+	logo := pdfLogo(pdf, brand)
+	pageW, pageH := pdf.GetPageSize()
+
+	// Footer on every page; text that reaches it continues on the next
+	footer := brand.FooterLines()
+	footerSpace := 12 + 4*float64(len(footer))
+	pdf.SetAutoPageBreak(true, footerSpace)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-footerSpace + 4)
+		pdf.SetFont("Arial", "I", 9)
+		pdf.SetTextColor(100, 100, 100)
+		for _, line := range footer {
+			pdf.Cell(0, 6, tr(line))
+			pdf.Ln(4)
+		}
+	})
 
 	// PAGE 1: Header and Schedule
 	pdf.AddPage()
 
-	// Add Purdue-style header with black background
-	pdf.SetFillColor(0, 0, 0)      // Black background
-	pdf.Rect(0, 0, 279.4, 30, "F") // Full width black header
+	// Header bar in the branding's colors
+	pdf.SetFillColor(hexToRGB(theme.Bar))
+	pdf.Rect(0, 0, pageW, 30, "F") // Full width header
+	titleX := 15.0
+	if logo != "" {
+		info := pdf.GetImageInfo(logo)
+		logoW := min(22*info.Width()/info.Height(), 60)
+		pdf.ImageOptions(logo, titleX, 4, logoW, 0, false, gofpdf.ImageOptions{}, 0, "")
+		titleX += logoW + 4
+	}
 
-	pdf.SetTextColor(218, 165, 32) // Purdue gold text
+	pdf.SetTextColor(hexToRGB(theme.BarText))
 	pdf.SetFont("Arial", "B", 18)
-	pdf.SetXY(15, 8)
-	pdf.Cell(0, 8, "BoilerSchedule")
+	pdf.SetXY(titleX, 8)
+	pdf.Cell(0, 8, tr(brand.Title))
 	pdf.Ln(6)
-	pdf.SetXY(15, 16)
+	pdf.SetXY(titleX, 16)
 	pdf.SetFont("Arial", "", 12)
-	pdf.Cell(0, 6, "Purdue University Course Schedule - Fall 2025")
+	pdf.Cell(0, 6, tr(brand.Subtitle))
 
 	// Student info on the right side of header
+	infoX := pageW - 100
 	if req.StudentInfo.Name != "" {
-		pdf.SetXY(180, 8)
+		pdf.SetXY(infoX, 8)
 		pdf.SetFont("Arial", "", 11)
 		pdf.Cell(0, 5, fmt.Sprintf("Student: %s", req.StudentInfo.Name))
 
 		if req.StudentInfo.Major != "" || req.StudentInfo.Year != "" {
-			pdf.SetXY(180, 13)
+			pdf.SetXY(infoX, 13)
 			info := ""
 			if req.StudentInfo.Major != "" {
 				info = req.StudentInfo.Major
@@ -199,7 +294,7 @@ func (h *Handler) HandlePDFFromImage(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if req.StudentInfo.Email != "" {
-			pdf.SetXY(180, 18)
+			pdf.SetXY(infoX, 18)
 			pdf.Cell(0, 5, req.StudentInfo.Email)
 		}
 	}
@@ -207,18 +302,19 @@ func (h *Handler) HandlePDFFromImage(w http.ResponseWriter, r *http.Request) {
 	// Reset text color for rest of document
 	pdf.SetTextColor(0, 0, 0)
 
-	// Save image to temp file for PDF embedding
-	tempFile := "/tmp/schedule.png"
-	if err := savePNGToFile(imgBytes, tempFile); err != nil {
+	// Re-encode the image, so interlaced PNGs and JPEGs embed too, and hand
+	// it to gofpdf from memory; each request has its own document
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, img); err != nil {
 		http.Error(w, "Failed to process image", http.StatusInternalServerError)
 		return
 	}
+	pdf.RegisterImageOptionsReader("schedule", gofpdf.ImageOptions{ImageType: "PNG"}, &pngBuf)
 
 	// Calculate image dimensions to fit the rest of page 1
-	pageW, pageH := pdf.GetPageSize()
 	margin := 15.0
 	maxW := pageW - 2*margin
-	maxH := pageH - 70 // Leave room for header (30) and footer (40)
+	maxH := pageH - 40 - footerSpace - 5 // Leave room for header (30) and footer
 
 	imgW := float64(img.Bounds().Dx())
 	imgH := float64(img.Bounds().Dy())
@@ -239,23 +335,15 @@ func (h *Handler) HandlePDFFromImage(w http.ResponseWriter, r *http.Request) {
 	y := 40.0 // Start after header
 
 	// Add the schedule image
-	pdf.Image(tempFile, x, y, finalW, finalH, false, "PNG", 0, "")
-
-	// Add footer to page 1
-	pdf.SetY(-20)
-	pdf.SetFont("Arial", "I", 9)
-	pdf.SetTextColor(100, 100, 100)
-	pdf.Cell(0, 6, "Made by Umberto Puddu (upuddu@purdue.edu)")
-	pdf.Ln(4)
-	pdf.Cell(0, 6, "Not affiliated with Purdue University")
+	pdf.ImageOptions("schedule", x, y, finalW, finalH, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
 
 	// PAGE 2: Course Details and Summary
 	pdf.AddPage()
 
 	// Header for page 2
-	pdf.SetFillColor(0, 0, 0)
-	pdf.Rect(0, 0, 279.4, 25, "F")
-	pdf.SetTextColor(218, 165, 32)
+	pdf.SetFillColor(hexToRGB(theme.Bar))
+	pdf.Rect(0, 0, pageW, 25, "F")
+	pdf.SetTextColor(hexToRGB(theme.BarText))
 	pdf.SetFont("Arial", "B", 16)
 	pdf.SetXY(15, 8)
 	pdf.Cell(0, 8, "Course Details & Summary")
@@ -263,28 +351,7 @@ func (h *Handler) HandlePDFFromImage(w http.ResponseWriter, r *http.Request) {
 
 	pdf.SetY(35)
 
-	// Calculate totals
-	totalCredits := 0
-	totalHours := 0
-	for _, section := range req.Sections {
-		// Assume 3 credits per course if not specified
-		totalCredits += 3
-
-		// Calculate weekly hours for this section
-		for _, meeting := range section.Meetings {
-			if meeting.Start != "" && meeting.End != "" {
-				// Parse time and calculate duration
-				startHour, startMin := parseTime(meeting.Start)
-				endHour, endMin := parseTime(meeting.End)
-				if startHour >= 0 && endHour >= 0 {
-					duration := (endHour*60 + endMin) - (startHour*60 + startMin)
-					if duration > 0 {
-						totalHours += (duration / 60) * len(meeting.Days) // Hours per week
-					}
-				}
-			}
-		}
-	}
+	credits, estimated, minutes := h.imagePDFTotals(req.Sections)
 
 	// Summary section
 	pdf.SetFont("Arial", "B", 14)
@@ -294,9 +361,9 @@ func (h *Handler) HandlePDFFromImage(w http.ResponseWriter, r *http.Request) {
 	pdf.SetFont("Arial", "", 12)
 	pdf.Cell(0, 6, fmt.Sprintf("Total Courses: %d", len(req.Sections)))
 	pdf.Ln(6)
-	pdf.Cell(0, 6, fmt.Sprintf("Total Credits: %d", totalCredits))
+	pdf.Cell(0, 6, "Total Credits: "+creditsLabel(credits, estimated))
 	pdf.Ln(6)
-	pdf.Cell(0, 6, fmt.Sprintf("Total Weekly Hours: %d", totalHours))
+	pdf.Cell(0, 6, "Total Weekly Hours: "+formatHours(minutes))
 	pdf.Ln(12)
 
 	// Course details
@@ -305,6 +372,23 @@ func (h *Handler) HandlePDFFromImage(w http.ResponseWriter, r *http.Request) {
 	pdf.Ln(10)
 
 	for i, section := range req.Sections {
+		// Start a course on a new page when it would not fit on this one;
+		// one taller than a page breaks where it must
+		lines := 1
+		for _, meeting := range section.Meetings {
+			if len(meeting.Days) > 0 {
+				lines++
+			}
+			if meeting.Location != "" {
+				lines++
+			}
+			lines += len(meeting.Instructors)
+		}
+		if need := 8 + 5*float64(lines) + 3; pdf.GetY()+need > pageH-footerSpace && need < pageH-15-footerSpace {
+			pdf.AddPage()
+			pdf.SetY(15)
+		}
+
 		pdf.SetFont("Arial", "B", 12)
 		pdf.Cell(0, 6, fmt.Sprintf("%d. %s - %s", i+1, section.Course, section.Title))
 		pdf.Ln(8)
@@ -339,12 +423,6 @@ func (h *Handler) HandlePDFFromImage(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 		pdf.Ln(3)
-
-		// Check if we need a new page
-		if pdf.GetY() > 180 {
-			pdf.AddPage()
-			pdf.SetY(15)
-		}
 	}
 
 	// Output PDF
@@ -360,73 +438,47 @@ func (h *Handler) HandlePDFFromImage(w http.ResponseWriter, r *http.Request) {
 	w.Write(buf.Bytes())
 }
 
-// Helper function to save PNG bytes to file
-func savePNGToFile(data []byte, filename string) error {
-	img, err := png.Decode(bytes.NewReader(data))
-	if err != nil {
-		return err
-	}
-
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
+// imagePDFTotals adds up credits and weekly class minutes as the native
+// PDF does. Sections the catalog does not have are timed from their
+// meetings in the request, and their courses counted at 3 credits.
+func (h *Handler) imagePDFTotals(sections []PDFSectionInfo) (credits float64, estimated bool, minutes int) {
+	store := h.store()
+	var ids []string
+	for _, sec := range sections {
+		if sec.Id != "" {
+			ids = append(ids, sec.Id)
+		}
 	}
-	defer file.Close()
+	known := store.SectionsByIds(ids)
+	report := store.Validate(ids, data.ValidateOptions{})
+	credits, estimated, minutes = report.Credits, report.CreditsEstimated, weeklyMinutes(known)
 
-	return png.Encode(file, img)
-}
-
-// Helper function to parse time strings like "9:00 AM" or "14:30"
-func parseTime(timeStr string) (hour, minute int) {
-	timeStr = strings.TrimSpace(timeStr)
-	if timeStr == "" {
-		return -1, -1
+	counted := make(map[string]bool) // courses already in the catalog's total
+	found := make(map[string]bool, len(known))
+	for _, sec := range known {
+		found[sec.Id] = true
+		if c, ok := store.CourseBySectionId(sec.Id); ok {
+			counted[data.CourseLabel(store, c)] = true
+		}
 	}
-
-	// Handle AM/PM format
-	if strings.Contains(timeStr, "AM") || strings.Contains(timeStr, "PM") {
-		isPM := strings.Contains(timeStr, "PM")
-		timeStr = strings.ReplaceAll(timeStr, " AM", "")
-		timeStr = strings.ReplaceAll(timeStr, " PM", "")
-
-		parts := strings.Split(timeStr, ":")
-		if len(parts) != 2 {
-			return -1, -1
+	for _, sec := range sections {
+		if found[sec.Id] {
+			continue
 		}
-
-		h, err1 := strconv.Atoi(parts[0])
-		m, err2 := strconv.Atoi(parts[1])
-		if err1 != nil || err2 != nil {
-			return -1, -1
+		if !counted[sec.Course] {
+			counted[sec.Course] = true
+			credits += 3
+			estimated = true
 		}
-
-		if isPM && h != 12 {
-			h += 12
-		} else if !isPM && h == 12 {
-			h = 0
+		for _, m := range sec.Meetings {
+			start, ok1 := layout.ParseTime(m.Start)
+			end, ok2 := layout.ParseTime(m.End)
+			if ok1 && ok2 && end > start {
+				minutes += (end - start) * len(m.Days)
+			}
 		}
-
-		return h, m
-	}
-
-	// Handle 24-hour format
-	parts := strings.Split(timeStr, ":")
-	if len(parts) != 2 {
-		return -1, -1
 	}
-
-	h, err1 := strconv.Atoi(parts[0])
-	m, err2 := strconv.Atoi(parts[1])
-	if err1 != nil || err2 != nil {
-		return -1, -1
-	}
-
-	return h, m
-}
-
-// generateSVGBasedPDF creates a PDF using SVG rendering instead of Chrome
-func (h *Handler) generateSVGBasedPDF(w http.ResponseWriter, r *http.Request) error {
-	return generateSVGBasedPDFWithStore(w, r, h.store)
+	return credits, estimated, minutes
 }
 
 // StudentInfo represents student information for PDF generation
@@ -438,58 +490,138 @@ type StudentInfo struct {
 	Year      string
 }
 
-// GET /api/schedule/pdf?sections=sec1,sec2,...&studentName=...&studentEmail=...
+func studentInfoFromQuery(q url.Values) StudentInfo {
+	return StudentInfo{
+		Name:      q.Get("studentName"),
+		Email:     q.Get("studentEmail"),
+		StudentID: q.Get("studentId"),
+		Major:     q.Get("major"),
+		Year:      q.Get("year"),
+	}
+}
+
+// Detail joins the major and year as "Computer Engineering | Sophomore".
+func (s StudentInfo) Detail() string {
+	var parts []string
+	for _, p := range []string{s.Major, s.Year} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// GET /api/schedule/pdf?sections=sec1,sec2,...&studentName=...&studentEmail=...&days=mon-sat&theme=dark&clock=24h&step=15&from=7&to=21&times=1&renderer=chrome&title=&subtitle=&footer=&accent=&logo=none&paper=letter|a4|legal&orientation=landscape|portrait
+//
+// The PDF is drawn natively. renderer=chrome prints the HTML page with
+// headless Chrome instead, when Chrome is installed.
 func (h *Handler) HandleSchedulePDF(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	// Get the host from the request to build the URL for the HTML version
-	host := r.Host
-	if host == "" {
-		host = "localhost:8080"
+	q := r.URL.Query()
+	ids, err := sectionIdsFromQuery(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-
-	// Check if SVG format is requested
-	format := r.URL.Query().Get("format")
-	if format == "svg" {
-		// Use SVG-based PDF generation
-		err := h.generateSVGBasedPDF(w, r)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("failed to create SVG PDF: %v", err), http.StatusInternalServerError)
-		}
+	grid, brand, err := h.exportOptions(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	// Fallback to HTML-based PDF generation (Chrome)
-	htmlURL := fmt.Sprintf("http://%s/api/schedule/html?%s", host, r.URL.RawQuery)
-	pdfBytes, err := generatePDFFromHTML(htmlURL)
+	paper, err := paperOptionsFromQuery(q)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to create pdf: %v", err), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	// Extract student name for filename
-	studentName := r.URL.Query().Get("studentName")
+	store := h.store()
+	sections := store.SectionsByIds(ids)
+	if len(sections) == 0 {
+		http.Error(w, "no valid sections found", http.StatusBadRequest)
+		return
+	}
+	student := studentInfoFromQuery(q)
 	filename := "BoilerSchedule.pdf"
-	if studentName != "" {
-		// Sanitize filename
-		safeName := strings.ReplaceAll(studentName, " ", "_")
-		filename = fmt.Sprintf("BoilerSchedule_%s.pdf", safeName)
+	if safe := safeFilename(student.Name); safe != "" {
+		filename = fmt.Sprintf("BoilerSchedule_%s.pdf", safe)
+	}
+
+	if q.Get("renderer") == "chrome" {
+		if chromeInstalled() {
+			host := r.Host
+			if host == "" {
+				host = "localhost:8080"
+			}
+			pdfBytes, err := generatePDFFromHTML(fmt.Sprintf("http://%s/api/schedule/html?%s", host, r.URL.RawQuery), paper)
+			if err == nil {
+				w.Header().Set("Content-Type", "application/pdf")
+				w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+				_, _ = w.Write(pdfBytes)
+				return
+			}
+			log.Printf("chrome pdf failed, using the native renderer: %v", err)
+		}
 	}
 
+	courseBySection := make(map[string]data.CourseSummary, len(sections))
+	for _, s := range sections {
+		if c, ok := store.CourseBySectionId(s.Id); ok {
+			if c.SubjectAbbr == "" {
+				c.SubjectAbbr = store.SubjectAbbr(c.SubjectId)
+			}
+			courseBySection[s.Id] = c
+		}
+	}
+	report := store.Validate(ids, data.ValidateOptions{})
+	var buf bytes.Buffer
+	err = writeSchedulePDF(&buf, schedulePDF{
+		Student:          student,
+		Grid:             grid,
+		Brand:            brand,
+		Paper:            paper,
+		Sections:         sections,
+		Courses:          courseBySection,
+		Credits:          report.Credits,
+		CreditsEstimated: report.CreditsEstimated,
+		Findings:         exportFindings(report),
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to create pdf: %v", err), http.StatusInternalServerError)
+		return
+	}
 	w.Header().Set("Content-Type", "application/pdf")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
-	_, _ = w.Write(pdfBytes)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// safeFilename keeps letters, digits, dashes and underscores, turning
+// spaces into underscores, so a name can go into Content-Disposition.
+func safeFilename(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '_'
+		case r == '-' || r == '_' || r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			return r
+		default:
+			return -1
+		}
+	}, strings.TrimSpace(name))
 }
 
-// GET /api/schedule/html?sections=sec1,sec2,...&studentName=...&studentEmail=...
+// GET /api/schedule/html?sections=sec1,sec2,...&studentName=...&studentEmail=...&days=mon-sat&theme=dark&clock=24h&step=15&from=7&to=21&times=1&title=&subtitle=&footer=&accent=&logo=none
 func (h *Handler) HandleScheduleHTML(w http.ResponseWriter, r *http.Request) {
-	raw := r.URL.Query().Get("sections")
-	if strings.TrimSpace(raw) == "" {
-		http.Error(w, "sections query param required", http.StatusBadRequest)
+	ids, err := sectionIdsFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	ids := strings.Split(raw, ",")
-	sections := h.store.SectionsByIds(ids)
+	grid, brand, err := h.exportOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sections := h.store().SectionsByIds(ids)
 	if len(sections) == 0 {
 		http.Error(w, "no valid sections found", http.StatusBadRequest)
 		return
@@ -498,31 +630,33 @@ func (h *Handler) HandleScheduleHTML(w http.ResponseWriter, r *http.Request) {
 	// Build course mapping for label enrichment
 	courseBySection := make(map[string]data.CourseSummary, len(sections))
 	for _, s := range sections {
-		if c, ok := h.store.CourseBySectionId(s.Id); ok {
+		if c, ok := h.store().CourseBySectionId(s.Id); ok {
 			// Ensure subject abbreviation is present; fall back to store map
 			if c.SubjectAbbr == "" {
-				c.SubjectAbbr = h.store.SubjectAbbr(c.SubjectId)
+				c.SubjectAbbr = h.store().SubjectAbbr(c.SubjectId)
 			}
 			courseBySection[s.Id] = c
 		}
 	}
 
-	// Extract student information
-	studentInfo := StudentInfo{
-		Name:      r.URL.Query().Get("studentName"),
-		Email:     r.URL.Query().Get("studentEmail"),
-		StudentID: r.URL.Query().Get("studentId"),
-		Major:     r.URL.Query().Get("major"),
-		Year:      r.URL.Query().Get("year"),
-	}
-
-	htmlContent := generateScheduleHTML(sections, courseBySection, studentInfo)
+	studentInfo := studentInfoFromQuery(r.URL.Query())
+	report := h.store().Validate(ids, data.ValidateOptions{})
 
-	w.Header().Set("Content-Type", "text/html")
-	_, _ = w.Write([]byte(htmlContent))
+	var buf bytes.Buffer
+	if err := writeScheduleHTML(&buf, sections, courseBySection, grid, brand, studentInfo, exportFindings(report)); err != nil {
+		http.Error(w, fmt.Sprintf("failed to render schedule: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// Everything the page needs is inline; forbid fetching anything else
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; font-src data:; img-src data:")
+	_, _ = w.Write(buf.Bytes())
 }
 
-// GET /api/schedule/svg?sections=sec1,sec2,...&width=800&height=600
+// GET /api/schedule/svg?sections=sec1,sec2,...&width=800&height=600&days=mon-sat&theme=dark&clock=24h&step=15&from=7&to=21&times=1&header=1&title=&subtitle=&footer=&accent=&logo=none
+//
+// header=1 adds the branded title bar and footer around the grid; without
+// it the SVG is the bare grid the web app embeds.
 func (h *Handler) HandleScheduleSVG(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
@@ -533,14 +667,13 @@ func (h *Handler) HandleScheduleSVG(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	raw := r.URL.Query().Get("sections")
-	if strings.TrimSpace(raw) == "" {
-		http.Error(w, "sections query param required", http.StatusBadRequest)
+	ids, err := sectionIdsFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	ids := strings.Split(raw, ",")
-	sections := h.store.SectionsByIds(ids)
+	sections := h.store().SectionsByIds(ids)
 	if len(sections) == 0 {
 		http.Error(w, "no valid sections found", http.StatusBadRequest)
 		return
@@ -549,15 +682,21 @@ func (h *Handler) HandleScheduleSVG(w http.ResponseWriter, r *http.Request) {
 	// Build course mapping for label enrichment
 	courseBySection := make(map[string]data.CourseSummary, len(sections))
 	for _, s := range sections {
-		if c, ok := h.store.CourseBySectionId(s.Id); ok {
+		if c, ok := h.store().CourseBySectionId(s.Id); ok {
 			// Ensure subject abbreviation is present; fall back to store map
 			if c.SubjectAbbr == "" {
-				c.SubjectAbbr = h.store.SubjectAbbr(c.SubjectId)
+				c.SubjectAbbr = h.store().SubjectAbbr(c.SubjectId)
 			}
 			courseBySection[s.Id] = c
 		}
 	}
 
+	grid, brand, err := h.exportOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Parse dimensions
 	width := 800
 	height := 600
@@ -573,18 +712,70 @@ func (h *Handler) HandleScheduleSVG(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate SVG schedule
-	svgSchedule, err := GenerateSVGSchedule(sections, courseBySection, width, height)
+	svgSchedule, err := GenerateSVGSchedule(sections, courseBySection, grid, width, height)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("failed to generate SVG: %v", err), http.StatusInternalServerError)
 		return
 	}
+	if header := r.URL.Query().Get("header"); header == "1" || header == "true" {
+		svgSchedule = svgSchedule.WithBranding(brand, grid.Theme)
+	}
 
 	w.Header().Set("Content-Type", "image/svg+xml")
 	_, _ = w.Write([]byte(svgSchedule.Content))
 }
 
-// generatePDFFromHTML uses ChromeDP to convert HTML to PDF
-func generatePDFFromHTML(url string) ([]byte, error) {
+// GET /api/schedule/png?sections=sec1,sec2,...&preset=iphone-15|android|4k|og&days=&theme=&clock=&step=&from=&to=&times=
+//
+// Phone presets leave the lock screen's clock and buttons clear, so the
+// image works as a wallpaper.
+func (h *Handler) HandleSchedulePNG(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	q := r.URL.Query()
+	ids, err := sectionIdsFromQuery(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	preset, err := pngPresetByName(q.Get("preset"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	grid, err := gridOptionsFromQuery(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	store := h.store()
+	sections := store.SectionsByIds(ids)
+	if len(sections) == 0 {
+		http.Error(w, "no valid sections found", http.StatusBadRequest)
+		return
+	}
+	courseBySection := make(map[string]data.CourseSummary, len(sections))
+	for _, s := range sections {
+		if c, ok := store.CourseBySectionId(s.Id); ok {
+			if c.SubjectAbbr == "" {
+				c.SubjectAbbr = store.SubjectAbbr(c.SubjectId)
+			}
+			courseBySection[s.Id] = c
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeSchedulePNG(&buf, sections, courseBySection, grid, preset); err != nil {
+		http.Error(w, fmt.Sprintf("failed to render png: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=BoilerSchedule_%s.png", preset.Name))
+	_, _ = w.Write(buf.Bytes())
+}
+
+// generatePDFFromHTML uses ChromeDP to convert HTML to PDF on the given paper
+func generatePDFFromHTML(url string, paper PaperOptions) ([]byte, error) {
 	// Create context
 	ctx, cancel := chromedp.NewContext(context.Background())
 	defer cancel()
@@ -602,9 +793,9 @@ func generatePDFFromHTML(url string) ([]byte, error) {
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			var err error
 			pdfBytes, _, err = page.PrintToPDF().
-				WithPaperWidth(11).   // Letter width in inches
-				WithPaperHeight(8.5). // Letter height in inches (landscape)
-				WithLandscape(true).
+				WithPaperWidth(paper.Size.Width). // portrait, in inches; Chrome turns it
+				WithPaperHeight(paper.Size.Height).
+				WithLandscape(paper.Landscape).
 				WithPrintBackground(true).
 				WithMarginTop(0.4).
 				WithMarginBottom(0.4).
@@ -622,290 +813,6 @@ func generatePDFFromHTML(url string) ([]byte, error) {
 	return pdfBytes, nil
 }
 
-type event struct {
-	Section  data.SectionInfo
-	Course   *data.CourseSummary // optional enrichment
-	DayIdx   int
-	StartMin int
-	EndMin   int
-	Meeting  data.MeetingInfo
-}
-
-// generateScheduleHTML creates HTML that mimics the React schedule view
-func generateScheduleHTML(sections []data.SectionInfo, courseBySection map[string]data.CourseSummary, studentInfo StudentInfo) string {
-	return fmt.Sprintf(`<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>BoilerSchedule - %s</title>
-    <script src="https://cdn.tailwindcss.com"></script>
-    <script>
-        tailwind.config = {
-            theme: {
-                extend: {
-                    colors: {
-                        'purdue-gold': '#CFB991',
-                        'purdue-black': '#000000',
-                        'purdue-gray': '#5B6870'
-                    }
-                }
-            }
-        }
-    </script>
-    <style>
-        @import url('https://fonts.googleapis.com/css2?family=Inter:wght@400;500;600;700&display=swap');
-        body { font-family: 'Inter', sans-serif; }
-        @media print {
-            body { -webkit-print-color-adjust: exact; }
-            .no-print { display: none !important; }
-        }
-    </style>
-</head>
-<body class="bg-gray-50 text-gray-900">
-    <!-- Header -->
-    <div class="bg-black text-purdue-gold p-6">
-        <div class="flex justify-between items-center">
-            <div>
-                <h1 class="text-3xl font-bold">BoilerSchedule</h1>
-                <p class="text-sm opacity-90">Purdue University Course Schedule - Fall 2025</p>
-            </div>
-            %s
-        </div>
-    </div>
-
-    <!-- Schedule Container -->
-    <div class="p-6">
-        <h2 class="text-xl font-semibold mb-4 text-center">Schedule Preview</h2>
-        <div class="bg-white rounded-lg shadow-lg overflow-hidden">
-            %s
-        </div>
-    </div>
-
-    <!-- Footer -->
-    <div class="bg-gray-100 p-4 text-center text-xs text-gray-600">
-        <p>Generated by BoilerSchedule - Made by <a href="mailto:upuddu@purdue.edu" class="text-purdue-gold">Umberto Puddu</a></p>
-        <p class="mt-1">Not affiliated with Purdue University</p>
-    </div>
-</body>
-</html>`, studentInfo.Name, generateStudentInfoHTML(studentInfo), generateScheduleGridHTML(sections, courseBySection))
-}
-
-func generateStudentInfoHTML(studentInfo StudentInfo) string {
-	if studentInfo.Name == "" {
-		return ""
-	}
-
-	html := fmt.Sprintf(`<div class="text-right">
-        <div class="font-semibold">Student: %s</div>`, studentInfo.Name)
-
-	if studentInfo.Major != "" || studentInfo.Year != "" {
-		info := ""
-		if studentInfo.Major != "" {
-			info = studentInfo.Major
-		}
-		if studentInfo.Year != "" {
-			if info != "" {
-				info += " | " + studentInfo.Year
-			} else {
-				info = studentInfo.Year
-			}
-		}
-		html += fmt.Sprintf(`<div class="text-sm opacity-90">%s</div>`, info)
-	}
-
-	if studentInfo.Email != "" {
-		html += fmt.Sprintf(`<div class="text-sm opacity-90">%s</div>`, studentInfo.Email)
-	}
-
-	html += `</div>`
-	return html
-}
-
-func generateScheduleGridHTML(sections []data.SectionInfo, courseBySection map[string]data.CourseSummary) string {
-	// Build events similar to the React component
-	events := make([]scheduleEvent, 0)
-	for _, s := range sections {
-		course := courseBySection[s.Id]
-		for _, m := range s.Meetings {
-			if len(m.Days) == 0 || m.Start == "" || m.DurationMin == 0 {
-				continue
-			}
-			startMin, ok := parseTimeToMinutes(m.Start)
-			if !ok {
-				continue
-			}
-
-			for _, day := range m.Days {
-				dayIndex := getDayIndex(day)
-				if dayIndex >= 0 && dayIndex <= 4 { // Mon-Fri only
-					events = append(events, scheduleEvent{
-						Section:  s,
-						Course:   course,
-						Meeting:  m,
-						DayIndex: dayIndex,
-						StartMin: startMin,
-						EndMin:   startMin + m.DurationMin,
-					})
-				}
-			}
-		}
-	}
-
-	if len(events) == 0 {
-		return `<div class="p-8 text-center text-gray-500">No classes to display</div>`
-	}
-
-	// Calculate time range
-	minTime := 24 * 60
-	maxTime := 0
-	for _, event := range events {
-		if event.StartMin < minTime {
-			minTime = event.StartMin
-		}
-		if event.EndMin > maxTime {
-			maxTime = event.EndMin
-		}
-	}
-
-	// Add 30-minute padding
-	minTime = ((minTime - 30) / 30) * 30
-	maxTime = ((maxTime + 30) / 30) * 30
-	if minTime < 0 {
-		minTime = 0
-	}
-	if maxTime > 24*60 {
-		maxTime = 24 * 60
-	}
-
-	totalMinutes := maxTime - minTime
-	days := []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}
-
-	html := `<div class="relative bg-white" style="width: 100%; height: 600px;">
-        <!-- Time axis -->
-        <div class="absolute left-0 top-0 w-16 h-full bg-gray-50 border-r border-gray-200">
-            <div class="h-12 border-b border-gray-300"></div>`
-
-	// Time labels
-	for t := minTime; t <= maxTime; t += 60 {
-		top := float64(t-minTime)/float64(totalMinutes)*552 + 48 // 48px for header, 552px for grid
-		html += fmt.Sprintf(`<div class="absolute text-xs text-gray-600 right-2" style="top: %.1fpx; transform: translateY(-50%%);">%s</div>`,
-			top, formatTime12Hour(t))
-	}
-
-	html += `</div>
-        
-        <!-- Day columns -->
-        <div class="absolute left-16 top-0 right-0 h-full">
-            <!-- Day headers -->
-            <div class="h-12 bg-gray-100 border-b border-gray-300 flex">`
-
-	for i, day := range days {
-		html += fmt.Sprintf(`<div class="flex-1 flex items-center justify-center font-medium text-gray-700 %s">%s</div>`,
-			getBorderClass(i < 4), day)
-	}
-
-	html += `</div>
-            
-            <!-- Grid lines -->
-            <div class="relative h-full">`
-
-	// Horizontal grid lines
-	for t := minTime; t <= maxTime; t += 30 {
-		top := float64(t-minTime) / float64(totalMinutes) * 552
-		lineClass := "border-gray-200"
-		if t%60 == 0 {
-			lineClass = "border-gray-300"
-		}
-		html += fmt.Sprintf(`<div class="absolute w-full border-t %s" style="top: %.1fpx;"></div>`, lineClass, top)
-	}
-
-	// Vertical grid lines
-	for i := 0; i <= 4; i++ {
-		left := float64(i) * 20 // 20% per column
-		html += fmt.Sprintf(`<div class="absolute h-full border-l border-gray-200" style="left: %.1f%%;"></div>`, left)
-	}
-
-	// Events
-	for _, event := range events {
-		top := float64(event.StartMin-minTime) / float64(totalMinutes) * 552
-		height := float64(event.EndMin-event.StartMin) / float64(totalMinutes) * 552
-		left := float64(event.DayIndex)*20 + 0.5 // 20% per column + small margin
-		width := 19                              // Slightly less than 20% to fit within column
-
-		// Get primary instructor
-		instructor := "TBA"
-		if len(event.Meeting.Instructors) > 0 {
-			instructor = event.Meeting.Instructors[0]
-			if len(instructor) > 15 {
-				instructor = instructor[:12] + "..."
-			}
-		}
-
-		// Type badge
-		badge := ""
-		if event.Section.Type != "" {
-			badge = strings.ToUpper(event.Section.Type)
-			if len(badge) > 3 {
-				badge = badge[:3]
-			}
-		}
-
-		html += fmt.Sprintf(`<div class="absolute bg-blue-100 border-2 border-blue-300 rounded-lg p-2 cursor-pointer hover:shadow-lg transition-all" 
-                style="top: %.1fpx; height: %.1fpx; left: %.1f%%; width: %.1f%%;">
-                %s
-                <div class="text-xs font-bold text-blue-900 leading-tight">%s %s</div>
-                <div class="text-xs text-blue-800 opacity-90 leading-tight mt-1">%s</div>
-            </div>`,
-			top, height, left, width,
-			generateBadgeHTML(badge),
-			event.Course.SubjectAbbr, event.Course.Number,
-			instructor)
-	}
-
-	html += `</div>
-        </div>
-    </div>`
-
-	return html
-}
-
-func generateBadgeHTML(badge string) string {
-	if badge == "" {
-		return ""
-	}
-	return fmt.Sprintf(`<div class="absolute top-1 right-1 px-1.5 py-0.5 rounded text-[9px] font-semibold bg-white/90 border border-gray-300 shadow-sm">%s</div>`, badge)
-}
-
-func parseTimeToMinutes(s string) (int, bool) {
-	if len(s) < 5 {
-		return 0, false
-	}
-	hh, err1 := strconv.Atoi(s[:2])
-	mm, err2 := strconv.Atoi(s[3:5])
-	if err1 != nil || err2 != nil {
-		return 0, false
-	}
-	return hh*60 + mm, true
-}
-
-func getDayIndex(day string) int {
-	switch day {
-	case "Monday":
-		return 0
-	case "Tuesday":
-		return 1
-	case "Wednesday":
-		return 2
-	case "Thursday":
-		return 3
-	case "Friday":
-		return 4
-	default:
-		return -1
-	}
-}
-
 func formatTime12Hour(minutes int) string {
 	hours := minutes / 60
 	mins := minutes % 60
@@ -921,19 +828,3 @@ func formatTime12Hour(minutes int) string {
 	}
 	return fmt.Sprintf("%d:%02d %s", hours, mins, period)
 }
-
-func getBorderClass(needsBorder bool) string {
-	if needsBorder {
-		return "border-r border-gray-200"
-	}
-	return ""
-}
-
-type scheduleEvent struct {
-	Section  data.SectionInfo
-	Course   data.CourseSummary
-	Meeting  data.MeetingInfo
-	DayIndex int
-	StartMin int
-	EndMin   int
-}
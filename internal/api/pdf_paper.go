@@ -0,0 +1,62 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// PaperOptions are the page a PDF export is laid out on, from
+//
+//	paper=letter|a4|legal
+//	orientation=landscape|portrait
+//
+// Every PDF path defaults to Letter landscape, as does the zero value.
+type PaperOptions struct {
+	Size      paperSize
+	Landscape bool
+}
+
+type paperSize struct {
+	Name          string  // as gofpdf names it
+	Width, Height float64 // portrait, in inches
+}
+
+var paperSizes = map[string]paperSize{
+	"letter": {"Letter", 8.5, 11},
+	"a4":     {"A4", 8.27, 11.69},
+	"legal":  {"Legal", 8.5, 14},
+}
+
+func paperOptionsFromQuery(q url.Values) (PaperOptions, error) {
+	p := PaperOptions{Size: paperSizes["letter"], Landscape: true}
+	if v := strings.ToLower(strings.TrimSpace(q.Get("paper"))); v != "" {
+		size, ok := paperSizes[v]
+		if !ok {
+			return p, fmt.Errorf("invalid paper %q (want letter, a4 or legal)", q.Get("paper"))
+		}
+		p.Size = size
+	}
+	switch strings.ToLower(strings.TrimSpace(q.Get("orientation"))) {
+	case "", "landscape", "l":
+	case "portrait", "p":
+		p.Landscape = false
+	default:
+		return p, fmt.Errorf("invalid orientation %q (want landscape or portrait)", q.Get("orientation"))
+	}
+	return p, nil
+}
+
+// newPDF starts a document in millimeters on the paper.
+func (p PaperOptions) newPDF() *gofpdf.Fpdf {
+	if p.Size.Name == "" {
+		p = PaperOptions{Size: paperSizes["letter"], Landscape: true}
+	}
+	orientation := "P"
+	if p.Landscape {
+		orientation = "L"
+	}
+	return gofpdf.New(orientation, "mm", p.Size.Name, "")
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"purdue_schedule/internal/auth"
+	"purdue_schedule/internal/data"
+	"purdue_schedule/internal/plan"
+)
+
+// PlanBody is a plan of study as sent and received by the API.
+type PlanBody struct {
+	Terms            []PlanTermBody `json:"terms"`
+	Completed        []string       `json:"completed"`
+	CompletedCredits float64        `json:"completedCredits"`
+	TargetCredits    float64        `json:"targetCredits"`
+	UpdatedAt        *time.Time     `json:"updatedAt,omitempty"`
+}
+
+type PlanTermBody struct {
+	Name       string              `json:"name"`
+	MinCredits float64             `json:"minCredits"`
+	MaxCredits float64             `json:"maxCredits"`
+	Courses    []PlannedCourseBody `json:"courses"`
+}
+
+type PlannedCourseBody struct {
+	Course   string   `json:"course"`
+	Credits  float64  `json:"credits"`
+	Sections []string `json:"sections"` // section ids in the term's catalog
+}
+
+// PlanView is a saved plan with its check report and the terms that have
+// catalog data to link courses against.
+type PlanView struct {
+	Plan     PlanBody    `json:"plan"`
+	Report   plan.Report `json:"report"`
+	Catalogs []string    `json:"catalogs"`
+}
+
+func (h *Handler) viewPlan(p auth.Plan) PlanView {
+	body := PlanBody{
+		Terms:            make([]PlanTermBody, 0, len(p.Terms)),
+		Completed:        nonNil(p.Completed),
+		CompletedCredits: p.CompletedCredits,
+		TargetCredits:    p.TargetCredits,
+		UpdatedAt:        &p.UpdatedAt,
+	}
+	for _, t := range p.Terms {
+		tb := PlanTermBody{Name: t.Name, MinCredits: t.MinCredits, MaxCredits: t.MaxCredits, Courses: make([]PlannedCourseBody, 0, len(t.Courses))}
+		for _, c := range t.Courses {
+			tb.Courses = append(tb.Courses, PlannedCourseBody{Course: c.Course, Credits: c.Credits, Sections: nonNil(c.Sections)})
+		}
+		body.Terms = append(body.Terms, tb)
+	}
+	return PlanView{Plan: body, Report: plan.Check(p, h.catalogs, h.prereqs), Catalogs: h.catalogs.Terms()}
+}
+
+// toPlan normalizes course names in a plan sent by the client. Malformed
+// names are an error; courses missing from a catalog are only reported by
+// the check.
+func toPlan(b PlanBody) (auth.Plan, error) {
+	p := auth.Plan{
+		Terms:            make([]auth.PlanTerm, 0, len(b.Terms)),
+		CompletedCredits: b.CompletedCredits,
+		TargetCredits:    b.TargetCredits,
+	}
+	for _, raw := range b.Completed {
+		name, ok := data.NormalizeCourse(raw)
+		if !ok {
+			return auth.Plan{}, fmt.Errorf("%w: %q", auth.ErrPlanCourse, raw)
+		}
+		if !slices.Contains(p.Completed, name) {
+			p.Completed = append(p.Completed, name)
+		}
+	}
+	for _, tb := range b.Terms {
+		t := auth.PlanTerm{Name: tb.Name, MinCredits: tb.MinCredits, MaxCredits: tb.MaxCredits, Courses: make([]auth.PlannedCourse, 0, len(tb.Courses))}
+		for _, cb := range tb.Courses {
+			name, ok := data.NormalizeCourse(cb.Course)
+			if !ok {
+				return auth.Plan{}, fmt.Errorf("%w: %q", auth.ErrPlanCourse, cb.Course)
+			}
+			c := auth.PlannedCourse{Course: name, Credits: cb.Credits}
+			for _, id := range cb.Sections {
+				if id = strings.TrimSpace(id); id != "" && !slices.Contains(c.Sections, id) {
+					c.Sections = append(c.Sections, id)
+				}
+			}
+			t.Courses = append(t.Courses, c)
+		}
+		p.Terms = append(p.Terms, t)
+	}
+	return p, nil
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func writePlanError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrPlanNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, auth.ErrPlanTerm), errors.Is(err, auth.ErrPlanCourse),
+		errors.Is(err, auth.ErrPlanCredits), errors.Is(err, auth.ErrPlanSize):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		writeScheduleError(w, err)
+	}
+}
+
+// GET /api/me/plan
+func (h *Handler) HandleGetPlan(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	p, err := h.accounts.Plan(u)
+	if err != nil {
+		writePlanError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.viewPlan(*p))
+}
+
+// maxPlanBodyBytes comfortably holds a plan at every auth.MaxPlan* limit.
+const maxPlanBodyBytes = 256 << 10
+
+// PUT /api/me/plan
+func (h *Handler) HandleSavePlan(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	var body PlanBody
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPlanBodyBytes)).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	p, err := toPlan(body)
+	if err != nil {
+		writePlanError(w, err)
+		return
+	}
+	saved, err := h.accounts.SavePlan(u, p)
+	if err != nil {
+		writePlanError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.viewPlan(*saved))
+}
+
+// DELETE /api/me/plan
+func (h *Handler) HandleDeletePlan(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	if err := h.accounts.DeletePlan(u); err != nil {
+		writePlanError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
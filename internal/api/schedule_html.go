@@ -0,0 +1,251 @@
+package api
+
+import (
+	"embed"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"purdue_schedule/internal/data"
+	"purdue_schedule/internal/layout"
+)
+
+// The schedule page is rendered by headless Chrome for PDF export, often
+// without network access, so its stylesheet and fonts are embedded and
+// inlined rather than linked.
+//
+//go:embed assets/schedule.css assets/fonts/*.woff2
+var scheduleAssets embed.FS
+
+// scheduleCSS is the page stylesheet with the fonts inlined as data URIs.
+var scheduleCSS = template.CSS(buildScheduleCSS())
+
+func buildScheduleCSS() string {
+	var b strings.Builder
+	for _, f := range []struct {
+		file   string
+		weight int
+	}{
+		{"OpenSans-Regular.woff2", 400},
+		{"OpenSans-SemiBold.woff2", 600},
+		{"OpenSans-Bold.woff2", 700},
+	} {
+		font, err := scheduleAssets.ReadFile("assets/fonts/" + f.file)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Fprintf(&b, "@font-face { font-family: 'Open Sans'; font-style: normal; font-weight: %d; src: url(data:font/woff2;base64,%s) format('woff2'); }\n",
+			f.weight, base64.StdEncoding.EncodeToString(font))
+	}
+	css, err := scheduleAssets.ReadFile("assets/schedule.css")
+	if err != nil {
+		panic(err)
+	}
+	b.Write(css)
+	return b.String()
+}
+
+// The grid is 600px tall: a 48px row of day headers over 552px of time.
+const (
+	gridHeaderPx = 48
+	gridBodyPx   = 552
+)
+
+type scheduleHTMLData struct {
+	CSS         template.CSS
+	ThemeCSS    template.CSS // custom properties for the theme's colors
+	Brand       Branding
+	Logo        template.URL // data URI, or empty
+	Student     StudentInfo
+	Grid        *scheduleGrid
+	Unscheduled []UnscheduledMeeting
+	Findings    []data.Finding
+}
+
+// scheduleGrid holds positions in pixels from the top of the grid body and
+// percentages across the day columns.
+type scheduleGrid struct {
+	Days   []string
+	Times  []gridTime
+	Lines  []gridLine
+	VLines []float64
+	Events []gridEvent
+}
+
+type gridTime struct {
+	Top   float64
+	Label string
+}
+
+type gridLine struct {
+	Top  float64
+	Hour bool
+}
+
+type gridEvent struct {
+	Top, Height, Left, Width float64
+	Conflict                 bool
+	Color, Border, Text      string
+	Badge                    string
+	Course                   string
+	Times                    string // start and end, when times= is on
+	Instructor               string
+}
+
+// writeScheduleHTML renders the printable schedule page, with findings
+// listed under "Checks". All catalog and student text goes through
+// html/template, so it is escaped for the context it lands in.
+func writeScheduleHTML(w io.Writer, sections []data.SectionInfo, courseBySection map[string]data.CourseSummary, o GridOptions, brand Branding, studentInfo StudentInfo, findings []data.Finding) error {
+	o = o.normalized()
+	l := layout.Build(sections, courseBySection, o.layout())
+	return scheduleHTMLTemplate.Execute(w, scheduleHTMLData{
+		CSS:         scheduleCSS,
+		ThemeCSS:    themeCSS(o.Theme),
+		Brand:       brand,
+		Logo:        template.URL(brand.LogoURI()), // built from our own bytes
+		Student:     studentInfo,
+		Grid:        buildScheduleGrid(l, o),
+		Unscheduled: unscheduledMeetings(l, o),
+		Findings:    findings,
+	})
+}
+
+// buildScheduleGrid paints a layout in page units. It returns nil when no
+// meeting lands on the grid.
+func buildScheduleGrid(l *layout.Schedule, o GridOptions) *scheduleGrid {
+	if len(l.Blocks) == 0 {
+		return nil
+	}
+	y := func(t int) float64 {
+		return round1(l.Y(t) * gridBodyPx)
+	}
+
+	g := &scheduleGrid{}
+	for _, d := range l.Days {
+		g.Days = append(g.Days, layout.DayNames[d])
+	}
+	columnPct := 100 / float64(len(l.Days))
+	o.gridLines(l, func(t int, hour bool) {
+		if hour {
+			g.Times = append(g.Times, gridTime{Top: y(t) + gridHeaderPx, Label: o.hourLabel(t)})
+		}
+		g.Lines = append(g.Lines, gridLine{Top: y(t), Hour: hour})
+	})
+	for i := range g.Days {
+		g.VLines = append(g.VLines, round1(float64(i)*columnPct))
+	}
+	for _, b := range l.Blocks {
+		x, top, w, h := l.Place(b)
+		instructor := b.Instructor()
+		if r := []rune(instructor); len(r) > 15 {
+			instructor = string(r[:12]) + "..."
+		}
+		badge := typeBadge(b.Section.Type)
+		if b.Lanes > 2 {
+			badge = "" // no room beside the course name
+		}
+		color := o.Theme.CourseColor(b.ColorKey())
+		g.Events = append(g.Events, gridEvent{
+			Top:        round1(top * gridBodyPx),
+			Height:     round1(h * gridBodyPx),
+			Left:       round1(x*100 + 0.5), // small margin inside the lane
+			Width:      round1(w*100 - 1),
+			Conflict:   b.Conflict,
+			Color:      color,
+			Border:     shade(color, -30),
+			Text:       textColorFor(color),
+			Badge:      badge,
+			Course:     b.Label(),
+			Times:      o.blockTimes(b),
+			Instructor: instructor,
+		})
+	}
+	return g
+}
+
+// themeCSS sets the custom properties schedule.css takes its colors from.
+// Theme colors are our own "#RRGGBB" constants.
+func themeCSS(t Theme) template.CSS {
+	return template.CSS(fmt.Sprintf(":root { --page: %s; --grid: %s; --header: %s; --line: %s; --text: %s; --muted: %s; --bar: %s; --bar-text: %s; --accent: %s; --badge: %s; --badge-text: %s; --conflict: %s; }",
+		t.Page, t.Grid, t.Header, t.Line, t.Text, t.Muted, t.Bar, t.BarText, t.Accent, t.Badge, t.BadgeText, t.Conflict))
+}
+
+func round1(v float64) float64 {
+	return float64(int(v*10+0.5)) / 10
+}
+
+var scheduleHTMLTemplate = template.Must(template.New("schedule").Funcs(template.FuncMap{"findingLabel": findingLabel}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>{{.Brand.Title}}{{with .Student.Name}} - {{.}}{{end}}</title>
+<style>
+{{.ThemeCSS}}
+{{.CSS}}
+</style>
+</head>
+<body>
+<div class="header">
+<div class="brand">
+{{with .Logo}}<img class="logo" src="{{.}}" alt="">
+{{end}}<div>
+{{with .Brand.Title}}<h1>{{.}}</h1>
+{{end}}{{with .Brand.Subtitle}}<p>{{.}}</p>
+{{end}}</div>
+</div>
+{{if .Student.Name}}<div class="student">
+<div class="name">Student: {{.Student.Name}}</div>
+{{with .Student.Detail}}<div class="detail">{{.}}</div>
+{{end}}{{with .Student.Email}}<div class="detail">{{.}}</div>
+{{end}}</div>
+{{end}}</div>
+
+<div class="content">
+<h2>Schedule Preview</h2>
+<div class="card">
+{{with .Grid}}<div class="grid">
+<div class="times">
+<div class="corner"></div>
+{{range .Times}}<div class="time" style="top: {{.Top}}px">{{.Label}}</div>
+{{end}}</div>
+<div class="days">
+<div class="day-headers">
+{{range .Days}}<div class="day-header">{{.}}</div>
+{{end}}</div>
+<div class="lanes">
+{{range .Lines}}<div class="hline{{if .Hour}} hour{{end}}" style="top: {{.Top}}px"></div>
+{{end}}{{range .VLines}}<div class="vline" style="left: {{.}}%"></div>
+{{end}}{{range .Events}}<div class="event{{if .Conflict}} conflict{{end}}" style="top: {{.Top}}px; height: {{.Height}}px; left: {{.Left}}%; width: {{.Width}}%; background: {{.Color}}; border-color: {{.Border}}; color: {{.Text}}">
+{{with .Badge}}<div class="badge">{{.}}</div>
+{{end}}<div class="course">{{.Course}}</div>
+{{with .Times}}<div class="when">{{.}}</div>
+{{end}}<div class="instructor">{{.Instructor}}</div>
+</div>
+{{end}}</div>
+</div>
+</div>
+{{else}}<div class="empty">No classes to display</div>
+{{end}}</div>
+{{with .Unscheduled}}<div class="unscheduled">
+<h3>Not on the grid</h3>
+<ul>
+{{range .}}<li><span class="title">{{.Title}}</span> - {{.Detail}}</li>
+{{end}}</ul>
+</div>
+{{end}}{{with .Findings}}<div class="checks">
+<h3>Checks</h3>
+<ul>
+{{range .}}<li class="{{.Severity}}"><span class="title">{{findingLabel .}}:</span> {{.Message}}</li>
+{{end}}</ul>
+</div>
+{{end}}</div>
+
+{{with .Brand.FooterLines}}<div class="footer">
+{{range .}}<p>{{.}}</p>
+{{end}}</div>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"purdue_schedule/internal/layout"
+)
+
+// GridOptions are the display settings of the week grid. Every export
+// format takes them from the same query parameters:
+//
+//	days=mon-sat    day columns
+//	theme=dark      colors
+//	clock=24h       "14:30" instead of "2:30 PM"
+//	step=15|30|60   minutes between gridlines
+//	from=7&to=21    visible hours
+//	times=1         start and end time inside each block
+type GridOptions struct {
+	Days     []int // nil picks them from the schedule
+	Theme    Theme
+	Clock24  bool
+	Step     int  // minutes between gridlines
+	From, To *int // visible span in minutes from midnight; nil is automatic
+	Times    bool
+}
+
+// gridSteps are the gridline spacings step= accepts.
+var gridSteps = []int{15, 30, 60}
+
+const defaultGridStep = 30
+
+func gridOptionsFromQuery(q url.Values) (GridOptions, error) {
+	var o GridOptions
+	var err error
+	if o.Days, err = layout.ParseDays(q.Get("days")); err != nil {
+		return o, err
+	}
+	if o.Theme, err = themeByName(q.Get("theme")); err != nil {
+		return o, err
+	}
+	switch strings.ToLower(strings.TrimSpace(q.Get("clock"))) {
+	case "", "12h", "12":
+	case "24h", "24":
+		o.Clock24 = true
+	default:
+		return o, fmt.Errorf("invalid clock %q (want 12h or 24h)", q.Get("clock"))
+	}
+	if v := strings.TrimSpace(q.Get("step")); v != "" {
+		step, err := strconv.Atoi(v)
+		if err != nil || !slices.Contains(gridSteps, step) {
+			return o, fmt.Errorf("invalid step %q (want 15, 30 or 60)", v)
+		}
+		o.Step = step
+	}
+	if o.From, err = parseGridHour(q.Get("from"), "from", 0, 23); err != nil {
+		return o, err
+	}
+	if o.To, err = parseGridHour(q.Get("to"), "to", 1, 24); err != nil {
+		return o, err
+	}
+	if o.From != nil && o.To != nil && *o.To <= *o.From {
+		return o, fmt.Errorf("to= must be after from=")
+	}
+	o.Times = q.Get("times") == "1" || q.Get("times") == "true"
+	return o, nil
+}
+
+// parseGridHour reads from= or to= as an hour: "7", "19", "7pm" or "19:00".
+// Empty returns nil. The result is in minutes from midnight.
+func parseGridHour(s, name string, lo, hi int) (*int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return nil, nil
+	}
+	hour, err := strconv.Atoi(s)
+	if err != nil {
+		t := s
+		if !strings.Contains(t, ":") {
+			// "7pm" is "7:00pm"
+			n := strings.IndexFunc(t, func(r rune) bool { return r < '0' || r > '9' })
+			if n > 0 {
+				t = t[:n] + ":00" + t[n:]
+			}
+		}
+		minutes, ok := layout.ParseTime(t)
+		if !ok && t == "24:00" {
+			minutes, ok = 24*60, true
+		}
+		if !ok || minutes%60 != 0 {
+			return nil, fmt.Errorf("invalid %s %q (want a whole hour like 7 or 19:00)", name, s)
+		}
+		hour = minutes / 60
+	}
+	if hour < lo || hour > hi {
+		return nil, fmt.Errorf("%s must be between %d and %d", name, lo, hi)
+	}
+	minutes := hour * 60
+	return &minutes, nil
+}
+
+// normalized fills in the defaults, so options built in code work as well
+// as parsed ones.
+func (o GridOptions) normalized() GridOptions {
+	if o.Theme.Name == "" {
+		o.Theme = defaultTheme
+	}
+	if o.Step == 0 {
+		o.Step = defaultGridStep
+	}
+	return o
+}
+
+func (o GridOptions) layout() layout.Options {
+	return layout.Options{Days: o.Days, Start: o.From, End: o.To}
+}
+
+// clock formats a time of day: "2:30 PM", or "14:30" on the 24-hour clock.
+func (o GridOptions) clock(minutes int) string {
+	if o.Clock24 {
+		return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+	}
+	return formatTime12Hour(minutes)
+}
+
+// hourLabel is the label of an hour line: "2 PM", or "14:00".
+func (o GridOptions) hourLabel(minutes int) string {
+	if o.Clock24 {
+		return fmt.Sprintf("%02d:00", minutes/60)
+	}
+	return formatHour(minutes / 60)
+}
+
+// blockTimes is the time line of a block when times= is on, "9:30-10:20 AM"
+// or "09:30-10:20"; "" otherwise.
+func (o GridOptions) blockTimes(b layout.Block) string {
+	if !o.Times {
+		return ""
+	}
+	start, end := o.clock(b.Start), o.clock(b.End)
+	if !o.Clock24 && (b.Start < 12*60) == (b.End < 12*60) {
+		// One AM or PM for both ends
+		start = strings.TrimSuffix(strings.TrimSuffix(start, " AM"), " PM")
+	}
+	return start + "-" + end
+}
+
+// gridLines calls line for every gridline of the span, saying whether it is
+// on the hour.
+func (o GridOptions) gridLines(l *layout.Schedule, line func(minutes int, hour bool)) {
+	for t := l.Start; t <= l.End; t += o.Step {
+		line(t, t%60 == 0)
+	}
+}
@@ -0,0 +1,542 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"purdue_schedule/internal/data"
+	"purdue_schedule/internal/layout"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// schedulePDF is what the native PDF renderer draws.
+type schedulePDF struct {
+	Student          StudentInfo
+	Grid             GridOptions
+	Brand            Branding
+	Paper            PaperOptions
+	Sections         []data.SectionInfo
+	Courses          map[string]data.CourseSummary // by section id
+	Credits          float64
+	CreditsEstimated bool           // some courses were assumed to be 3 credits
+	Findings         []data.Finding // errors and warnings to print under "Checks"
+}
+
+// Page geometry in mm, on any paper
+const (
+	pdfMargin           = 12.0
+	pdfHeaderHeight     = 28.0
+	pdfFooterSpace      = 18.0
+	pdfGridHeaderHeight = 10.0 // day names over the grid
+
+	// Hour rows shrink to fit the page down to the height that still holds
+	// a course name and one more line; longer days continue on more pages.
+	pdfMinHourHeight = 8.0
+	pdfMaxHourHeight = 20.0
+)
+
+// writeSchedulePDF renders the schedule without a browser: the week grid on
+// the first page, or the first few when its hours do not fit one, then
+// course details and totals, breaking pages as needed.
+func writeSchedulePDF(w io.Writer, s schedulePDF) error {
+	pdf := s.Paper.newPDF()
+	// Catalog and student text is UTF-8; the core fonts are cp1252
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle(s.Brand.Title, true)
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfFooterSpace)
+	pdf.AliasNbPages("")
+	pageW, pageH := pdf.GetPageSize()
+	grid := s.Grid.normalized()
+	theme := grid.Theme
+	logo := pdfLogo(pdf, s.Brand)
+	footer := strings.Join(s.Brand.FooterLines(), " - ")
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFillColor(hexToRGB(theme.Page))
+		pdf.Rect(0, 0, pageW, pageH, "F")
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-14)
+		pdf.SetFont("Arial", "I", 8)
+		pdf.SetTextColor(hexToRGB(theme.Muted))
+		half := pageW/2 - pdfMargin
+		pdf.CellFormat(half, 5, fitText(pdf, tr(footer), half), "", 0, "L", false, 0, "")
+		pdf.CellFormat(half, 5, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	courses := groupByCourse(s.Sections, s.Courses)
+	summary := fmt.Sprintf("%d %s  |  %s  |  %s hours of class a week",
+		len(courses), plural(len(courses), "course", "courses"), creditsLabel(s.Credits, s.CreditsEstimated), formatHours(weeklyMinutes(s.Sections)))
+
+	// Page 1 on: the week
+	gridTop := pdfHeaderHeight + 11
+	gridPage := func(continued bool) {
+		pdf.AddPage()
+		drawPDFHeader(pdf, tr, theme, logo, s.Brand.Title, s.Brand.Subtitle, s.Student)
+		pdf.SetTextColor(hexToRGB(theme.Muted))
+		pdf.SetFont("Arial", "", 10)
+		pdf.SetXY(pdfMargin, pdfHeaderHeight+3)
+		line := summary
+		if continued {
+			line += "  |  continued"
+		}
+		pdf.CellFormat(0, 5, line, "", 0, "L", false, 0, "")
+	}
+	l := layout.Build(s.Sections, s.Courses, grid.layout())
+	unscheduled := unscheduledMeetings(l, grid)
+	var listHeight float64
+	if n := len(unscheduled); n > 0 {
+		listHeight = 8 + 5*float64(min(n, maxUnscheduledLines))
+	}
+
+	// Rows fit under the list of meetings not on the grid if they can, else
+	// the list moves to a page of its own, else the hours are split evenly
+	// over as few pages as keep the rows tall enough
+	room := pageH - gridTop - pdfFooterSpace - pdfGridHeaderHeight
+	hours := l.Hours()
+	perPage := hours
+	hourHeight := min(pdfMaxHourHeight, (room-listHeight)/float64(hours))
+	listBelow := hourHeight >= pdfMinHourHeight
+	if !listBelow {
+		hourHeight = min(pdfMaxHourHeight, room/float64(hours))
+	}
+	if hourHeight < pdfMinHourHeight {
+		fit := int(room / pdfMinHourHeight)
+		pages := (hours + fit - 1) / fit
+		perPage = (hours + pages - 1) / pages
+		hourHeight = min(pdfMaxHourHeight, room/float64(perPage))
+	}
+	var bottom float64
+	for start := l.Start; start < l.End; start += perPage * 60 {
+		end := min(start+perPage*60, l.End)
+		gridPage(start > l.Start)
+		part := l
+		if perPage < hours {
+			part = l.Window(start, end)
+		}
+		height := pdfGridHeaderHeight + float64(part.Hours())*hourHeight
+		bottom = drawPDFSchedule(pdf, tr, grid, part, pdfMargin, gridTop, pageW-2*pdfMargin, height)
+	}
+	if len(unscheduled) > 0 {
+		if !listBelow && bottom+listHeight > pageH-pdfFooterSpace {
+			gridPage(true)
+			bottom = gridTop - 3
+		}
+		drawPDFUnscheduled(pdf, tr, theme, unscheduled, bottom+3)
+	}
+
+	// Page 2 on: details
+	pdf.AddPage()
+	drawPDFHeader(pdf, tr, theme, logo, "Course Details & Summary", "", s.Student)
+	pdf.SetY(pdfHeaderHeight + 6)
+	pdf.SetTextColor(hexToRGB(theme.Text))
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(0, 8, "Summary", "", 1, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 11)
+	for _, line := range []string{
+		fmt.Sprintf("Total courses: %d (%d %s)", len(courses), len(s.Sections), plural(len(s.Sections), "section", "sections")),
+		"Total credits: " + creditsLabel(s.Credits, s.CreditsEstimated),
+		"Weekly class hours: " + formatHours(weeklyMinutes(s.Sections)),
+	} {
+		pdf.CellFormat(0, 6, line, "", 1, "L", false, 0, "")
+	}
+	if s.CreditsEstimated {
+		pdf.SetFont("Arial", "I", 9)
+		pdf.SetTextColor(hexToRGB(theme.Muted))
+		pdf.CellFormat(0, 5, "Courses without credit hours in the catalog are counted as 3 credits.", "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	if len(s.Findings) > 0 {
+		pdf.SetTextColor(hexToRGB(theme.Text))
+		pdf.SetFont("Arial", "B", 14)
+		pdf.CellFormat(0, 8, "Checks", "", 1, "L", false, 0, "")
+		pdf.SetFont("Arial", "", 10)
+		for _, f := range s.Findings {
+			color := theme.Text
+			if f.Severity == data.SeverityError {
+				color = theme.Conflict
+			}
+			pdf.SetTextColor(hexToRGB(color))
+			pdf.MultiCell(0, 5, tr(findingLabel(f)+": "+f.Message), "", "L", false)
+		}
+		pdf.Ln(4)
+	}
+
+	pdf.SetTextColor(hexToRGB(theme.Text))
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(0, 8, "Course Details", "", 1, "L", false, 0, "")
+	for i, c := range courses {
+		// Keep a course's heading with its sections where it fits on a page
+		lines := 1
+		for _, sec := range c.Sections {
+			lines += 1 + max(len(sec.Meetings), 1)
+		}
+		if need := float64(lines)*5 + 6; pdf.GetY()+need > pageH-pdfFooterSpace && need < pageH-2*pdfMargin-pdfFooterSpace {
+			pdf.AddPage()
+		}
+
+		pdf.SetTextColor(hexToRGB(theme.Text))
+		pdf.SetFont("Arial", "B", 12)
+		heading := fmt.Sprintf("%d. %s - %s", i+1, c.Label, c.Course.Title)
+		if c.Course.Credits > 0 {
+			heading += fmt.Sprintf(" (%s)", creditsLabel(c.Course.Credits, false))
+		}
+		pdf.MultiCell(0, 6, tr(heading), "", "L", false)
+		for _, sec := range c.Sections {
+			pdf.SetFont("Arial", "B", 10)
+			pdf.SetTextColor(hexToRGB(theme.Text))
+			line := fmt.Sprintf("   %s  |  CRN %s", sec.Type, sec.Crn)
+			if sec.StartDate != "" && sec.EndDate != "" {
+				line += fmt.Sprintf("  |  %s to %s", sec.StartDate, sec.EndDate)
+			}
+			pdf.CellFormat(0, 5, tr(line), "", 1, "L", false, 0, "")
+			pdf.SetFont("Arial", "", 10)
+			pdf.SetTextColor(hexToRGB(theme.Muted))
+			if len(sec.Meetings) == 0 {
+				pdf.CellFormat(0, 5, "      Time and place to be announced", "", 1, "L", false, 0, "")
+			}
+			for _, m := range sec.Meetings {
+				pdf.MultiCell(0, 5, tr("      "+meetingDetail(m)), "", "L", false)
+			}
+		}
+		pdf.Ln(3)
+	}
+
+	return pdf.Output(w)
+}
+
+// pdfCourse is one course and the chosen sections of it.
+type pdfCourse struct {
+	Label    string
+	Course   data.CourseSummary
+	Sections []data.SectionInfo
+}
+
+// groupByCourse groups sections by course in the order courses first
+// appear.
+func groupByCourse(sections []data.SectionInfo, courseBySection map[string]data.CourseSummary) []pdfCourse {
+	var out []pdfCourse
+	index := make(map[string]int)
+	for _, sec := range sections {
+		c := courseBySection[sec.Id]
+		i, ok := index[c.Id]
+		if !ok {
+			i = len(out)
+			index[c.Id] = i
+			label := strings.TrimSpace(c.SubjectAbbr + " " + c.Number)
+			if label == "" {
+				label = "Unknown course"
+			}
+			out = append(out, pdfCourse{Label: label, Course: c})
+		}
+		out[i].Sections = append(out[i].Sections, sec)
+	}
+	return out
+}
+
+// meetingDetail describes a meeting as "MWF 9:30 AM-10:20 AM  |  LWSN B151
+// |  Jane Doe, John Roe".
+func meetingDetail(m data.MeetingInfo) string {
+	when := "Time TBA"
+	if start, ok := m.StartMinutes(); ok && len(m.Days) > 0 {
+		when = fmt.Sprintf("%s %s-%s", data.DayLetters(m.Days), formatTime12Hour(start), formatTime12Hour(start+m.DurationMin))
+	}
+	where := strings.TrimSpace(m.BuildingCode + " " + m.RoomNumber)
+	if where == "" {
+		where = "Room TBA"
+	}
+	who := "Instructor TBA"
+	if len(m.Instructors) > 0 {
+		who = strings.Join(m.Instructors, ", ")
+	}
+	return when + "  |  " + where + "  |  " + who
+}
+
+// weeklyMinutes adds up scheduled class time in one week.
+func weeklyMinutes(sections []data.SectionInfo) int {
+	total := 0
+	for _, sec := range sections {
+		for _, m := range sec.Meetings {
+			if _, ok := m.StartMinutes(); ok {
+				total += m.DurationMin * len(m.Days)
+			}
+		}
+	}
+	return total
+}
+
+// formatHours shows minutes as hours to a tenth, dropping a trailing ".0".
+func formatHours(minutes int) string {
+	return strings.TrimSuffix(strconv.FormatFloat(float64(minutes)/60, 'f', 1, 64), ".0")
+}
+
+func creditsLabel(credits float64, estimated bool) string {
+	s := strconv.FormatFloat(credits, 'f', -1, 64) + " credits"
+	if credits == 1 {
+		s = "1 credit"
+	}
+	if estimated {
+		s += " (estimated)"
+	}
+	return s
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// pdfLogo registers the branding's logo with the document and returns its
+// image name, or "" without a logo.
+func pdfLogo(pdf *gofpdf.Fpdf, brand Branding) string {
+	if brand.logo == nil {
+		return ""
+	}
+	pdf.RegisterImageOptionsReader("logo", gofpdf.ImageOptions{ImageType: brand.logoType}, bytes.NewReader(brand.logo))
+	if pdf.Err() {
+		// A logo gofpdf cannot read is left out rather than failing the export
+		pdf.ClearError()
+		return ""
+	}
+	return "logo"
+}
+
+// drawPDFHeader draws the title bar, with the logo registered as logo, if
+// any, before the title and the student's details on the right.
+func drawPDFHeader(pdf *gofpdf.Fpdf, tr func(string) string, theme Theme, logo, title, subtitle string, st StudentInfo) {
+	pageW, _ := pdf.GetPageSize()
+	pdf.SetFillColor(hexToRGB(theme.Bar))
+	pdf.Rect(0, 0, pageW, pdfHeaderHeight, "F")
+	pdf.SetFillColor(hexToRGB(theme.Accent))
+	pdf.Rect(0, pdfHeaderHeight-0.8, pageW, 0.8, "F")
+	x := pdfMargin
+	if logo != "" {
+		h := pdfHeaderHeight - 8
+		info := pdf.GetImageInfo(logo)
+		w := min(h*info.Width()/info.Height(), pageW/4)
+		pdf.ImageOptions(logo, x, 4, w, 0, false, gofpdf.ImageOptions{}, 0, "")
+		x += w + 4
+	}
+	textW := pageW/2 - x
+	pdf.SetTextColor(hexToRGB(theme.BarText))
+	pdf.SetFont("Arial", "B", 20)
+	pdf.SetXY(x, 7)
+	pdf.CellFormat(textW, 8, fitText(pdf, tr(title), textW), "", 0, "L", false, 0, "")
+	if subtitle != "" {
+		pdf.SetFont("Arial", "", 11)
+		pdf.SetXY(x, 17)
+		pdf.CellFormat(textW, 6, fitText(pdf, tr(subtitle), textW), "", 0, "L", false, 0, "")
+	}
+
+	var lines []string
+	if st.Name != "" {
+		lines = append(lines, "Student: "+st.Name)
+	}
+	if d := st.Detail(); d != "" {
+		lines = append(lines, d)
+	}
+	if st.StudentID != "" {
+		lines = append(lines, "ID: "+st.StudentID)
+	}
+	if st.Email != "" {
+		lines = append(lines, st.Email)
+	}
+	half := pageW/2 - pdfMargin
+	for i, l := range lines {
+		if i == 0 {
+			pdf.SetFont("Arial", "B", 11)
+		} else {
+			pdf.SetFont("Arial", "", 10)
+		}
+		pdf.SetXY(pageW/2, 4.5+float64(i)*5.2)
+		pdf.CellFormat(half, 5, fitText(pdf, tr(l), half), "", 0, "R", false, 0, "")
+	}
+}
+
+// drawPDFSchedule paints a layout into the box at x0, y0, scaling the hour
+// rows to fit its height. It returns the bottom of the grid.
+func drawPDFSchedule(pdf *gofpdf.Fpdf, tr func(string) string, o GridOptions, l *layout.Schedule, x0, y0, width, height float64) float64 {
+	theme := o.Theme
+	const (
+		timeColumnWidth = 22.0
+		headerHeight    = pdfGridHeaderHeight
+	)
+	dayWidth := (width - timeColumnWidth) / float64(len(l.Days))
+	hours := l.Hours()
+	hourHeight := min(pdfMaxHourHeight, (height-headerHeight)/float64(hours))
+	bodyWidth := width - timeColumnWidth
+	bodyHeight := float64(hours) * hourHeight
+	gridHeight := headerHeight + bodyHeight
+
+	pdf.SetLineWidth(0.3)
+	pdf.SetFillColor(hexToRGB(theme.Grid))
+	pdf.SetDrawColor(hexToRGB(theme.Line))
+	pdf.RoundedRect(x0, y0, width, gridHeight, 2, "1234", "FD")
+
+	// Day headers
+	pdf.SetFont("Arial", "B", 11)
+	pdf.SetTextColor(hexToRGB(theme.Text))
+	pdf.SetDrawColor(hexToRGB(theme.Line))
+	pdf.Rect(x0, y0, timeColumnWidth, headerHeight, "D")
+	for i, d := range l.Days {
+		x := x0 + timeColumnWidth + float64(i)*dayWidth
+		pdf.SetFillColor(hexToRGB(theme.Header))
+		pdf.Rect(x, y0, dayWidth, headerHeight, "FD")
+		pdf.SetXY(x, y0)
+		pdf.CellFormat(dayWidth, headerHeight, layout.DayNames[d], "", 0, "CM", false, 0, "")
+	}
+
+	// Hour rows
+	pdf.SetFont("Arial", "", 9)
+	pdf.SetTextColor(hexToRGB(theme.Muted))
+	y := y0 + headerHeight
+	for t := l.Start; t < l.End; t += 60 {
+		pdf.SetDrawColor(hexToRGB(theme.Line))
+		pdf.SetFillColor(hexToRGB(theme.Header))
+		pdf.Rect(x0, y, timeColumnWidth, hourHeight, "FD")
+		pdf.SetXY(x0, y+1)
+		pdf.CellFormat(timeColumnWidth, 4, o.hourLabel(t), "", 0, "C", false, 0, "")
+		pdf.SetDrawColor(hexToRGB(theme.Line))
+		pdf.SetFillColor(hexToRGB(theme.Grid))
+		for i := range l.Days {
+			pdf.Rect(x0+timeColumnWidth+float64(i)*dayWidth, y, dayWidth, hourHeight, "FD")
+		}
+		y += hourHeight
+	}
+
+	// Dashed lines between the hours, every step
+	pdf.SetDrawColor(hexToRGB(theme.Line))
+	pdf.SetLineWidth(0.2)
+	pdf.SetDashPattern([]float64{0.8, 1.2}, 0)
+	o.gridLines(l, func(t int, hour bool) {
+		if !hour {
+			ly := y0 + headerHeight + l.Y(t)*bodyHeight
+			pdf.Line(x0+timeColumnWidth, ly, x0+width, ly)
+		}
+	})
+	pdf.SetDashPattern([]float64{}, 0)
+
+	// Class blocks
+	for _, b := range l.Blocks {
+		fx, fy, fw, fh := l.Place(b)
+		x := x0 + timeColumnWidth + fx*bodyWidth + 1.5
+		top := y0 + headerHeight + fy*bodyHeight + 0.5
+		h := max(fh*bodyHeight-1, 5)
+		w := fw*bodyWidth - 3
+		color := theme.CourseColor(b.ColorKey())
+
+		pdf.SetFillColor(hexToRGB(color))
+		pdf.SetDrawColor(hexToRGB(shade(color, -20)))
+		if b.Conflict {
+			pdf.SetDrawColor(hexToRGB(theme.Conflict))
+		}
+		pdf.SetLineWidth(0.5)
+		pdf.RoundedRect(x, top, w, h, 1.5, "1234", "FD")
+
+		textWidth := w - 3
+		if b.Section.Type != "" && h >= 8 && w >= 24 {
+			const badgeW, badgeH = 9.0, 4.0
+			bx := x + w - badgeW - 1
+			pdf.SetFillColor(hexToRGB(theme.Badge))
+			pdf.SetDrawColor(hexToRGB(theme.BadgeText))
+			pdf.SetLineWidth(0.2)
+			pdf.RoundedRect(bx, top+1, badgeW, badgeH, 1, "1234", "FD")
+			pdf.SetTextColor(hexToRGB(theme.BadgeText))
+			pdf.SetFont("Arial", "B", 6.5)
+			pdf.SetXY(bx, top+1)
+			pdf.CellFormat(badgeW, badgeH, tr(typeBadge(b.Section.Type)), "", 0, "CM", false, 0, "")
+			textWidth -= badgeW + 1
+		}
+
+		// As many lines as the block has room for
+		pdf.SetTextColor(hexToRGB(textColorFor(color)))
+		lines := []struct {
+			text  string
+			style string
+			size  float64
+			width float64
+		}{
+			{b.Label(), "B", 9, textWidth},
+			{o.blockTimes(b), "", 7.5, w - 3},
+			{b.Instructor(), "", 7.5, w - 3},
+			{b.Location(), "", 7, w - 3},
+		}
+		ty := top + 1
+		for _, line := range lines {
+			if line.text == "" || ty+4 > top+h {
+				continue
+			}
+			pdf.SetFont("Arial", line.style, line.size)
+			pdf.SetXY(x+1.5, ty)
+			pdf.CellFormat(line.width, 4, fitText(pdf, tr(line.text), line.width), "", 0, "L", false, 0, "")
+			ty += 4
+		}
+	}
+	return y0 + gridHeight
+}
+
+// drawPDFUnscheduled lists the meetings missing from the grid, starting at
+// y.
+func drawPDFUnscheduled(pdf *gofpdf.Fpdf, tr func(string) string, theme Theme, unscheduled []UnscheduledMeeting, y float64) {
+	pdf.SetXY(pdfMargin, y)
+	pdf.SetTextColor(hexToRGB(theme.Text))
+	pdf.SetFont("Arial", "B", 10)
+	pdf.CellFormat(0, 5, "Not on the grid", "", 1, "L", false, 0, "")
+	pdf.SetTextColor(hexToRGB(theme.Muted))
+	for i, u := range unscheduled {
+		if i == maxUnscheduledLines-1 && len(unscheduled) > maxUnscheduledLines {
+			pdf.SetFont("Arial", "I", 9)
+			pdf.CellFormat(0, 5, fmt.Sprintf("and %d more, listed with the course details", len(unscheduled)-i), "", 1, "L", false, 0, "")
+			break
+		}
+		pdf.SetFont("Arial", "B", 9)
+		title := tr(u.Title)
+		tw := pdf.GetStringWidth(title) + 1
+		pdf.CellFormat(tw, 5, title, "", 0, "L", false, 0, "")
+		pdf.SetFont("Arial", "", 9)
+		pdf.CellFormat(0, 5, tr("- "+u.Detail), "", 1, "L", false, 0, "")
+	}
+}
+
+// fitText shortens s with an ellipsis until it fits width in the current
+// font. s is already translated to the font's single-byte encoding.
+func fitText(pdf *gofpdf.Fpdf, s string, width float64) string {
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	for len(s) > 0 && pdf.GetStringWidth(s+"...") > width {
+		s = s[:len(s)-1]
+	}
+	return s + "..."
+}
+
+// hexToRGB parses "#RRGGBB", falling back to Purdue gold.
+func hexToRGB(hex string) (int, int, int) {
+	if len(hex) != 7 || hex[0] != '#' {
+		return 207, 185, 145
+	}
+	r, _ := strconv.ParseInt(hex[1:3], 16, 0)
+	g, _ := strconv.ParseInt(hex[3:5], 16, 0)
+	b, _ := strconv.ParseInt(hex[5:7], 16, 0)
+	return int(r), int(g), int(b)
+}
+
+// chromeInstalled reports whether a Chrome or Chromium binary is on PATH,
+// which the optional renderer=chrome export needs.
+var chromeInstalled = sync.OnceValue(func() bool {
+	for _, name := range []string{"google-chrome", "google-chrome-stable", "chromium", "chromium-browser", "chrome", "headless-shell"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return true
+		}
+	}
+	return false
+})
@@ -0,0 +1,284 @@
+package api
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"slices"
+	"strings"
+
+	"purdue_schedule/internal/data"
+	"purdue_schedule/internal/layout"
+)
+
+// pngPreset is an output size for PNG exports. Insets are in device pixels
+// and keep the grid clear of what a phone draws over its lock screen: the
+// clock and widgets on top, the flashlight and camera buttons below.
+type pngPreset struct {
+	Name                     string
+	Width, Height            int
+	Scale                    float64 // device pixels per layout pixel
+	Top, Right, Bottom, Left int
+}
+
+var pngPresets = []pngPreset{
+	{Name: "iphone-15", Width: 1179, Height: 2556, Scale: 2.5, Top: 820, Right: 36, Bottom: 300, Left: 36},
+	{Name: "android", Width: 1080, Height: 2400, Scale: 2.5, Top: 700, Right: 32, Bottom: 260, Left: 32},
+	{Name: "4k", Width: 3840, Height: 2160, Scale: 3, Top: 96, Right: 96, Bottom: 96, Left: 96},
+	// The size link previews use
+	{Name: "og", Width: 1200, Height: 630, Scale: 1.5, Top: 0, Right: 0, Bottom: 0, Left: 0},
+}
+
+// pngPresetByName finds a preset for the preset= parameter; empty is the
+// first one.
+func pngPresetByName(name string) (pngPreset, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return pngPresets[0], nil
+	}
+	i := slices.IndexFunc(pngPresets, func(p pngPreset) bool { return p.Name == name })
+	if i < 0 {
+		names := make([]string, len(pngPresets))
+		for i, p := range pngPresets {
+			names[i] = p.Name
+		}
+		return pngPreset{}, fmt.Errorf("unknown preset %q (want %s)", name, strings.Join(names, ", "))
+	}
+	return pngPresets[i], nil
+}
+
+// writeSchedulePNG paints the schedule layout with the SVG export's
+// geometry, scaled into the preset's safe area.
+func writeSchedulePNG(w io.Writer, sections []data.SectionInfo, courseBySection map[string]data.CourseSummary, o GridOptions, p pngPreset) error {
+	o = o.normalized()
+	c := &pngCanvas{
+		img:   image.NewRGBA(image.Rect(0, 0, p.Width, p.Height)),
+		scale: p.Scale,
+		ox:    float64(p.Left),
+		oy:    float64(p.Top),
+	}
+	draw.Draw(c.img, c.img.Bounds(), image.NewUniform(rgba(o.Theme.Page)), image.Point{}, draw.Src)
+	l := layout.Build(sections, courseBySection, o.layout())
+	width := math.Floor(float64(p.Width-p.Left-p.Right) / p.Scale)
+	height := math.Floor(float64(p.Height-p.Top-p.Bottom) / p.Scale)
+	paintSchedule(c, l, o, width, height)
+	return png.Encode(w, c.img)
+}
+
+// paintSchedule draws a layout the way generateSVGContent and
+// drawUnscheduled do, in a width by height box of layout pixels.
+func paintSchedule(c *pngCanvas, l *layout.Schedule, o GridOptions, width, height float64) {
+	theme := o.Theme
+	unscheduled := unscheduledMeetings(l, o)
+	gridHeight := height - float64(unscheduledFooterHeight(unscheduled))
+	bodyWidth := width - svgTimeColumnWidth
+	bodyHeight := gridHeight - svgHeaderHeight
+	dayWidth := bodyWidth / float64(len(l.Days))
+
+	c.fill(0, 0, width, gridHeight, theme.Grid)
+	c.fill(0, 0, svgTimeColumnWidth, gridHeight, theme.Header)
+	c.fill(svgTimeColumnWidth, 0, bodyWidth, svgHeaderHeight, theme.Header)
+
+	// Day names, abbreviated when the full name does not fit
+	for i, d := range l.Days {
+		name := layout.DayNames[d]
+		if c.textWidth(name, 14) > dayWidth-8 {
+			name = name[:3]
+		}
+		c.textCentered(svgTimeColumnWidth+(float64(i)+0.5)*dayWidth, svgHeaderHeight/2, name, 14, true, theme.Text)
+	}
+	o.gridLines(l, func(t int, hour bool) {
+		y := svgHeaderHeight + l.Y(t)*bodyHeight
+		if !hour {
+			// Dashed, like the SVG
+			for x := svgTimeColumnWidth; x < width; x += 5 {
+				c.hline(x, min(x+2, width), y, theme.Line)
+			}
+			return
+		}
+		c.hline(svgTimeColumnWidth, width, y, theme.Line)
+		if t < l.End {
+			c.textCentered(svgTimeColumnWidth/2, y+8, o.hourLabel(t), 12, false, theme.Muted)
+		}
+	})
+	for i := 0; i <= len(l.Days); i++ {
+		c.vline(svgTimeColumnWidth+float64(i)*dayWidth, 0, gridHeight, theme.Line)
+	}
+	c.fill(0, svgHeaderHeight-1, width, 2, theme.Accent)
+
+	for _, b := range l.Blocks {
+		x, y, w, h := l.Place(b)
+		paintEvent(c, b, o, svgTimeColumnWidth+x*bodyWidth, svgHeaderHeight+y*bodyHeight, w*bodyWidth-4, h*bodyHeight)
+	}
+
+	if len(unscheduled) > 0 {
+		top := gridHeight
+		c.fill(0, top, width, height-top, theme.Header)
+		c.text(10, top+10, "Not on the grid", 12, true, theme.Text)
+		for i, u := range unscheduled {
+			y := top + 26 + 16*float64(i)
+			if i == maxUnscheduledLines-1 && len(unscheduled) > maxUnscheduledLines {
+				c.text(10, y, fmt.Sprintf("and %d more", len(unscheduled)-i), 11, false, theme.Muted)
+				break
+			}
+			c.text(10, y, c.fit(u.Title+" - "+u.Detail, width-20, 11), 11, false, theme.Text)
+		}
+	}
+}
+
+// paintEvent draws a class block like drawEvent, in the box at x, y.
+func paintEvent(c *pngCanvas, b layout.Block, o GridOptions, x, y, width, height float64) {
+	theme := o.Theme
+	color := theme.CourseColor(b.ColorKey())
+	textColor := textColorFor(color)
+	outline := theme.Grid
+	if b.Conflict {
+		outline = theme.Conflict
+	}
+	c.roundRect(x+2, y+1, width-4, height-2, 4, outline)
+	c.roundRect(x+3.5, y+2.5, width-7, height-5, 3, color)
+
+	textWidth := width - 12
+	if badge := blockBadge(b, width, height); badge != "" {
+		bx, by := x+width-25, y+8
+		c.roundRect(bx, by, 20, 12, 2, theme.Badge)
+		c.textCentered(bx+10, by+6, badge, 8, true, theme.BadgeText)
+		textWidth -= 24
+	}
+	top := y + 10
+	if top+8 < y+height {
+		c.text(x+6, top, c.fit(b.Label(), textWidth, 12), 12, true, textColor)
+	}
+	// Then the time, instructor and location, as space allows
+	lineY := top
+	for _, line := range []struct {
+		text string
+		size float64
+	}{
+		{o.blockTimes(b), 10},
+		{b.Instructor(), 10},
+		{b.Location(), 9},
+	} {
+		if line.text == "" {
+			continue
+		}
+		lineY += 15
+		if lineY+8 > y+height {
+			break
+		}
+		c.text(x+6, lineY, c.fit(line.text, width-12, line.size), line.size, false, textColor)
+	}
+}
+
+// pngCanvas paints in layout pixels onto an image in device pixels.
+type pngCanvas struct {
+	img    *image.RGBA
+	scale  float64
+	ox, oy float64 // device position of the layout origin
+}
+
+func (c *pngCanvas) point(x, y float64) (int, int) {
+	return int(math.Round(c.ox + x*c.scale)), int(math.Round(c.oy + y*c.scale))
+}
+
+func (c *pngCanvas) fill(x, y, w, h float64, hex string) {
+	x0, y0 := c.point(x, y)
+	x1, y1 := c.point(x+w, y+h)
+	draw.Draw(c.img, image.Rect(x0, y0, x1, y1), image.NewUniform(rgba(hex)), image.Point{}, draw.Src)
+}
+
+// hline and vline draw grid lines a device pixel or two thick.
+func (c *pngCanvas) hline(x0, x1, y float64, hex string) {
+	c.fill(x0, y, x1-x0, c.lineWidth(), hex)
+}
+
+func (c *pngCanvas) vline(x, y0, y1 float64, hex string) {
+	c.fill(x, y0, c.lineWidth(), y1-y0, hex)
+}
+
+func (c *pngCanvas) lineWidth() float64 {
+	return math.Max(1, math.Floor(c.scale/2)) / c.scale
+}
+
+// roundRect fills a rectangle with corners of radius r, row by row.
+func (c *pngCanvas) roundRect(x, y, w, h, r float64, hex string) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	x0, y0 := c.point(x, y)
+	x1, y1 := c.point(x+w, y+h)
+	rd := math.Min(r*c.scale, math.Min(float64(x1-x0), float64(y1-y0))/2)
+	col := rgba(hex)
+	for py := y0; py < y1; py++ {
+		inset := 0
+		// Distance into the corner zone from the top or bottom edge
+		if dy := math.Max(float64(y0)+rd-(float64(py)+0.5), (float64(py)+0.5)-(float64(y1)-rd)); dy > 0 {
+			inset = int(math.Round(rd - math.Sqrt(math.Max(rd*rd-dy*dy, 0))))
+		}
+		draw.Draw(c.img, image.Rect(x0+inset, py, x1-inset, py+1), image.NewUniform(col), image.Point{}, draw.Src)
+	}
+}
+
+// unit is the device size of one font pixel for a font size in layout
+// pixels; the 5x7 glyphs are scaled by whole pixels to stay crisp.
+func (c *pngCanvas) unit(size float64) int {
+	return max(1, int(math.Round(size*c.scale/12)))
+}
+
+func (c *pngCanvas) textWidth(s string, size float64) float64 {
+	return float64(len([]rune(s))*glyphAdvance*c.unit(size)) / c.scale
+}
+
+// fit shortens s with "..." to fit width at the given size.
+func (c *pngCanvas) fit(s string, width, size float64) string {
+	if c.textWidth(s, size) <= width {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && c.textWidth(string(r)+"...", size) > width {
+		r = r[:len(r)-1]
+	}
+	if len(r) == 0 {
+		return ""
+	}
+	return string(r) + "..."
+}
+
+// text draws s with its top left corner at x, y. Bold doubles each stroke
+// one font pixel to the right.
+func (c *pngCanvas) text(x, y float64, s string, size float64, bold bool, hex string) {
+	u := c.unit(size)
+	px, py := c.point(x, y)
+	col := rgba(hex)
+	for _, r := range s {
+		g := glyph(r)
+		for gx, bits := range g {
+			for gy := 0; gy < 7; gy++ {
+				if bits&(1<<gy) == 0 {
+					continue
+				}
+				w := u
+				if bold {
+					w += max(1, u/2)
+				}
+				draw.Draw(c.img, image.Rect(px+gx*u, py+gy*u, px+gx*u+w, py+(gy+1)*u), image.NewUniform(col), image.Point{}, draw.Src)
+			}
+		}
+		px += glyphAdvance * u
+	}
+}
+
+// textCentered draws s centered on cx, cy.
+func (c *pngCanvas) textCentered(cx, cy float64, s string, size float64, bold bool, hex string) {
+	h := float64(7*c.unit(size)) / c.scale
+	c.text(cx-c.textWidth(s, size)/2, cy-h/2, s, size, bold, hex)
+}
+
+func rgba(hex string) color.RGBA {
+	r, g, b := hexToRGB(hex)
+	return color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 255}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Theme colors a schedule export. Every renderer takes its colors from
+// here, so theme= looks the same in SVG, HTML and PDF. Colors are "#RRGGBB".
+type Theme struct {
+	Name      string
+	Page      string // page background around the grid
+	Grid      string // grid background
+	Header    string // day headers and the time column
+	Line      string // grid lines
+	Text      string
+	Muted     string // time labels and secondary text
+	Bar       string // title bar
+	BarText   string
+	Accent    string // rules and links
+	Badge     string // section type badge
+	BadgeText string
+	Conflict  string // outline of overlapping blocks
+	Palette   []string
+}
+
+var scheduleThemes = []Theme{
+	{
+		Name: "purdue", Page: "#F9FAFB", Grid: "#FFFFFF", Header: "#F3F4F6", Line: "#E5E7EB",
+		Text: "#111827", Muted: "#4B5563", Bar: "#000000", BarText: "#CFB991", Accent: "#CFB991",
+		Badge: "#000000", BadgeText: "#CFB991", Conflict: "#DC2626",
+		// Purdue brand colors: Old Gold, Aged, Rush, Steel, Field, Cool Gray, Dust, Railway Gray
+		Palette: []string{"#CFB991", "#8E6F3E", "#DAAA00", "#555960", "#DDB945", "#6F727B", "#EBD99F", "#9D9795"},
+	},
+	{
+		Name: "high-contrast", Page: "#FFFFFF", Grid: "#FFFFFF", Header: "#FFFFFF", Line: "#000000",
+		Text: "#000000", Muted: "#000000", Bar: "#000000", BarText: "#FFFFFF", Accent: "#000000",
+		Badge: "#FFFFFF", BadgeText: "#000000", Conflict: "#D00000",
+		Palette: []string{"#000000", "#0033CC", "#B30000", "#006600", "#6600CC", "#FFD700", "#00A3CC", "#CC5500"},
+	},
+	{
+		Name: "grayscale", Page: "#FFFFFF", Grid: "#FFFFFF", Header: "#F0F0F0", Line: "#BBBBBB",
+		Text: "#000000", Muted: "#444444", Bar: "#FFFFFF", BarText: "#000000", Accent: "#000000",
+		Badge: "#FFFFFF", BadgeText: "#000000", Conflict: "#000000",
+		Palette: []string{"#D9D9D9", "#8C8C8C", "#BFBFBF", "#595959", "#A6A6A6", "#737373"},
+	},
+	{
+		Name: "dark", Page: "#0B0F19", Grid: "#111827", Header: "#1F2937", Line: "#374151",
+		Text: "#F9FAFB", Muted: "#9CA3AF", Bar: "#000000", BarText: "#CFB991", Accent: "#CFB991",
+		Badge: "#000000", BadgeText: "#CFB991", Conflict: "#F87171",
+		Palette: []string{"#2563EB", "#7C3AED", "#DB2777", "#059669", "#D97706", "#0891B2", "#CFB991", "#65A30D"},
+	},
+}
+
+// defaultTheme is Purdue gold and black.
+var defaultTheme = scheduleThemes[0]
+
+// themeByName finds a theme for the theme= parameter; empty is the default.
+func themeByName(name string) (Theme, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return defaultTheme, nil
+	}
+	i := slices.IndexFunc(scheduleThemes, func(t Theme) bool { return t.Name == name })
+	if i < 0 {
+		names := make([]string, len(scheduleThemes))
+		for i, t := range scheduleThemes {
+			names[i] = t.Name
+		}
+		return Theme{}, fmt.Errorf("unknown theme %q (want %s)", name, strings.Join(names, ", "))
+	}
+	return scheduleThemes[i], nil
+}
+
+// CourseColor picks the block color for a course from the palette by
+// hashing its id, so a course keeps its color across exports and reloads.
+func (t Theme) CourseColor(courseId string) string {
+	h := fnv.New32a()
+	h.Write([]byte(courseId))
+	return t.Palette[h.Sum32()%uint32(len(t.Palette))]
+}
+
+// textColorFor picks black or white text, whichever contrasts more with bg.
+func textColorFor(bg string) string {
+	// WCAG contrast against black is (L+0.05)/0.05 and against white
+	// 1.05/(L+0.05); they are equal at L = 0.179
+	if relativeLuminance(bg) > 0.179 {
+		return "#000000"
+	}
+	return "#FFFFFF"
+}
+
+// relativeLuminance is the WCAG relative luminance of a "#RRGGBB" color.
+func relativeLuminance(hex string) float64 {
+	r, g, b := hexToRGB(hex)
+	channel := func(v int) float64 {
+		c := float64(v) / 255
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(r) + 0.7152*channel(g) + 0.0722*channel(b)
+}
+
+// shade darkens (negative amount) or lightens a "#RRGGBB" color.
+func shade(hex string, amount int) string {
+	r, g, b := hexToRGB(hex)
+	clamp := func(v int) int { return min(max(v+amount, 0), 255) }
+	return fmt.Sprintf("#%02X%02X%02X", clamp(r), clamp(g), clamp(b))
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"purdue_schedule/internal/auth"
+	"purdue_schedule/internal/data"
+
+	"github.com/gorilla/mux"
+)
+
+// Section statuses reported when a saved schedule is read back
+const (
+	SectionOK      = "ok"
+	SectionMissing = "missing" // no longer in the catalog (cancelled or removed)
+	SectionChanged = "changed" // id now resolves to a different CRN
+)
+
+// SavedScheduleView is a saved schedule checked against the loaded catalog.
+type SavedScheduleView struct {
+	Id         string             `json:"id"`
+	Name       string             `json:"name"`
+	Term       string             `json:"term"`
+	Notes      string             `json:"notes"`
+	Primary    bool               `json:"primary"`
+	Visibility string             `json:"visibility"`
+	Sections   []SavedSectionView `json:"sections"`
+	Stale      bool               `json:"stale"`
+	CreatedAt  time.Time          `json:"createdAt"`
+	UpdatedAt  time.Time          `json:"updatedAt"`
+}
+
+// SavedSectionView pairs the labels stored with a schedule and the current
+// catalog entry, if there still is one.
+type SavedSectionView struct {
+	Id      string            `json:"id"`
+	Crn     string            `json:"crn"`
+	Course  string            `json:"course"`
+	Type    string            `json:"type"`
+	Status  string            `json:"status"`
+	Section *data.SectionInfo `json:"section,omitempty"`
+}
+
+type savedScheduleRequest struct {
+	Name       *string  `json:"name"`
+	Term       *string  `json:"term"`
+	Notes      *string  `json:"notes"`
+	Primary    *bool    `json:"primary"`
+	Visibility *string  `json:"visibility"`
+	SectionIds []string `json:"sectionIds"`
+}
+
+// snapshotSections resolves section ids against the catalog and records the
+// labels needed to describe them later. Unknown ids are an error.
+func snapshotSections(store *data.Store, ids []string) ([]auth.SavedSection, error) {
+	found, missing := store.ResolveSections(ids)
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("unknown section ids: %s", strings.Join(missing, ", "))
+	}
+	out := make([]auth.SavedSection, 0, len(found))
+	for _, sec := range found {
+		c, _ := store.CourseBySectionId(sec.Id)
+		out = append(out, auth.SavedSection{Id: sec.Id, Crn: sec.Crn, Course: data.CourseLabel(store, c), Type: sec.Type})
+	}
+	return out, nil
+}
+
+// viewSavedSchedule validates a saved schedule against the loaded catalog,
+// flagging sections that vanished or changed rather than dropping them.
+func viewSavedSchedule(store *data.Store, sc auth.SavedSchedule) SavedScheduleView {
+	v := SavedScheduleView{
+		Id:         sc.Id,
+		Name:       sc.Name,
+		Term:       sc.Term,
+		Notes:      sc.Notes,
+		Primary:    sc.Primary,
+		Visibility: auth.VisibilityPrivate,
+		Sections:   make([]SavedSectionView, 0, len(sc.Sections)),
+		CreatedAt:  sc.CreatedAt,
+		UpdatedAt:  sc.UpdatedAt,
+	}
+	if auth.Shared(sc) {
+		v.Visibility = auth.VisibilityFriends
+	}
+	for _, saved := range sc.Sections {
+		sv := SavedSectionView{Id: saved.Id, Crn: saved.Crn, Course: saved.Course, Type: saved.Type, Status: SectionOK}
+		sec, ok := store.SectionById(saved.Id)
+		switch {
+		case !ok:
+			sv.Status = SectionMissing
+		case saved.Crn != "" && sec.Crn != saved.Crn:
+			sv.Status = SectionChanged
+		}
+		if ok {
+			sv.Section = &sec
+		}
+		if sv.Status != SectionOK {
+			v.Stale = true
+		}
+		v.Sections = append(v.Sections, sv)
+	}
+	return v
+}
+
+func writeScheduleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrScheduleNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, auth.ErrScheduleName), errors.Is(err, auth.ErrVisibility), errors.Is(err, auth.ErrScheduleSize):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, auth.ErrTooManySchedules):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		log.Printf("schedules: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+	}
+}
+
+// GET /api/me/schedules
+func (h *Handler) HandleListSchedules(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	saved := h.accounts.Schedules(u)
+	out := make([]SavedScheduleView, 0, len(saved))
+	for _, sc := range saved {
+		out = append(out, viewSavedSchedule(h.store(), sc))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// maxScheduleBodyBytes comfortably holds a schedule at every
+// auth.MaxSchedule* limit.
+const maxScheduleBodyBytes = 64 << 10
+
+// POST /api/me/schedules
+func (h *Handler) HandleCreateSchedule(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	var req savedScheduleRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxScheduleBodyBytes)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	sections, err := snapshotSections(h.store(), req.SectionIds)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	sc, err := h.accounts.CreateSchedule(u, deref(req.Name), deref(req.Term), deref(req.Notes), deref(req.Visibility), req.Primary != nil && *req.Primary, sections)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, viewSavedSchedule(h.store(), *sc))
+}
+
+// GET /api/me/schedules/{id}
+func (h *Handler) HandleGetSchedule(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	id := mux.Vars(r)["id"]
+	for _, sc := range h.accounts.Schedules(u) {
+		if sc.Id == id {
+			writeJSON(w, http.StatusOK, viewSavedSchedule(h.store(), sc))
+			return
+		}
+	}
+	writeScheduleError(w, auth.ErrScheduleNotFound)
+}
+
+// PATCH /api/me/schedules/{id}
+func (h *Handler) HandleUpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	var req savedScheduleRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxScheduleBodyBytes)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	changes := auth.ScheduleChanges{Name: req.Name, Term: req.Term, Notes: req.Notes, Primary: req.Primary, Visibility: req.Visibility}
+	if req.SectionIds != nil {
+		sections, err := snapshotSections(h.store(), req.SectionIds)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		changes.Sections = sections
+	}
+	sc, err := h.accounts.UpdateSchedule(u, mux.Vars(r)["id"], changes)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, viewSavedSchedule(h.store(), *sc))
+}
+
+// POST /api/me/schedules/{id}/duplicate
+func (h *Handler) HandleDuplicateSchedule(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	var req savedScheduleRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxScheduleBodyBytes)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+			return
+		}
+	}
+	sc, err := h.accounts.DuplicateSchedule(u, mux.Vars(r)["id"], deref(req.Name))
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, viewSavedSchedule(h.store(), *sc))
+}
+
+// DELETE /api/me/schedules/{id}
+func (h *Handler) HandleDeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	if err := h.accounts.DeleteSchedule(u, mux.Vars(r)["id"]); err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+
+	"purdue_schedule/internal/data"
+	"purdue_schedule/internal/seats"
+
+	"github.com/gorilla/mux"
+)
+
+// SeatReport is a section's current seat counts with their history.
+type SeatReport struct {
+	SectionId string        `json:"sectionId"`
+	Crn       string        `json:"crn"`
+	Course    string        `json:"course"`
+	Current   *data.Seats   `json:"current"` // null when the dataset has no seat data
+	Full      bool          `json:"full"`
+	History   []seats.Point `json:"history"`
+}
+
+// GET /api/section/{id}/seats
+func (h *Handler) HandleSectionSeats(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	store := h.store()
+	sec, ok := store.SectionById(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "section not found"})
+		return
+	}
+	report := SeatReport{
+		SectionId: sec.Id,
+		Crn:       sec.Crn,
+		Current:   sec.Seats,
+		Full:      sec.Full(),
+		History:   []seats.Point{},
+	}
+	if c, ok := store.CourseBySectionId(sec.Id); ok {
+		report.Course = data.CourseLabel(store, c)
+	}
+	if h.seats != nil {
+		report.History = h.seats.Series(sec.Id)
+	}
+	writeJSON(w, http.StatusOK, report)
+}
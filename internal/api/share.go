@@ -0,0 +1,443 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"purdue_schedule/internal/auth"
+	"purdue_schedule/internal/data"
+	"purdue_schedule/internal/share"
+
+	"github.com/gorilla/mux"
+)
+
+// Share link lifetimes
+const (
+	defaultShareDays = 30
+	maxShareDays     = 365
+)
+
+// Limits on what one account can store as share links
+const (
+	maxShareBodyBytes = 64 << 10
+	maxShareSections  = 40
+	maxActiveShares   = 50
+)
+
+type shareRequest struct {
+	SectionIds    []string `json:"sectionIds"`
+	Title         string   `json:"title"`
+	StudentName   string   `json:"studentName"`
+	Width         int      `json:"width"`
+	Height        int      `json:"height"`
+	Theme         string   `json:"theme"`
+	ExpiresInDays int      `json:"expiresInDays"`
+}
+
+// ShareView is the API representation of a share link.
+type ShareView struct {
+	Id        string    `json:"id"`
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Revoked   bool      `json:"revoked"`
+	Active    bool      `json:"active"`
+	Sections  int       `json:"sections"`
+}
+
+func viewShare(r *http.Request, s *share.Share) ShareView {
+	return ShareView{
+		Id:        s.Id,
+		URL:       baseURL(r) + "/s/" + s.Id,
+		Title:     s.Options.Title,
+		CreatedAt: s.CreatedAt,
+		ExpiresAt: s.ExpiresAt,
+		Revoked:   !s.RevokedAt.IsZero(),
+		Active:    s.Active(time.Now()),
+		Sections:  len(s.Sections),
+	}
+}
+
+// baseURL reconstructs the public origin, honoring a TLS-terminating proxy.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	host := r.Host
+	if host == "" {
+		host = "localhost:8080"
+	}
+	return scheme + "://" + host
+}
+
+func shareDays(days int) int {
+	if days <= 0 {
+		return defaultShareDays
+	}
+	if days > maxShareDays {
+		return maxShareDays
+	}
+	return days
+}
+
+// POST /api/share needs a session, so every link has an owner who can
+// revoke it.
+func (h *Handler) HandleCreateShare(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	var req shareRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxShareBodyBytes)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	if len(req.SectionIds) > maxShareSections {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("a share link holds at most %d sections", maxShareSections)})
+		return
+	}
+	owned, err := h.shares.ByOwner(u.Id)
+	if err != nil {
+		log.Printf("share: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	now := time.Now()
+	active := 0
+	for _, s := range owned {
+		if s.Active(now) {
+			active++
+		}
+	}
+	if active >= maxActiveShares {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": fmt.Sprintf("you already have %d active share links; revoke one first", maxActiveShares)})
+		return
+	}
+	sections := h.store().SectionsByIds(req.SectionIds)
+	if len(sections) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "no valid sections found"})
+		return
+	}
+	theme, err := themeByName(req.Theme)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	id, err := share.NewId()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	courses := make(map[string]data.CourseSummary, len(sections))
+	for _, sec := range sections {
+		if c, ok := h.store().CourseBySectionId(sec.Id); ok {
+			if c.SubjectAbbr == "" {
+				c.SubjectAbbr = h.store().SubjectAbbr(c.SubjectId)
+			}
+			courses[sec.Id] = c
+		}
+	}
+	s := &share.Share{
+		Id:        id,
+		CreatedAt: now,
+		ExpiresAt: now.AddDate(0, 0, shareDays(req.ExpiresInDays)),
+		Options: share.Options{
+			Title:       strings.TrimSpace(req.Title),
+			StudentName: strings.TrimSpace(req.StudentName),
+			Width:       req.Width,
+			Height:      req.Height,
+			Theme:       theme.Name,
+		},
+		OwnerId:  u.Id,
+		Sections: sections,
+		Courses:  courses,
+	}
+	if err := h.shares.Save(s); err != nil {
+		log.Printf("share: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusCreated, viewShare(r, s))
+}
+
+// GET /api/me/shares
+func (h *Handler) HandleListShares(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	shares, err := h.shares.ByOwner(u.Id)
+	if err != nil {
+		log.Printf("share: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	out := make([]ShareView, 0, len(shares))
+	for _, s := range shares {
+		out = append(out, viewShare(r, s))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// ownedShare loads a share and checks that the signed-in user created it.
+func (h *Handler) ownedShare(w http.ResponseWriter, r *http.Request) (*share.Share, bool) {
+	u, _ := auth.UserFromContext(r.Context())
+	s, err := h.shares.Get(mux.Vars(r)["id"])
+	if err != nil || s.OwnerId == "" || s.OwnerId != u.Id {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": share.ErrNotFound.Error()})
+		return nil, false
+	}
+	return s, true
+}
+
+// PATCH /api/share/{id}
+func (h *Handler) HandleUpdateShare(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.ownedShare(w, r)
+	if !ok {
+		return
+	}
+	var req shareRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxShareBodyBytes)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	if req.ExpiresInDays != 0 {
+		s.ExpiresAt = time.Now().AddDate(0, 0, shareDays(req.ExpiresInDays))
+	}
+	if req.Title != "" {
+		s.Options.Title = strings.TrimSpace(req.Title)
+	}
+	if err := h.shares.Save(s); err != nil {
+		log.Printf("share: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, viewShare(r, s))
+}
+
+// DELETE /api/share/{id} revokes the link; the snapshot is kept for the owner.
+func (h *Handler) HandleRevokeShare(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.ownedShare(w, r)
+	if !ok {
+		return
+	}
+	if s.RevokedAt.IsZero() {
+		s.RevokedAt = time.Now()
+		if err := h.shares.Save(s); err != nil {
+			log.Printf("share: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// activeShare loads a share for public viewing, answering 404 or 410 itself.
+func (h *Handler) activeShare(w http.ResponseWriter, r *http.Request) (*share.Share, bool) {
+	s, err := h.shares.Get(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, share.ErrNotFound.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	if !s.Active(time.Now()) {
+		http.Error(w, share.ErrGone.Error(), http.StatusGone)
+		return nil, false
+	}
+	return s, true
+}
+
+func shareSVG(s *share.Share, width, height int) (*SVGSchedule, error) {
+	if width <= 0 || width > 4000 {
+		width = 1000
+	}
+	if height <= 0 || height > 4000 {
+		height = 700
+	}
+	// A theme removed since the link was made falls back to the default
+	theme, err := themeByName(s.Options.Theme)
+	if err != nil {
+		theme = defaultTheme
+	}
+	return GenerateSVGSchedule(s.Sections, s.Courses, GridOptions{Theme: theme}, width, height)
+}
+
+// shareTitle is the heading shown on the page and in link previews.
+func shareTitle(s *share.Share) string {
+	switch {
+	case s.Options.Title != "":
+		return s.Options.Title
+	case s.Options.StudentName != "":
+		return s.Options.StudentName + "'s schedule"
+	default:
+		return "Shared schedule"
+	}
+}
+
+type sharePageData struct {
+	SiteName    string
+	Bar         template.CSS // branding colors, "#RRGGBB"
+	BarText     template.CSS
+	Footer      []string
+	Title       string
+	Description string
+	StudentName string
+	PageURL     string
+	ImageURL    string
+	SVG         template.HTML
+	Courses     []shareCourseRow
+	ExpiresAt   time.Time
+}
+
+type shareCourseRow struct {
+	Course string
+	Title  string
+	Crn    string
+	Type   string
+	When   string
+	Where  string
+}
+
+// GET /s/{id}
+func (h *Handler) HandleSharePage(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.activeShare(w, r)
+	if !ok {
+		return
+	}
+	svg, err := shareSVG(s, s.Options.Width, s.Options.Height)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to render schedule: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	rows := make([]shareCourseRow, 0, len(s.Sections))
+	labels := make([]string, 0, len(s.Sections))
+	seen := make(map[string]struct{})
+	for _, sec := range s.Sections {
+		c := s.Courses[sec.Id]
+		label := strings.TrimSpace(c.SubjectAbbr + " " + c.Number)
+		rows = append(rows, shareCourseRow{
+			Course: label,
+			Title:  c.Title,
+			Crn:    sec.Crn,
+			Type:   sec.Type,
+			When:   sectionWhen(sec),
+			Where:  sectionWhere(sec),
+		})
+		if _, dup := seen[label]; !dup && label != "" {
+			seen[label] = struct{}{}
+			labels = append(labels, label)
+		}
+	}
+
+	// Share links are public: the server's branding, without overrides
+	brand, _ := h.branding(nil)
+	theme := brand.Theme(defaultTheme)
+	page := baseURL(r) + "/s/" + s.Id
+	pd := sharePageData{
+		SiteName:    brand.Title,
+		Bar:         template.CSS(theme.Bar),
+		BarText:     template.CSS(theme.BarText),
+		Footer:      brand.FooterLines(),
+		Title:       shareTitle(s),
+		Description: fmt.Sprintf("%d courses: %s", len(labels), strings.Join(labels, ", ")),
+		StudentName: s.Options.StudentName,
+		PageURL:     page,
+		ImageURL:    page + "/preview.png",
+		// The SVG is generated by us with all catalog text escaped
+		SVG:       template.HTML(svg.Content),
+		Courses:   rows,
+		ExpiresAt: s.ExpiresAt,
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := sharePageTemplate.Execute(w, pd); err != nil {
+		log.Printf("share page: %v", err)
+	}
+}
+
+// GET /s/{id}/preview.svg is the Open Graph preview image.
+func (h *Handler) HandleSharePreview(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.activeShare(w, r)
+	if !ok {
+		return
+	}
+	// 1200x630 is the preview size social sites expect
+	svg, err := shareSVG(s, 1200, 630)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to render schedule: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	_, _ = w.Write([]byte(svg.Content))
+}
+
+// GET /s/{id}/preview.png is the same preview as a PNG, which link
+// previews accept where SVG is not.
+func (h *Handler) HandleSharePreviewPNG(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.activeShare(w, r)
+	if !ok {
+		return
+	}
+	theme, err := themeByName(s.Options.Theme)
+	if err != nil {
+		theme = defaultTheme
+	}
+	preset, _ := pngPresetByName("og")
+	var buf bytes.Buffer
+	if err := writeSchedulePNG(&buf, s.Sections, s.Courses, GridOptions{Theme: theme}, preset); err != nil {
+		http.Error(w, fmt.Sprintf("failed to render schedule: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(buf.Bytes())
+}
+
+var sharePageTemplate = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>{{.Title}}{{with .SiteName}} - {{.}}{{end}}</title>
+<meta name="robots" content="noindex">
+<meta property="og:type" content="website">
+{{with .SiteName}}<meta property="og:site_name" content="{{.}}">
+{{end}}
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Description}}">
+<meta property="og:url" content="{{.PageURL}}">
+<meta property="og:image" content="{{.ImageURL}}">
+<meta property="og:image:width" content="1200">
+<meta property="og:image:height" content="630">
+<meta name="twitter:card" content="summary_large_image">
+<style>
+body { font-family: Arial, sans-serif; margin: 0; background: #f8f9fa; color: #111; }
+header { background: {{.Bar}}; color: {{.BarText}}; padding: 16px 24px; }
+header h1 { margin: 0; font-size: 24px; }
+header p { margin: 4px 0 0; font-size: 13px; }
+main { padding: 16px 24px; }
+.grid svg { width: 100%; height: auto; background: #fff; border-radius: 8px; box-shadow: 0 1px 4px rgba(0,0,0,.15); }
+table { border-collapse: collapse; width: 100%; margin-top: 16px; background: #fff; }
+th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #e5e5e5; font-size: 13px; }
+th { background: #f1f1f1; }
+footer { padding: 12px 24px; font-size: 12px; color: #666; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<p>Shared{{with .SiteName}} from {{.}}{{end}}{{if .StudentName}} by {{.StudentName}}{{end}} - read only</p>
+</header>
+<main>
+<div class="grid">{{.SVG}}</div>
+<table>
+<tr><th>Course</th><th>Title</th><th>CRN</th><th>Type</th><th>When</th><th>Where</th></tr>
+{{range .Courses}}<tr><td>{{.Course}}</td><td>{{.Title}}</td><td>{{.Crn}}</td><td>{{.Type}}</td><td>{{.When}}</td><td>{{.Where}}</td></tr>
+{{end}}</table>
+</main>
+<footer>This link expires {{.ExpiresAt.Format "January 2, 2006"}}.{{range .Footer}}<br>{{.}}{{end}}</footer>
+</body>
+</html>
+`))
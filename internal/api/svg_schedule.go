@@ -2,262 +2,269 @@ package api
 
 import (
 	"fmt"
-	"strconv"
+	"html"
 	"strings"
 
 	"purdue_schedule/internal/data"
+	"purdue_schedule/internal/layout"
 )
 
 // SVGSchedule represents a schedule rendered as SVG
 type SVGSchedule struct {
-	Width     int
-	Height    int
-	Content   string
-	TimeRange TimeRange
-	Events    []SVGEvent
+	Width   int
+	Height  int
+	Content string
 }
 
-// TimeRange represents the time span of the schedule
-type TimeRange struct {
-	StartHour int
-	EndHour   int
-}
-
-// SVGEvent represents a course event in the schedule
-type SVGEvent struct {
-	ID          string
-	Title       string
-	Instructor  string
-	Location    string
-	Type        string
-	Day         int // 0=Monday, 1=Tuesday, etc.
-	StartMinute int // Minutes from midnight
-	EndMinute   int
-	Color       string
-	X           float64
-	Y           float64
-	Width       float64
-	Height      float64
-}
-
-// Color palettes for departments (matching frontend exactly)
-var departmentColors = map[string][]string{
-	"CS":   {"#CFB991", "#B8A47E", "#A1906B", "#8A7B58", "#736645"},
-	"MA":   {"#2C2C2C", "#404040", "#545454", "#686868", "#7C7C7C"},
-	"PHYS": {"#8B4513", "#A0522D", "#CD853F", "#DEB887", "#F4A460"},
-	"ECE":  {"#4169E1", "#6495ED", "#87CEEB", "#B0C4DE", "#E6F3FF"}, // Fixed to match frontend
-	"ME":   {"#DC143C", "#F08080", "#FA8072", "#FFA07A", "#FFB6C1"},
-	"CHEM": {"#228B22", "#32CD32", "#90EE90", "#98FB98", "#F0FFF0"},
-	"AAE":  {"#4B0082", "#8A2BE2", "#9370DB", "#BA55D3", "#DDA0DD"},
-	"BIOL": {"#FF8C00", "#FFA500", "#FFB347", "#FFCC99", "#FFE4B5"},
-}
-
-// GenerateSVGSchedule creates an SVG representation of the schedule
-func GenerateSVGSchedule(sections []data.SectionInfo, courseBySection map[string]data.CourseSummary, width, height int) (*SVGSchedule, error) {
-	// Calculate time range
-	timeRange := calculateTimeRange(sections)
-
-	// Convert sections to SVG events
-	events := convertToSVGEvents(sections, courseBySection, timeRange, width, height)
+// Grid geometry in SVG pixels: the row of day names and the column of hours
+const (
+	svgHeaderHeight    = 60.0
+	svgTimeColumnWidth = 80.0
+)
 
-	// Generate SVG content
-	svgContent := generateSVGContent(events, timeRange, width, height)
+// GenerateSVGSchedule creates an SVG representation of the schedule with
+// the given grid options. Meetings that land outside the grid are listed in
+// a footer under it.
+//
+// Layers overlay other schedules on the same grid, such as friends'. The
+// sections and each layer then get a lane of their own inside every day
+// column, so blocks that overlap in time stay side by side, and a legend
+// under the grid names each labeled layer.
+func GenerateSVGSchedule(sections []data.SectionInfo, courseBySection map[string]data.CourseSummary, o GridOptions, width, height int, layers ...SVGLayer) (*SVGSchedule, error) {
+	o = o.normalized()
+	if len(sections) > 0 || len(layers) == 0 {
+		layers = append([]SVGLayer{{Sections: sections}}, layers...)
+	}
+	l, colors := layoutLayers(layers, courseBySection, o)
+	unscheduled := unscheduledMeetings(l, o)
+	legendHeight := 0
+	if len(layers) > 1 {
+		legendHeight = overlayLegendHeight
+	}
+	footerHeight := unscheduledFooterHeight(unscheduled)
+	gridHeight := height - legendHeight - footerHeight
+
+	svgContent := generateSVGContent(l, colors, o, width, gridHeight)
+	if legendHeight > 0 || footerHeight > 0 {
+		var svg strings.Builder
+		svg.WriteString(fmt.Sprintf(`<svg width="%d" height="%d" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">`, width, height, width, height))
+		svg.WriteString(svgContent)
+		if legendHeight > 0 {
+			drawLegend(&svg, layers, o.Theme, gridHeight, width)
+		}
+		if footerHeight > 0 {
+			drawUnscheduled(&svg, unscheduled, o.Theme, gridHeight+legendHeight, width, footerHeight)
+		}
+		svg.WriteString("</svg>")
+		svgContent = svg.String()
+	}
 
 	return &SVGSchedule{
-		Width:     width,
-		Height:    height,
-		Content:   svgContent,
-		TimeRange: timeRange,
-		Events:    events,
+		Width:   width,
+		Height:  height,
+		Content: svgContent,
 	}, nil
 }
 
-// calculateTimeRange determines the optimal time range for the schedule
-func calculateTimeRange(sections []data.SectionInfo) TimeRange {
-	if len(sections) == 0 {
-		return TimeRange{StartHour: 8, EndHour: 17} // Default 8 AM - 5 PM
-	}
-
-	minHour := 23
-	maxHour := 0
-
-	for _, section := range sections {
-		for _, meeting := range section.Meetings {
-			if meeting.Start == "" {
-				continue
-			}
+// Branded SVG title bar and footer, in pixels
+const (
+	svgBrandBarHeight = 64
+	svgBrandLineGap   = 16
+)
 
-			startHour, _ := parseTimeSimple(meeting.Start)
-			if startHour >= 0 {
-				if startHour < minHour {
-					minHour = startHour
-				}
-
-				endHour := startHour + (meeting.DurationMin / 60)
-				if meeting.DurationMin%60 > 0 {
-					endHour++
-				}
-				if endHour > maxHour {
-					maxHour = endHour
-				}
-			}
-		}
+// WithBranding returns the schedule under a title bar and over a footer
+// from brand, taller by their height. The bar takes its colors from theme.
+func (s *SVGSchedule) WithBranding(brand Branding, theme Theme) *SVGSchedule {
+	footer := brand.FooterLines()
+	footerHeight := 0
+	if len(footer) > 0 {
+		footerHeight = 12 + svgBrandLineGap*len(footer)
 	}
+	height := s.Height + svgBrandBarHeight + footerHeight
 
-	// Add 30 minutes buffer, ensure minimum 4-hour range
-	startHour := minHour - 1
-	if startHour < 0 {
-		startHour = 0
+	var svg strings.Builder
+	svg.WriteString(fmt.Sprintf(`<svg width="%d" height="%d" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">`, s.Width, height, s.Width, height))
+	svg.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="%s"/>`, s.Width, svgBrandBarHeight, theme.Bar))
+	svg.WriteString(fmt.Sprintf(`<rect y="%d" width="%d" height="3" fill="%s"/>`, svgBrandBarHeight-3, s.Width, theme.Accent))
+	x := 16
+	if uri := brand.LogoURI(); uri != "" {
+		h := svgBrandBarHeight - 20
+		w := min(h*brand.logoWidth/brand.logoHeight, s.Width/4)
+		svg.WriteString(fmt.Sprintf(`<image href="%s" x="%d" y="8" width="%d" height="%d" preserveAspectRatio="xMinYMid meet"/>`, uri, x, w, h))
+		x += w + 12
 	}
-
-	endHour := maxHour + 1
-	if endHour > 23 {
-		endHour = 23
+	titleY := svgBrandBarHeight/2 + 8
+	if brand.Subtitle != "" {
+		titleY = 30
+		svg.WriteString(fmt.Sprintf(`<text x="%d" y="48" font-family="Arial,sans-serif" font-size="13" fill="%s" opacity="0.9">%s</text>`,
+			x, theme.BarText, html.EscapeString(brand.Subtitle)))
 	}
-
-	// Ensure minimum 4-hour range
-	if endHour-startHour < 4 {
-		endHour = startHour + 4
-		if endHour > 23 {
-			endHour = 23
-			startHour = 19
+	svg.WriteString(fmt.Sprintf(`<text x="%d" y="%d" font-family="Arial,sans-serif" font-size="22" font-weight="bold" fill="%s">%s</text>`,
+		x, titleY, theme.BarText, html.EscapeString(brand.Title)))
+
+	svg.WriteString(fmt.Sprintf(`<g transform="translate(0,%d)">`, svgBrandBarHeight))
+	svg.WriteString(s.Content)
+	svg.WriteString("</g>")
+	if footerHeight > 0 {
+		top := svgBrandBarHeight + s.Height
+		svg.WriteString(fmt.Sprintf(`<rect y="%d" width="%d" height="%d" fill="%s"/>`, top, s.Width, footerHeight, theme.Header))
+		for i, line := range footer {
+			svg.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="middle" font-family="Arial,sans-serif" font-size="11" fill="%s">%s</text>`,
+				s.Width/2, top+6+svgBrandLineGap*(i+1)-4, theme.Muted, html.EscapeString(line)))
 		}
 	}
-
-	return TimeRange{StartHour: startHour, EndHour: endHour}
+	svg.WriteString("</svg>")
+	return &SVGSchedule{Width: s.Width, Height: height, Content: svg.String()}
 }
 
-// convertToSVGEvents converts course sections to SVG events with positioning
-func convertToSVGEvents(sections []data.SectionInfo, courseBySection map[string]data.CourseSummary, timeRange TimeRange, width, height int) []SVGEvent {
-	var events []SVGEvent
-
-	// Layout constants
-	headerHeight := 60.0
-	timeColumnWidth := 80.0
-	dayWidth := (float64(width) - timeColumnWidth) / 5.0 // 5 weekdays
-	hourHeight := (float64(height) - headerHeight) / float64(timeRange.EndHour-timeRange.StartHour)
-
-	// Department color tracking
-	deptColorIndex := make(map[string]int)
+// UnscheduledMeeting is how the exports list a meeting the grid has no
+// place for.
+type UnscheduledMeeting struct {
+	Title  string // "CS 18000 Lecture"
+	Detail string // "S 9:00 AM-12:00 PM, Saturday not shown"
+}
 
-	dayMap := map[string]int{
-		"Monday": 0, "Tuesday": 1, "Wednesday": 2, "Thursday": 3, "Friday": 4,
-		"Mon": 0, "Tue": 1, "Wed": 2, "Thu": 3, "Fri": 4,
-		"M": 0, "T": 1, "W": 2, "R": 3, "F": 4,
+// unscheduledMeetings describes the meetings a layout left off the grid.
+func unscheduledMeetings(l *layout.Schedule, o GridOptions) []UnscheduledMeeting {
+	var out []UnscheduledMeeting
+	for _, u := range l.Unscheduled {
+		if u.TBA() {
+			out = append(out, UnscheduledMeeting{Title: u.Title, Detail: "Time to be announced"})
+			continue
+		}
+		start, _ := layout.ParseTime(u.Meeting.Start)
+		when := fmt.Sprintf("%s-%s", o.clock(start), o.clock(start+u.Meeting.DurationMin))
+		detail := fmt.Sprintf("%s %s, outside the hours shown", data.DayLetters(u.Meeting.Days), when)
+		if !u.Outside {
+			detail = fmt.Sprintf("%s %s, %s not shown", data.DayLetters(u.Missing), when, strings.Join(u.Missing, " and "))
+		}
+		out = append(out, UnscheduledMeeting{Title: u.Title, Detail: detail})
 	}
+	return out
+}
 
-	for _, section := range sections {
-		course, hasCourse := courseBySection[section.Id]
+// The unscheduled footer shows a heading and at most this many lines; the
+// rest are summed up on the last one.
+const maxUnscheduledLines = 4
 
-		// Get department and color
-		dept := "DEFAULT"
-		if hasCourse && course.SubjectAbbr != "" {
-			dept = course.SubjectAbbr
-		}
+func unscheduledFooterHeight(unscheduled []UnscheduledMeeting) int {
+	if len(unscheduled) == 0 {
+		return 0
+	}
+	return 30 + 16*min(len(unscheduled), maxUnscheduledLines)
+}
 
-		colors, hasColors := departmentColors[dept]
-		if !hasColors {
-			colors = []string{"#CFB991"} // Default Purdue gold
+// drawUnscheduled draws the footer listing meetings that are not on the
+// grid, in the strip of the given height starting at top.
+func drawUnscheduled(svg *strings.Builder, unscheduled []UnscheduledMeeting, theme Theme, top, width, height int) {
+	svg.WriteString(fmt.Sprintf(`<rect y="%d" width="%d" height="%d" fill="%s" stroke="%s" stroke-width="1"/>`, top, width, height, theme.Header, theme.Line))
+	svg.WriteString(fmt.Sprintf(`<text x="10" y="%d" font-family="Arial,sans-serif" font-size="12" font-weight="bold" fill="%s">Not on the grid</text>`, top+18, theme.Text))
+	for i, u := range unscheduled {
+		y := top + 34 + 16*i
+		if i == maxUnscheduledLines-1 && len(unscheduled) > maxUnscheduledLines {
+			svg.WriteString(fmt.Sprintf(`<text x="10" y="%d" font-family="Arial,sans-serif" font-size="11" fill="%s">and %d more</text>`, y, theme.Muted, len(unscheduled)-i))
+			break
 		}
+		svg.WriteString(fmt.Sprintf(`<text x="10" y="%d" font-family="Arial,sans-serif" font-size="11" fill="%s"><tspan font-weight="bold">%s</tspan> - %s</text>`,
+			y, theme.Text, html.EscapeString(u.Title), html.EscapeString(u.Detail)))
+	}
+}
 
-		colorIndex := deptColorIndex[dept] % len(colors)
-		deptColorIndex[dept]++
-		color := colors[colorIndex]
-
-		// Debug: Force all courses to use Royal Blue to test
-		color = "#4169E1"
-
-		// Process each meeting
-		for _, meeting := range section.Meetings {
-			if meeting.Start == "" || len(meeting.Days) == 0 {
-				continue
-			}
+// SVGLayer is one person's sections in an overlay. A layer without a color
+// takes one from the theme; one without a label is left out of the legend
+// and keeps the theme's course colors.
+type SVGLayer struct {
+	Label    string
+	Color    string
+	Sections []data.SectionInfo
+}
 
-			startHour, startMin := parseTimeSimple(meeting.Start)
-			if startHour < 0 {
-				continue
-			}
+// color is the fill of the layer's blocks and its legend entry.
+func (l SVGLayer) color(theme Theme) string {
+	if l.Color != "" {
+		return l.Color
+	}
+	return theme.CourseColor(l.Label)
+}
 
-			startMinute := startHour*60 + startMin
-			endMinute := startMinute + meeting.DurationMin
-
-			// Calculate position
-			relativeStartHour := float64(startHour-timeRange.StartHour) + float64(startMin)/60.0
-			duration := float64(meeting.DurationMin) / 60.0
-
-			y := headerHeight + (relativeStartHour * hourHeight)
-			eventHeight := duration * hourHeight
-
-			for _, dayStr := range meeting.Days {
-				dayIndex, hasDay := dayMap[dayStr]
-				if !hasDay {
-					continue
-				}
-
-				x := timeColumnWidth + (float64(dayIndex) * dayWidth)
-				eventWidth := dayWidth - 4 // Small margin
-
-				// Create course title
-				title := fmt.Sprintf("%s %s", course.SubjectAbbr, course.Number)
-				if !hasCourse {
-					title = "Unknown Course"
-				}
-
-				instructor := "TBA"
-				if len(meeting.Instructors) > 0 {
-					instructor = meeting.Instructors[0]
-				}
-
-				location := ""
-				if meeting.BuildingCode != "" && meeting.RoomNumber != "" {
-					location = fmt.Sprintf("%s %s", meeting.BuildingCode, meeting.RoomNumber)
-				}
-
-				events = append(events, SVGEvent{
-					ID:          fmt.Sprintf("%s-%s-%d", section.Id, dayStr, len(events)),
-					Title:       title,
-					Instructor:  instructor,
-					Location:    location,
-					Type:        section.Type,
-					Day:         dayIndex,
-					StartMinute: startMinute,
-					EndMinute:   endMinute,
-					Color:       color,
-					X:           x,
-					Y:           y,
-					Width:       eventWidth,
-					Height:      eventHeight,
-				})
+// overlayLegendHeight is the strip under the grid naming each layer
+const overlayLegendHeight = 32
+
+// layoutLayers lays out the layers on one grid, returning a fill for each
+// block, or nil for a single unlabeled layer. The union of the layers
+// decides the days and hours. Each layer is laid out on them by itself and
+// its lanes are nested in the layer's own slice of the day column, so a
+// person's own overlapping classes still stand side by side.
+func layoutLayers(layers []SVGLayer, courseBySection map[string]data.CourseSummary, o GridOptions) (*layout.Schedule, []string) {
+	if len(layers) == 1 && layers[0].Label == "" && layers[0].Color == "" {
+		return layout.Build(layers[0].Sections, courseBySection, o.layout()), nil
+	}
+	var all []data.SectionInfo
+	for _, l := range layers {
+		all = append(all, l.Sections...)
+	}
+	union := layout.Build(all, courseBySection, o.layout())
+	union.Blocks = nil
+
+	var colors []string
+	opts := layout.Options{Days: union.Days, Start: &union.Start, End: &union.End}
+	for i, l := range layers {
+		for _, b := range layout.Build(l.Sections, courseBySection, opts).Blocks {
+			lanes := max(b.Lanes, 1)
+			b.Lane, b.Lanes = i*lanes+b.Lane, len(layers)*lanes
+			union.Blocks = append(union.Blocks, b)
+			if l.Label == "" && l.Color == "" {
+				colors = append(colors, o.Theme.CourseColor(b.ColorKey()))
+			} else {
+				colors = append(colors, l.color(o.Theme))
 			}
 		}
 	}
+	return union, colors
+}
 
-	return events
+// drawLegend names each labeled layer in its color, in the strip starting
+// at top.
+func drawLegend(svg *strings.Builder, layers []SVGLayer, theme Theme, top, width int) {
+	svg.WriteString(fmt.Sprintf(`<rect y="%d" width="%d" height="%d" fill="%s"/>`, top, width, overlayLegendHeight, theme.Grid))
+	x := 10.0
+	y := float64(top) + 9
+	for _, l := range layers {
+		if l.Label == "" {
+			continue
+		}
+		svg.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="14" height="14" rx="3" fill="%s"/>`, x, y, l.color(theme)))
+		svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" font-family="Arial,sans-serif" font-size="12" fill="%s">%s</text>`, x+20, y+11, theme.Text, html.EscapeString(l.Label)))
+		x += 40 + 7*float64(len([]rune(l.Label)))
+	}
 }
 
-// generateSVGContent creates the complete SVG markup
-func generateSVGContent(events []SVGEvent, timeRange TimeRange, width, height int) string {
+// generateSVGContent creates the complete SVG markup. colors holds a fill
+// for each block; nil takes the theme's course colors.
+func generateSVGContent(l *layout.Schedule, colors []string, o GridOptions, width, height int) string {
+	theme := o.Theme
 	var svg strings.Builder
 
 	// SVG header
 	svg.WriteString(fmt.Sprintf(`<svg width="%d" height="%d" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">`, width, height, width, height))
-	svg.WriteString(`<defs><style>.schedule-text{font-family:Arial,sans-serif;font-size:12px;fill:#000;}.schedule-small{font-size:10px;}.schedule-tiny{font-size:8px;}.schedule-header{font-weight:bold;font-size:14px;}</style></defs>`)
+	svg.WriteString(`<defs><style>.schedule-text{font-family:Arial,sans-serif;font-size:12px;}.schedule-small{font-size:10px;}.schedule-tiny{font-size:8px;}.schedule-header{font-weight:bold;font-size:14px;}</style></defs>`)
 
 	// Background
-	svg.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="#ffffff" stroke="none"/>`, width, height))
-
-	// Layout constants
-	headerHeight := 60.0
-	timeColumnWidth := 80.0
-	dayWidth := (float64(width) - timeColumnWidth) / 5.0
-	hourHeight := (float64(height) - headerHeight) / float64(timeRange.EndHour-timeRange.StartHour)
-
-	// Draw grid
-	drawGrid(&svg, timeRange, width, height, headerHeight, timeColumnWidth, dayWidth, hourHeight)
-
-	// Draw events
-	for _, event := range events {
-		drawEvent(&svg, event)
+	svg.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="%s" stroke="none"/>`, width, height, theme.Grid))
+
+	// The grid body sits right of the hours and below the day names
+	bodyWidth := float64(width) - svgTimeColumnWidth
+	bodyHeight := float64(height) - svgHeaderHeight
+	drawGrid(&svg, l, o, width, height, bodyWidth/float64(len(l.Days)), bodyHeight)
+
+	for i, b := range l.Blocks {
+		x, y, w, h := l.Place(b)
+		color := theme.CourseColor(b.ColorKey())
+		if colors != nil {
+			color = colors[i]
+		}
+		drawEvent(&svg, fmt.Sprintf("%s-%d", b.Section.Id, i), b, color, o,
+			svgTimeColumnWidth+x*bodyWidth, svgHeaderHeight+y*bodyHeight, w*bodyWidth-4, h*bodyHeight)
 	}
 
 	svg.WriteString("</svg>")
@@ -265,72 +272,125 @@ func generateSVGContent(events []SVGEvent, timeRange TimeRange, width, height in
 }
 
 // drawGrid creates the schedule grid (time slots and day columns)
-func drawGrid(svg *strings.Builder, timeRange TimeRange, width, height int, headerHeight, timeColumnWidth, dayWidth, hourHeight float64) {
-	days := []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}
-
+func drawGrid(svg *strings.Builder, l *layout.Schedule, o GridOptions, width, height int, dayWidth, bodyHeight float64) {
+	theme := o.Theme
 	// Draw day headers
-	for i, day := range days {
-		x := timeColumnWidth + (float64(i) * dayWidth)
-		svg.WriteString(fmt.Sprintf(`<rect x="%.1f" y="0" width="%.1f" height="%.1f" fill="#f8f9fa" stroke="#e9ecef" stroke-width="1"/>`, x, dayWidth, headerHeight))
-		svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="35" text-anchor="middle" class="schedule-text schedule-header">%s</text>`, x+dayWidth/2, day))
+	for i, day := range l.Days {
+		x := svgTimeColumnWidth + (float64(i) * dayWidth)
+		svg.WriteString(fmt.Sprintf(`<rect x="%.1f" y="0" width="%.1f" height="%.1f" fill="%s" stroke="%s" stroke-width="1"/>`, x, dayWidth, svgHeaderHeight, theme.Header, theme.Line))
+		svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="35" text-anchor="middle" class="schedule-text schedule-header" fill="%s">%s</text>`, x+dayWidth/2, theme.Text, layout.DayNames[day]))
 	}
 
 	// Draw time column background
-	svg.WriteString(fmt.Sprintf(`<rect x="0" y="0" width="%.1f" height="%d" fill="#f8f9fa" stroke="#e9ecef" stroke-width="1"/>`, timeColumnWidth, height))
-
-	// Draw time labels and horizontal lines
-	for hour := timeRange.StartHour; hour <= timeRange.EndHour; hour++ {
-		y := headerHeight + (float64(hour-timeRange.StartHour) * hourHeight)
+	svg.WriteString(fmt.Sprintf(`<rect x="0" y="0" width="%.1f" height="%d" fill="%s" stroke="%s" stroke-width="1"/>`, svgTimeColumnWidth, height, theme.Header, theme.Line))
+
+	// Draw time labels on the hour and horizontal lines every step
+	o.gridLines(l, func(t int, hour bool) {
+		y := svgHeaderHeight + l.Y(t)*bodyHeight
+		if !hour {
+			svg.WriteString(fmt.Sprintf(`<line x1="%.1f" y1="%.1f" x2="%d" y2="%.1f" stroke="%s" stroke-width="1" stroke-dasharray="2,3" opacity="0.7"/>`, svgTimeColumnWidth, y, width, y, theme.Line))
+			return
+		}
 
 		// Time label
-		timeLabel := formatHour(hour)
-		svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="middle" class="schedule-text">%s</text>`, timeColumnWidth/2, y+5, timeLabel))
+		svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="middle" class="schedule-text" fill="%s">%s</text>`, svgTimeColumnWidth/2, y+5, theme.Muted, o.hourLabel(t)))
 
 		// Horizontal grid line
-		svg.WriteString(fmt.Sprintf(`<line x1="%.1f" y1="%.1f" x2="%d" y2="%.1f" stroke="#e9ecef" stroke-width="1"/>`, timeColumnWidth, y, width, y))
-	}
+		svg.WriteString(fmt.Sprintf(`<line x1="%.1f" y1="%.1f" x2="%d" y2="%.1f" stroke="%s" stroke-width="1"/>`, svgTimeColumnWidth, y, width, y, theme.Line))
+	})
 
 	// Draw vertical lines between days
-	for i := 0; i <= 5; i++ {
-		x := timeColumnWidth + (float64(i) * dayWidth)
-		svg.WriteString(fmt.Sprintf(`<line x1="%.1f" y1="0" x2="%.1f" y2="%d" stroke="#e9ecef" stroke-width="1"/>`, x, x, height))
+	for i := 0; i <= len(l.Days); i++ {
+		x := svgTimeColumnWidth + (float64(i) * dayWidth)
+		svg.WriteString(fmt.Sprintf(`<line x1="%.1f" y1="0" x2="%.1f" y2="%d" stroke="%s" stroke-width="1"/>`, x, x, height, theme.Line))
 	}
 
 	// Draw header bottom line
-	svg.WriteString(fmt.Sprintf(`<line x1="0" y1="%.1f" x2="%d" y2="%.1f" stroke="#CFB991" stroke-width="2"/>`, headerHeight, width, headerHeight))
+	svg.WriteString(fmt.Sprintf(`<line x1="0" y1="%.1f" x2="%d" y2="%.1f" stroke="%s" stroke-width="2"/>`, svgHeaderHeight, width, svgHeaderHeight, theme.Accent))
 }
 
-// drawEvent renders a single course event
-func drawEvent(svg *strings.Builder, event SVGEvent) {
-	// Event rectangle with rounded corners
-	svg.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="#ffffff" stroke-width="2" rx="4" ry="4" opacity="0.9" data-event-id="%s"/>`,
-		event.X+2, event.Y+1, event.Width-4, event.Height-2, event.Color, event.ID))
+// drawEvent renders a single class block in the box at x, y
+func drawEvent(svg *strings.Builder, id string, b layout.Block, color string, o GridOptions, x, y, width, height float64) {
+	theme := o.Theme
+	textColor := textColorFor(color)
+
+	// Event rectangle with rounded corners, outlined when it overlaps another
+	stroke := theme.Grid
+	if b.Conflict {
+		stroke = theme.Conflict
+	}
+	svg.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s" stroke-width="2" rx="4" ry="4" opacity="0.9" data-event-id="%s"/>`,
+		x+2, y+1, width-4, height-2, color, stroke, html.EscapeString(id)))
 
 	// Type badge (top-right corner)
-	badgeX := event.X + event.Width - 25
-	badgeY := event.Y + 8
-	svg.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="20" height="12" fill="#000000" stroke="none" rx="2" ry="2"/>`, badgeX, badgeY))
-	svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="middle" class="schedule-text schedule-tiny" fill="#CFB991">%s</text>`, badgeX+10, badgeY+9, strings.ToUpper(event.Type[:min(3, len(event.Type))])))
-
-	// Course title
-	titleY := event.Y + 20
-	svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" class="schedule-text" fill="#ffffff" font-weight="bold">%s</text>`, event.X+6, titleY, event.Title))
-
-	// Instructor (if space allows)
-	if event.Height > 40 {
-		instructorY := titleY + 16
-		svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" class="schedule-text schedule-small" fill="#ffffff" opacity="0.9">%s</text>`, event.X+6, instructorY, event.Instructor))
+	textWidth := width - 12
+	if badge := blockBadge(b, width, height); badge != "" {
+		badgeX := x + width - 25
+		badgeY := y + 8
+		svg.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="20" height="12" fill="%s" stroke="%s" stroke-width="0.5" rx="2" ry="2"/>`, badgeX, badgeY, theme.Badge, theme.BadgeText))
+		svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" text-anchor="middle" class="schedule-text schedule-tiny" fill="%s">%s</text>`, badgeX+10, badgeY+9, theme.BadgeText, html.EscapeString(badge)))
+		textWidth -= 24
 	}
 
-	// Location (if space allows)
-	if event.Height > 60 && event.Location != "" {
-		locationY := titleY + 32
-		svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" class="schedule-text schedule-tiny" fill="#ffffff" opacity="0.8">%s</text>`, event.X+6, locationY, event.Location))
+	// Course title, if the block is tall enough, like the lines below it
+	titleY := y + 20
+	if titleY+4 <= y+height {
+		svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" class="schedule-text" fill="%s" font-weight="bold">%s</text>`, x+6, titleY, textColor, html.EscapeString(svgFitText(b.Label(), textWidth, 12))))
 	}
+
+	// Then the time, instructor and location, as space allows
+	lineY := titleY
+	for _, line := range []struct {
+		text    string
+		class   string
+		size    float64
+		opacity string
+	}{
+		{o.blockTimes(b), "schedule-small", 10, "0.9"},
+		{b.Instructor(), "schedule-small", 10, "0.9"},
+		{b.Location(), "schedule-tiny", 8, "0.8"},
+	} {
+		if line.text == "" {
+			continue
+		}
+		lineY += 16
+		if lineY+4 > y+height {
+			break
+		}
+		svg.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" class="schedule-text %s" fill="%s" opacity="%s">%s</text>`, x+6, lineY, line.class, textColor, line.opacity, html.EscapeString(svgFitText(line.text, width-12, line.size))))
+	}
+}
+
+// blockBadge returns the type badge for a block drawn width by height
+// pixels, or "" when the block is too narrow to keep the badge clear of the
+// title or too short to hold it, as the PDF's h >= 8 check does. The SVG
+// and PNG renderers share it so they agree on every block.
+func blockBadge(b layout.Block, width, height float64) string {
+	// The badge spans y+8 to y+20, inside the block's 2.5px inset border
+	if width < 70 || height < 24 {
+		return ""
+	}
+	return typeBadge(b.Section.Type)
+}
+
+// svgFitText shortens s to about what fits width at the given font size,
+// taking an average Arial character as 0.6em since SVG text does not wrap
+// or clip.
+func svgFitText(s string, width, fontSize float64) string {
+	fits := int(width / (fontSize * 0.6))
+	r := []rune(s)
+	if len(r) <= fits {
+		return s
+	}
+	if fits <= 3 {
+		return ""
+	}
+	return string(r[:fits-3]) + "..."
 }
 
 // Helper functions
 func formatHour(hour int) string {
+	hour %= 24 // the line at the end of the day is midnight
 	if hour == 0 {
 		return "12 AM"
 	} else if hour < 12 {
@@ -341,26 +401,3 @@ func formatHour(hour int) string {
 		return fmt.Sprintf("%d PM", hour-12)
 	}
 }
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-// parseTimeSimple parses time strings like "09:00" or "14:30" and returns hour, minute
-func parseTimeSimple(timeStr string) (int, int) {
-	parts := strings.Split(strings.TrimSpace(timeStr), ":")
-	if len(parts) != 2 {
-		return -1, -1
-	}
-
-	hour, err1 := strconv.Atoi(parts[0])
-	minute, err2 := strconv.Atoi(parts[1])
-	if err1 != nil || err2 != nil {
-		return -1, -1
-	}
-
-	return hour, minute
-}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"purdue_schedule/internal/data"
+)
+
+// maxSectionIds caps the sections one export or check may name, since
+// Validate compares every pair of them.
+const maxSectionIds = 40
+
+// sectionIdsFromQuery splits the sections= list of an export or check.
+func sectionIdsFromQuery(q url.Values) ([]string, error) {
+	raw := strings.TrimSpace(q.Get("sections"))
+	if raw == "" {
+		return nil, errors.New("sections query param required")
+	}
+	ids := strings.Split(raw, ",")
+	if len(ids) > maxSectionIds {
+		return nil, fmt.Errorf("at most %d sections may be given", maxSectionIds)
+	}
+	return ids, nil
+}
+
+// GET /api/schedule/validate?sections=&minCredits=&maxCredits=
+func (h *Handler) HandleValidateSchedule(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	ids, err := sectionIdsFromQuery(q)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	var opts data.ValidateOptions
+	for name, dst := range map[string]*float64{"minCredits": &opts.MinCredits, "maxCredits": &opts.MaxCredits} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed <= 0 || parsed > 40 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": name + " must be a number between 0 and 40"})
+			return
+		}
+		*dst = parsed
+	}
+	if opts.MinCredits > 0 && opts.MaxCredits > 0 && opts.MinCredits > opts.MaxCredits {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "minCredits is above maxCredits"})
+		return
+	}
+	writeJSON(w, http.StatusOK, h.store().Validate(ids, opts))
+}
+
+// exportFindings are the findings a schedule export prints under "Checks":
+// its errors and warnings, in the order Validate gives them.
+func exportFindings(r data.Report) []data.Finding {
+	var out []data.Finding
+	for _, f := range r.Findings {
+		if f.Severity == data.SeverityError || f.Severity == data.SeverityWarning {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// findingLabel names a finding's severity for print.
+func findingLabel(f data.Finding) string {
+	if f.Severity == data.SeverityError {
+		return "Error"
+	}
+	return "Warning"
+}
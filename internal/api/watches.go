@@ -0,0 +1,190 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"purdue_schedule/internal/auth"
+	"purdue_schedule/internal/data"
+
+	"github.com/gorilla/mux"
+)
+
+// WatchView is a watched section with its current seat counts.
+type WatchView struct {
+	SectionId  string      `json:"sectionId"`
+	Crn        string      `json:"crn"`
+	Course     string      `json:"course"`
+	Seats      *data.Seats `json:"seats"` // null when the section or its seat data is gone
+	Full       bool        `json:"full"`
+	Missing    bool        `json:"missing"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	NotifiedAt *time.Time  `json:"notifiedAt,omitempty"`
+}
+
+// NotifySettingsView is the user's notification settings as sent and
+// received by the API.
+type NotifySettingsView struct {
+	Channels   []string `json:"channels"`
+	WebhookURL string   `json:"webhookUrl"`
+	QuietStart string   `json:"quietStart"`
+	QuietEnd   string   `json:"quietEnd"`
+	TimeZone   string   `json:"timeZone"`
+	// ScheduleAlerts also sends schedule alerts through Channels
+	ScheduleAlerts bool `json:"scheduleAlerts"`
+}
+
+func viewWatch(store *data.Store, w auth.Watch) WatchView {
+	v := WatchView{SectionId: w.SectionId, Crn: w.Crn, Course: w.Course, CreatedAt: w.CreatedAt}
+	if !w.NotifiedAt.IsZero() {
+		v.NotifiedAt = &w.NotifiedAt
+	}
+	sec, ok := store.SectionById(w.SectionId)
+	if !ok {
+		v.Missing = true
+		return v
+	}
+	v.Seats = sec.Seats
+	v.Full = sec.Full()
+	return v
+}
+
+func viewNotifySettings(n auth.NotifySettings) NotifySettingsView {
+	tz := n.TimeZone
+	if tz == "" {
+		tz = auth.DefaultTimeZone
+	}
+	return NotifySettingsView{
+		Channels:       auth.NotifyChannels(n),
+		WebhookURL:     n.WebhookURL,
+		QuietStart:     n.QuietStart,
+		QuietEnd:       n.QuietEnd,
+		TimeZone:       tz,
+		ScheduleAlerts: n.ScheduleAlerts,
+	}
+}
+
+func writeWatchError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrWatchNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, auth.ErrTooManyWatches):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, auth.ErrChannel), errors.Is(err, auth.ErrWebhookURL), errors.Is(err, auth.ErrWebhookPrivate),
+		errors.Is(err, auth.ErrQuietHours), errors.Is(err, auth.ErrTimeZone):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		writeScheduleError(w, err)
+	}
+}
+
+// GET /api/me/watches
+func (h *Handler) HandleListWatches(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	store := h.store()
+	watches := h.accounts.Watches(u)
+	out := make([]WatchView, 0, len(watches))
+	for _, wt := range watches {
+		out = append(out, viewWatch(store, wt))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+type watchRequest struct {
+	SectionId string `json:"sectionId"`
+}
+
+// POST /api/me/watches
+func (h *Handler) HandleAddWatch(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	var req watchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	store := h.store()
+	sec, ok := store.SectionById(req.SectionId)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "section not found"})
+		return
+	}
+	watch := auth.Watch{SectionId: sec.Id, Crn: sec.Crn}
+	if c, ok := store.CourseBySectionId(sec.Id); ok {
+		watch.Course = data.CourseLabel(store, c)
+	}
+	if sec.Seats != nil {
+		watch.Remaining = sec.Seats.Remaining
+		watch.WaitlistCount = sec.Seats.WaitlistCount
+	}
+	added, err := h.accounts.AddWatch(u, watch)
+	if err != nil {
+		writeWatchError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, viewWatch(store, *added))
+}
+
+// DELETE /api/me/watches/{id}
+func (h *Handler) HandleRemoveWatch(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	if err := h.accounts.RemoveWatch(u, mux.Vars(r)["id"]); err != nil {
+		writeWatchError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/me/notify
+func (h *Handler) HandleGetNotifySettings(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	writeJSON(w, http.StatusOK, viewNotifySettings(u.Notify))
+}
+
+type notifyRequest struct {
+	Channels       []string `json:"channels"`
+	WebhookURL     *string  `json:"webhookUrl"`
+	QuietStart     *string  `json:"quietStart"`
+	QuietEnd       *string  `json:"quietEnd"`
+	TimeZone       *string  `json:"timeZone"`
+	ScheduleAlerts *bool    `json:"scheduleAlerts"`
+}
+
+// PATCH /api/me/notify
+func (h *Handler) HandleUpdateNotifySettings(w http.ResponseWriter, r *http.Request) {
+	u, _ := auth.UserFromContext(r.Context())
+	var req notifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	n := u.Notify
+	if req.Channels != nil {
+		n.Channels = req.Channels
+	}
+	if req.WebhookURL != nil {
+		n.WebhookURL = *req.WebhookURL
+	}
+	if req.QuietStart != nil {
+		n.QuietStart = *req.QuietStart
+	}
+	if req.QuietEnd != nil {
+		n.QuietEnd = *req.QuietEnd
+	}
+	if req.TimeZone != nil {
+		n.TimeZone = *req.TimeZone
+	}
+	if req.ScheduleAlerts != nil {
+		n.ScheduleAlerts = *req.ScheduleAlerts
+	}
+	if n.TimeZone == auth.DefaultTimeZone {
+		n.TimeZone = ""
+	}
+	updated, err := h.accounts.SetNotifySettings(u, n)
+	if err != nil {
+		writeWatchError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, viewNotifySettings(*updated))
+}
@@ -0,0 +1,581 @@
+package api
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"purdue_schedule/internal/data"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Worksheet is the registration-day view of a schedule: CRNs grouped by
+// course in the order they should be entered, plus backup CRNs.
+type Worksheet struct {
+	Campus     string            `json:"campus"`
+	CampusName string            `json:"campusName"`
+	Courses    []WorksheetCourse `json:"courses"`
+	CRNs       []string          `json:"crns"`
+	// Checks are the schedule validation findings, as at /api/schedule/validate
+	Checks []data.Finding `json:"checks"`
+	Text   string         `json:"text"`
+}
+
+// WorksheetCourse lists the chosen linked sections of one course and
+// alternate section groups that fit the rest of the schedule.
+type WorksheetCourse struct {
+	CourseId   string               `json:"courseId"`
+	Course     string               `json:"course"`
+	Title      string               `json:"title"`
+	Sections   []WorksheetSection   `json:"sections"`
+	Alternates [][]WorksheetSection `json:"alternates"`
+	Warnings   []string             `json:"warnings,omitempty"`
+}
+
+// WorksheetSection is a single CRN line on the worksheet.
+type WorksheetSection struct {
+	Id          string `json:"id"`
+	Crn         string `json:"crn"`
+	Type        string `json:"type"`
+	When        string `json:"when"`
+	Where       string `json:"where"`
+	CampusId    string `json:"campusId"`
+	WrongCampus bool   `json:"wrongCampus"`
+}
+
+// maxAlternateCombos bounds the search for backup section groups per course.
+const maxAlternateCombos = 500
+
+// buildWorksheet groups the chosen sections by course and computes up to
+// maxAlternates backup groups per course. If campus is empty the campus of
+// the majority of sections is assumed.
+func buildWorksheet(store *data.Store, sections []data.SectionInfo, campus string, maxAlternates int) Worksheet {
+	if campus == "" {
+		campus = majorityCampus(sections)
+	}
+
+	// Group chosen sections by course, keeping the order courses were given in
+	var order []string
+	byCourse := make(map[string][]data.SectionInfo)
+	courses := make(map[string]data.CourseSummary)
+	for _, s := range sections {
+		c, _ := store.CourseBySectionId(s.Id)
+		if _, ok := byCourse[c.Id]; !ok {
+			order = append(order, c.Id)
+			courses[c.Id] = c
+		}
+		byCourse[c.Id] = append(byCourse[c.Id], s)
+	}
+
+	ws := Worksheet{Campus: campus, CampusName: store.CampusName(campus)}
+	for _, courseId := range order {
+		chosen := byCourse[courseId]
+		sortLinkedSections(chosen)
+
+		// Everything else in the schedule that alternates must not collide with
+		others := make([]data.SectionInfo, 0, len(sections))
+		for _, s := range sections {
+			if c, _ := store.CourseBySectionId(s.Id); c.Id != courseId {
+				others = append(others, s)
+			}
+		}
+
+		c := courses[courseId]
+		wc := WorksheetCourse{
+			CourseId: courseId,
+			Course:   data.CourseLabel(store, c),
+			Title:    c.Title,
+		}
+		for _, s := range chosen {
+			ws.CRNs = append(ws.CRNs, s.Crn)
+			wcs := worksheetSection(s, campus)
+			if wcs.WrongCampus {
+				wc.Warnings = append(wc.Warnings, fmt.Sprintf("CRN %s is not offered on the %s campus", s.Crn, store.CampusName(campus)))
+			}
+			wc.Sections = append(wc.Sections, wcs)
+		}
+
+		for _, alt := range findAlternates(store.SectionsByCourse(courseId), chosen, others, campus, maxAlternates) {
+			group := make([]WorksheetSection, 0, len(alt))
+			for _, s := range alt {
+				group = append(group, worksheetSection(s, campus))
+			}
+			wc.Alternates = append(wc.Alternates, group)
+		}
+		ws.Courses = append(ws.Courses, wc)
+	}
+	ids := make([]string, 0, len(sections))
+	for _, s := range sections {
+		ids = append(ids, s.Id)
+	}
+	ws.Checks = store.Validate(ids, data.ValidateOptions{}).Findings
+	ws.Text = worksheetText(ws)
+	return ws
+}
+
+func worksheetSection(s data.SectionInfo, campus string) WorksheetSection {
+	return WorksheetSection{
+		Id:          s.Id,
+		Crn:         s.Crn,
+		Type:        s.Type,
+		When:        sectionWhen(s),
+		Where:       sectionWhere(s),
+		CampusId:    s.CampusId,
+		WrongCampus: campus != "" && s.CampusId != "" && s.CampusId != campus,
+	}
+}
+
+// findAlternates searches the linked groups of a course for section
+// combinations that avoid the rest of the schedule. Groups that keep more of
+// the current choice are preferred so backups disturb as little as possible.
+func findAlternates(all, chosen, others []data.SectionInfo, campus string, limit int) [][]data.SectionInfo {
+	if limit <= 0 || len(all) == 0 {
+		return nil
+	}
+	chosenIds := make(map[string]struct{}, len(chosen))
+	for _, s := range chosen {
+		chosenIds[s.Id] = struct{}{}
+	}
+
+	// Bucket candidates by linked group and component type. Every component
+	// type of a group is required, even when none of its sections fit.
+	var classOrder []string
+	byClass := make(map[string]map[string][]data.SectionInfo)
+	for _, s := range all {
+		if campus != "" && s.CampusId != "" && s.CampusId != campus {
+			continue
+		}
+		if _, ok := byClass[s.ClassId]; !ok {
+			classOrder = append(classOrder, s.ClassId)
+			byClass[s.ClassId] = make(map[string][]data.SectionInfo)
+		}
+		if conflictsWithAny(s, others) {
+			if _, ok := byClass[s.ClassId][s.Type]; !ok {
+				byClass[s.ClassId][s.Type] = nil
+			}
+			continue
+		}
+		byClass[s.ClassId][s.Type] = append(byClass[s.ClassId][s.Type], s)
+	}
+
+	type candidate struct {
+		sections []data.SectionInfo
+		kept     int
+	}
+	var candidates []candidate
+	for _, classId := range classOrder {
+		types := make([]string, 0, len(byClass[classId]))
+		for t := range byClass[classId] {
+			types = append(types, t)
+		}
+		sort.Slice(types, func(i, j int) bool {
+			ri, rj := sectionTypeRank(types[i]), sectionTypeRank(types[j])
+			if ri != rj {
+				return ri < rj
+			}
+			return types[i] < types[j]
+		})
+
+		var walk func(i int, picked []data.SectionInfo)
+		walk = func(i int, picked []data.SectionInfo) {
+			if len(candidates) >= maxAlternateCombos {
+				return
+			}
+			if i == len(types) {
+				kept := 0
+				for _, s := range picked {
+					if _, ok := chosenIds[s.Id]; ok {
+						kept++
+					}
+				}
+				if kept == len(picked) && kept == len(chosen) {
+					return // identical to the current choice
+				}
+				candidates = append(candidates, candidate{sections: append([]data.SectionInfo(nil), picked...), kept: kept})
+				return
+			}
+			for _, s := range byClass[classId][types[i]] {
+				if conflictsWithAny(s, picked) {
+					continue
+				}
+				walk(i+1, append(picked, s))
+			}
+		}
+		walk(0, nil)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].kept > candidates[j].kept })
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	out := make([][]data.SectionInfo, 0, len(candidates))
+	for _, c := range candidates {
+		out = append(out, c.sections)
+	}
+	return out
+}
+
+func conflictsWithAny(s data.SectionInfo, others []data.SectionInfo) bool {
+	for _, o := range others {
+		if data.SectionsConflict(s, o) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortLinkedSections orders a linked group the way it is entered at
+// registration: lecture first, then recitations, labs and the rest.
+func sortLinkedSections(sections []data.SectionInfo) {
+	sort.SliceStable(sections, func(i, j int) bool {
+		ri, rj := sectionTypeRank(sections[i].Type), sectionTypeRank(sections[j].Type)
+		if ri != rj {
+			return ri < rj
+		}
+		return sections[i].Crn < sections[j].Crn
+	})
+}
+
+func sectionTypeRank(t string) int {
+	switch strings.ToLower(t) {
+	case "lecture":
+		return 0
+	case "recitation":
+		return 1
+	case "laboratory", "lab":
+		return 2
+	default:
+		return 3
+	}
+}
+
+func majorityCampus(sections []data.SectionInfo) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, s := range sections {
+		if s.CampusId == "" {
+			continue
+		}
+		counts[s.CampusId]++
+		if counts[s.CampusId] > counts[best] || (counts[s.CampusId] == counts[best] && s.CampusId < best) {
+			best = s.CampusId
+		}
+	}
+	return best
+}
+
+// sectionWhen summarizes meeting times, e.g. "MWF 9:30 AM-10:20 AM".
+func sectionWhen(s data.SectionInfo) string {
+	parts := make([]string, 0, len(s.Meetings))
+	for _, m := range s.Meetings {
+		start, ok := m.StartMinutes()
+		if !ok || len(m.Days) == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %s-%s", data.DayLetters(m.Days), formatTime12Hour(start), formatTime12Hour(start+m.DurationMin)))
+	}
+	if len(parts) == 0 {
+		return "TBA"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// sectionWhere lists the distinct rooms a section meets in.
+func sectionWhere(s data.SectionInfo) string {
+	seen := make(map[string]struct{})
+	var parts []string
+	for _, m := range s.Meetings {
+		loc := strings.TrimSpace(m.BuildingCode + " " + m.RoomNumber)
+		if loc == "" {
+			continue
+		}
+		if _, ok := seen[loc]; ok {
+			continue
+		}
+		seen[loc] = struct{}{}
+		parts = append(parts, loc)
+	}
+	if len(parts) == 0 {
+		return "TBA"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// worksheetText renders the plain-text block students paste from.
+func worksheetText(ws Worksheet) string {
+	var b strings.Builder
+	b.WriteString("BoilerSchedule registration worksheet\n")
+	if ws.CampusName != "" {
+		fmt.Fprintf(&b, "Campus: %s\n", ws.CampusName)
+	}
+	fmt.Fprintf(&b, "\nCRNs to add:\n%s\n", strings.Join(ws.CRNs, " "))
+	for _, c := range ws.Courses {
+		fmt.Fprintf(&b, "\n%s  %s\n", c.Course, c.Title)
+		for _, s := range c.Sections {
+			fmt.Fprintf(&b, "  %s  %-4s %s  %s\n", s.Crn, typeBadge(s.Type), s.When, s.Where)
+		}
+		for i, alt := range c.Alternates {
+			crns := make([]string, 0, len(alt))
+			for _, s := range alt {
+				crns = append(crns, s.Crn)
+			}
+			fmt.Fprintf(&b, "  Backup %d: %s\n", i+1, strings.Join(crns, " "))
+		}
+		for _, warn := range c.Warnings {
+			fmt.Fprintf(&b, "  ! %s\n", warn)
+		}
+	}
+	if len(ws.Checks) > 0 {
+		b.WriteString("\nSchedule checks:\n")
+		for _, f := range ws.Checks {
+			fmt.Fprintf(&b, "  %s %s\n", findingMark(f.Severity), f.Message)
+		}
+	}
+	return b.String()
+}
+
+// findingMark prefixes a validation finding in plain-text output.
+func findingMark(severity string) string {
+	switch severity {
+	case data.SeverityError:
+		return "!!"
+	case data.SeverityWarning:
+		return "! "
+	default:
+		return "- "
+	}
+}
+
+// typeBadge shortens a section type to the three-letter badge used in the grid.
+func typeBadge(t string) string {
+	badge := strings.ToUpper(t)
+	if len(badge) > 3 {
+		badge = badge[:3]
+	}
+	return badge
+}
+
+// GET /api/schedule/worksheet?sections=sec1,sec2,...&campus=...&alternates=2&format=html|text|pdf|json&title=&accent=&logo=none
+func (h *Handler) HandleScheduleWorksheet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	ids, err := sectionIdsFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sections := h.store().SectionsByIds(ids)
+	if len(sections) == 0 {
+		http.Error(w, "no valid sections found", http.StatusBadRequest)
+		return
+	}
+
+	alternates := 2
+	if v := r.URL.Query().Get("alternates"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			alternates = parsed
+		}
+	}
+	if alternates > 2 {
+		alternates = 2
+	}
+
+	brand, err := h.branding(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ws := buildWorksheet(h.store(), sections, strings.TrimSpace(r.URL.Query().Get("campus")), alternates)
+
+	switch r.URL.Query().Get("format") {
+	case "json":
+		writeJSON(w, http.StatusOK, ws)
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(ws.Text))
+	case "pdf":
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", "attachment; filename=BoilerSchedule_worksheet.pdf")
+		if err := writeWorksheetPDF(w, ws, brand); err != nil {
+			http.Error(w, fmt.Sprintf("failed to create worksheet pdf: %v", err), http.StatusInternalServerError)
+		}
+	default:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		theme := brand.Theme(defaultTheme)
+		page := worksheetPage{Worksheet: ws, Brand: brand, Logo: template.URL(brand.LogoURI()),
+			Bar: template.CSS(theme.Bar), BarText: template.CSS(theme.BarText)}
+		if err := worksheetTemplate.Execute(w, page); err != nil {
+			http.Error(w, fmt.Sprintf("failed to render worksheet: %v", err), http.StatusInternalServerError)
+		}
+	}
+}
+
+// worksheetPage is the worksheet as the HTML template sees it.
+type worksheetPage struct {
+	Worksheet
+	Brand        Branding
+	Logo         template.URL // data URI, or empty
+	Bar, BarText template.CSS // "#RRGGBB"
+}
+
+var worksheetTemplate = template.Must(template.New("worksheet").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>{{.Brand.Title}} - Registration Worksheet</title>
+<style>
+body { font-family: Arial, sans-serif; margin: 0; color: #111; }
+header { background: {{.Bar}}; color: {{.BarText}}; padding: 16px 24px; display: flex; align-items: center; gap: 16px; }
+header img { height: 40px; width: auto; }
+header h1 { margin: 0; font-size: 24px; }
+header p { margin: 4px 0 0; font-size: 13px; }
+main { padding: 16px 24px; }
+pre { background: #f4f4f4; border: 1px solid #ddd; padding: 12px; font-size: 13px; white-space: pre-wrap; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 8px; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #e5e5e5; font-size: 13px; }
+th { background: #f8f9fa; }
+h2 { font-size: 16px; margin: 20px 0 6px; }
+.crn { font-family: monospace; font-weight: bold; }
+.alt { color: #555; font-size: 13px; margin: 2px 0; }
+.warn { color: #b00020; font-size: 13px; margin: 2px 0; }
+.check { font-size: 13px; margin: 2px 0; }
+.check.error { color: #b00020; font-weight: bold; }
+.check.warning { color: #8a5a00; }
+.check.info { color: #555; }
+tr.wrong td { background: #fdecea; }
+@media print { pre { border-color: #999; } }
+</style>
+</head>
+<body>
+<header>
+{{with .Logo}}<img src="{{.}}" alt="">
+{{end}}<div>
+<h1>{{.Brand.Title}}</h1>
+<p>Registration worksheet{{if .CampusName}} - {{.CampusName}} campus{{end}}</p>
+</div>
+</header>
+<main>
+{{if .Checks}}<h2>Schedule checks</h2>
+{{range .Checks}}<p class="check {{.Severity}}">{{if eq .Severity "info"}}&#8505;{{else}}&#9888;{{end}} {{.Message}}</p>
+{{end}}{{end}}<h2>CRNs to add</h2>
+<pre>{{.Text}}</pre>
+{{range .Courses}}
+<h2>{{.Course}} - {{.Title}}</h2>
+<table>
+<tr><th>CRN</th><th>Type</th><th>When</th><th>Where</th></tr>
+{{range .Sections}}<tr{{if .WrongCampus}} class="wrong"{{end}}><td class="crn">{{.Crn}}</td><td>{{.Type}}</td><td>{{.When}}</td><td>{{.Where}}</td></tr>
+{{end}}</table>
+{{range $i, $alt := .Alternates}}<p class="alt">Backup {{inc $i}}:{{range $alt}} <span class="crn">{{.Crn}}</span> ({{.Type}}, {{.When}}){{end}}</p>
+{{end}}{{range .Warnings}}<p class="warn">&#9888; {{.}}</p>
+{{end}}{{end}}
+</main>
+</body>
+</html>
+`))
+
+// writeWorksheetPDF renders the worksheet as a portrait Letter page using the
+// same header styling as the schedule PDF.
+func writeWorksheetPDF(w io.Writer, ws Worksheet, brand Branding) error {
+	pdf := gofpdf.New("P", "mm", "Letter", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle(brand.Title, true)
+	pdf.SetAutoPageBreak(true, 15)
+	logo := pdfLogo(pdf, brand)
+	pdf.AddPage()
+	pageW, _ := pdf.GetPageSize()
+	theme := brand.Theme(defaultTheme)
+
+	// Header bar
+	pdf.SetFillColor(hexToRGB(theme.Bar))
+	pdf.Rect(0, 0, pageW, 28, "F")
+	x := 15.0
+	if logo != "" {
+		info := pdf.GetImageInfo(logo)
+		logoW := min(20*info.Width()/info.Height(), pageW/4)
+		pdf.ImageOptions(logo, x, 4, logoW, 0, false, gofpdf.ImageOptions{}, 0, "")
+		x += logoW + 4
+	}
+	pdf.SetTextColor(hexToRGB(theme.BarText))
+	pdf.SetFont("Arial", "B", 20)
+	pdf.SetXY(x, 7)
+	pdf.Cell(0, 8, tr(brand.Title))
+	pdf.SetFont("Arial", "", 11)
+	pdf.SetXY(x, 17)
+	subtitle := "Registration worksheet"
+	if ws.CampusName != "" {
+		subtitle += " - " + ws.CampusName + " campus"
+	}
+	pdf.Cell(0, 6, subtitle)
+
+	// CRN block to type into the add-classes form
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetXY(15, 36)
+	pdf.SetFont("Arial", "B", 13)
+	pdf.Cell(0, 7, "CRNs to add")
+	pdf.Ln(8)
+	pdf.SetFont("Courier", "B", 13)
+	pdf.SetFillColor(244, 244, 244)
+	pdf.MultiCell(0, 7, strings.Join(ws.CRNs, "  "), "1", "L", true)
+	pdf.Ln(4)
+
+	for _, c := range ws.Courses {
+		pdf.SetFont("Arial", "B", 12)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.MultiCell(0, 6, fmt.Sprintf("%s - %s", c.Course, c.Title), "", "L", false)
+
+		pdf.SetFont("Arial", "", 10)
+		for _, s := range c.Sections {
+			if s.WrongCampus {
+				pdf.SetTextColor(176, 0, 32)
+			} else {
+				pdf.SetTextColor(0, 0, 0)
+			}
+			pdf.SetFont("Courier", "B", 10)
+			pdf.CellFormat(18, 5, s.Crn, "", 0, "L", false, 0, "")
+			pdf.SetFont("Arial", "", 10)
+			pdf.CellFormat(14, 5, typeBadge(s.Type), "", 0, "L", false, 0, "")
+			pdf.CellFormat(0, 5, fmt.Sprintf("%s   %s", s.When, s.Where), "", 1, "L", false, 0, "")
+		}
+
+		pdf.SetTextColor(90, 90, 90)
+		for i, alt := range c.Alternates {
+			crns := make([]string, 0, len(alt))
+			for _, s := range alt {
+				crns = append(crns, s.Crn)
+			}
+			pdf.CellFormat(0, 5, fmt.Sprintf("Backup %d: %s", i+1, strings.Join(crns, " ")), "", 1, "L", false, 0, "")
+		}
+		pdf.SetTextColor(176, 0, 32)
+		for _, warn := range c.Warnings {
+			pdf.MultiCell(0, 5, "! "+warn, "", "L", false)
+		}
+		pdf.Ln(3)
+	}
+
+	if len(ws.Checks) > 0 {
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFont("Arial", "B", 12)
+		pdf.MultiCell(0, 6, "Schedule checks", "", "L", false)
+		pdf.SetFont("Arial", "", 10)
+		for _, f := range ws.Checks {
+			switch f.Severity {
+			case data.SeverityError:
+				pdf.SetTextColor(176, 0, 32)
+			case data.SeverityWarning:
+				pdf.SetTextColor(138, 90, 0)
+			default:
+				pdf.SetTextColor(90, 90, 90)
+			}
+			pdf.MultiCell(0, 5, findingMark(f.Severity)+" "+f.Message, "", "L", false)
+		}
+	}
+
+	return pdf.Output(w)
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"errors"
+	"sort"
+)
+
+// Operator actions used by cmd/admin. They address accounts by email and
+// bypass the checks the HTTP flows apply to the signed-in user.
+
+// Users lists every account, oldest first.
+func (s *Service) Users() ([]*User, error) {
+	users, err := s.store.Users()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].CreatedAt.Before(users[j].CreatedAt) })
+	return users, nil
+}
+
+// User looks up an account by email.
+func (s *Service) User(email string) (*User, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.UserByEmail(email)
+}
+
+// MarkVerified verifies an account without a code.
+func (s *Service) MarkVerified(email string) (*User, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.UpdateUser(email, func(u *User) error {
+		u.IsVerified = true
+		return nil
+	})
+}
+
+// SetDisabled blocks or unblocks sign-in. Disabling also ends every session.
+func (s *Service) SetDisabled(email string, disabled bool) (*User, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.store.UpdateUser(email, func(u *User) error {
+		u.Disabled = disabled
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if disabled {
+		if _, err := s.RevokeSessions(u, ""); err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+// DeleteAccount removes an account with its sessions, pending code and the
+// friend records other users hold for it.
+func (s *Service) DeleteAccount(email string) error {
+	u, err := s.User(email)
+	if err != nil {
+		return err
+	}
+	if _, err := s.RevokeSessions(u, ""); err != nil {
+		return err
+	}
+	for _, f := range u.Friends {
+		other, err := s.store.UserById(f.UserId)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := s.store.UpdateUser(other.Email, func(o *User) error {
+			if i := friendIndex(o, u.Id); i >= 0 {
+				o.Friends = append(o.Friends[:i], o.Friends[i+1:]...)
+			}
+			return nil
+		}); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	if err := s.store.DeleteOTP(u.Email); err != nil {
+		return err
+	}
+	return s.store.DeleteUser(u.Email)
+}
+
+// Export is everything stored about one account, in its stored form. The
+// password hash and session tokens are left out.
+type Export struct {
+	User     User              `json:"user"`
+	Sessions []ExportedSession `json:"sessions"`
+}
+
+// ExportedSession replaces the session token with its SessionId.
+type ExportedSession struct {
+	Session
+	Id string `json:"id"`
+}
+
+// ExportUser gathers an account's data for a data request.
+func (s *Service) ExportUser(email string) (*Export, error) {
+	u, err := s.User(email)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.store.SessionsByUser(u.Id)
+	if err != nil {
+		return nil, err
+	}
+	out := &Export{User: *u, Sessions: make([]ExportedSession, 0, len(sessions))}
+	out.User.PasswordHash = ""
+	for _, sess := range sessions {
+		es := ExportedSession{Session: *sess, Id: SessionId(sess.Token)}
+		es.Token = ""
+		out.Sessions = append(out.Sessions, es)
+	}
+	return out, nil
+}
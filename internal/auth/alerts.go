@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"errors"
+	"slices"
+)
+
+// MaxAlerts caps the alerts kept per account; the oldest are dropped.
+const MaxAlerts = 200
+
+// AlertRemoved is the Field of an alert for a section that left the catalog.
+const AlertRemoved = "removed"
+
+var ErrAlertNotFound = errors.New("alert not found")
+
+// Alerts returns the user's alerts, newest first.
+func (s *Service) Alerts(u *User) []Alert {
+	out := slices.Clone(u.Alerts)
+	slices.Reverse(out)
+	if out == nil {
+		return []Alert{}
+	}
+	return out
+}
+
+// AddAlerts stores new alerts for the user. They are marked pending when
+// the user asked for schedule alerts to be delivered.
+func (s *Service) AddAlerts(u *User, alerts []Alert) error {
+	now := s.now()
+	for i := range alerts {
+		id, err := randomHex(8)
+		if err != nil {
+			return err
+		}
+		alerts[i].Id = id
+		alerts[i].CreatedAt = now
+	}
+	return s.updateById(u, func(u *User) error {
+		for _, a := range alerts {
+			a.Pending = u.Notify.ScheduleAlerts
+			u.Alerts = append(u.Alerts, a)
+		}
+		if over := len(u.Alerts) - MaxAlerts; over > 0 {
+			u.Alerts = slices.Delete(u.Alerts, 0, over)
+		}
+		return nil
+	})
+}
+
+// MarkAlertsRead marks the alerts with the given ids read, or every alert
+// when ids is empty. It returns how many changed.
+func (s *Service) MarkAlertsRead(u *User, ids []string) (int, error) {
+	n := 0
+	_, err := s.store.UpdateUser(u.Email, func(u *User) error {
+		n = 0
+		for i := range u.Alerts {
+			if !u.Alerts[i].Read && (len(ids) == 0 || slices.Contains(ids, u.Alerts[i].Id)) {
+				u.Alerts[i].Read = true
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+// DeleteAlert removes one alert.
+func (s *Service) DeleteAlert(u *User, id string) error {
+	_, err := s.store.UpdateUser(u.Email, func(u *User) error {
+		i := slices.IndexFunc(u.Alerts, func(a Alert) bool { return a.Id == id })
+		if i < 0 {
+			return ErrAlertNotFound
+		}
+		u.Alerts = slices.Delete(u.Alerts, i, i+1)
+		return nil
+	})
+	return err
+}
+
+// AlertsDelivered clears the pending flag of the alerts with the given ids.
+func (s *Service) AlertsDelivered(u *User, ids []string) error {
+	return s.updateById(u, func(u *User) error {
+		for i := range u.Alerts {
+			if slices.Contains(ids, u.Alerts[i].Id) {
+				u.Alerts[i].Pending = false
+			}
+		}
+		return nil
+	})
+}
+
+// updateById applies fn to the stored record of u, looked up by id since
+// the email may have changed since u was read, e.g. during a background
+// check.
+func (s *Service) updateById(u *User, fn func(*User) error) error {
+	current, err := s.store.UserById(u.Id)
+	if err != nil {
+		return err
+	}
+	_, err = s.store.UpdateUser(current.Email, fn)
+	return err
+}
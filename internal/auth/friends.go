@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"slices"
+)
+
+// Friend statuses, from the point of view of the user holding the record
+const (
+	FriendIncoming = "incoming" // they asked, waiting on us
+	FriendOutgoing = "outgoing" // we asked, waiting on them
+	FriendAccepted = "accepted"
+)
+
+// MaxFriends caps connections, pending ones included, per account.
+const MaxFriends = 200
+
+var (
+	ErrFriendNotFound = errors.New("friend not found")
+	ErrSelfFriend     = errors.New("you cannot add yourself as a friend")
+	ErrTooManyFriends = errors.New("too many friends")
+)
+
+// Friends returns the user's connections, pending ones included.
+func (s *Service) Friends(u *User) []Friend {
+	if u.Friends == nil {
+		return []Friend{}
+	}
+	return u.Friends
+}
+
+// FriendUsers returns the accounts of the user's accepted friends. Friends
+// whose account has since been deleted are skipped.
+func (s *Service) FriendUsers(u *User) ([]*User, error) {
+	out := make([]*User, 0, len(u.Friends))
+	for _, f := range u.Friends {
+		if f.Status != FriendAccepted {
+			continue
+		}
+		friend, err := s.store.UserById(f.UserId)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, friend)
+	}
+	return out, nil
+}
+
+// UserById looks up another account, e.g. to describe a friend.
+func (s *Service) UserById(id string) (*User, error) {
+	return s.store.UserById(id)
+}
+
+// AddFriend asks the account registered to email to connect. If they had
+// already asked us, the connection is accepted instead. When no verified
+// account has the address the request is kept on our side only, so the
+// answer is the same whether or not the address is registered.
+func (s *Service) AddFriend(u *User, email string) (*Friend, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if email == u.Email {
+		return nil, ErrSelfFriend
+	}
+	other, err := s.store.UserByEmail(email)
+	if errors.Is(err, ErrNotFound) || (err == nil && !other.IsVerified) {
+		return s.askUnmatched(u, email)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if other.Id == u.Id {
+		return nil, ErrSelfFriend
+	}
+	if i := friendIndex(u, other.Id); i >= 0 {
+		switch u.Friends[i].Status {
+		case FriendIncoming:
+			return s.AcceptFriend(u, other.Id)
+		default:
+			f := u.Friends[i]
+			return &f, nil
+		}
+	}
+	if slices.ContainsFunc(u.Friends, unmatched(email)) {
+		// They have signed up since we asked; ask them for real
+		if _, err := s.store.UpdateUser(u.Email, func(me *User) error {
+			me.Friends = slices.DeleteFunc(me.Friends, unmatched(email))
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return s.link(u, other, FriendOutgoing, FriendIncoming)
+}
+
+// askUnmatched records a request to an address with no verified account.
+// It is never delivered, but reads like any other outgoing request and
+// counts towards MaxFriends.
+func (s *Service) askUnmatched(u *User, email string) (*Friend, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	var f Friend
+	if _, err := s.store.UpdateUser(u.Email, func(me *User) error {
+		if i := slices.IndexFunc(me.Friends, unmatched(email)); i >= 0 {
+			f = me.Friends[i]
+			return nil
+		}
+		if len(me.Friends) >= MaxFriends {
+			return ErrTooManyFriends
+		}
+		f = Friend{UserId: id, Status: FriendOutgoing, Since: s.now(), Email: email, Unmatched: true}
+		me.Friends = append(me.Friends, f)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func unmatched(email string) func(Friend) bool {
+	return func(f Friend) bool { return f.Unmatched && f.Email == email }
+}
+
+// AcceptFriend accepts a pending request from the user with id.
+func (s *Service) AcceptFriend(u *User, id string) (*Friend, error) {
+	i := friendIndex(u, id)
+	if i < 0 || u.Friends[i].Status == FriendOutgoing {
+		return nil, ErrFriendNotFound
+	}
+	if u.Friends[i].Status == FriendAccepted {
+		f := u.Friends[i]
+		return &f, nil
+	}
+	other, err := s.store.UserById(id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrFriendNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.link(u, other, FriendAccepted, FriendAccepted)
+}
+
+// link writes a connection on both accounts: mine on u and theirs on other.
+// Both limits are checked before either account is written, and their side
+// is undone if ours then fails, so a connection is never left one-sided.
+func (s *Service) link(u, other *User, mine, theirs string) (*Friend, error) {
+	now := s.now()
+	full := func(holder *User, id string) bool {
+		return friendIndex(holder, id) < 0 && len(holder.Friends) >= MaxFriends
+	}
+	set := func(holder *User, id, status, email string) error {
+		if full(holder, id) {
+			return ErrTooManyFriends
+		}
+		if i := friendIndex(holder, id); i >= 0 {
+			holder.Friends[i].Status = status
+			holder.Friends[i].Since = now
+			holder.Friends[i].Email = email
+			return nil
+		}
+		holder.Friends = append(holder.Friends, Friend{UserId: id, Status: status, Since: now, Email: email})
+		return nil
+	}
+	// Only an outgoing request keeps the address it was sent to
+	mineEmail := ""
+	if mine == FriendOutgoing {
+		mineEmail = other.Email
+	}
+	current, err := s.store.UserById(u.Id)
+	if err != nil {
+		return nil, err
+	}
+	if full(current, other.Id) || full(other, u.Id) {
+		return nil, ErrTooManyFriends
+	}
+
+	var prev *Friend // their record of us before this change
+	if _, err := s.store.UpdateUser(other.Email, func(o *User) error {
+		if i := friendIndex(o, u.Id); i >= 0 {
+			f := o.Friends[i]
+			prev = &f
+		}
+		return set(o, u.Id, theirs, "")
+	}); err != nil {
+		return nil, err
+	}
+	updated, err := s.store.UpdateUser(u.Email, func(me *User) error { return set(me, other.Id, mine, mineEmail) })
+	if err != nil {
+		if _, undoErr := s.store.UpdateUser(other.Email, func(o *User) error {
+			i := friendIndex(o, u.Id)
+			switch {
+			case i < 0:
+			case prev == nil:
+				o.Friends = slices.Delete(o.Friends, i, i+1)
+			default:
+				o.Friends[i] = *prev
+			}
+			return nil
+		}); undoErr != nil {
+			log.Printf("friends: undo link %s -> %s: %v", other.Id, u.Id, undoErr)
+		}
+		return nil, err
+	}
+	f := updated.Friends[friendIndex(updated, other.Id)]
+	return &f, nil
+}
+
+// RemoveFriend ends a connection or withdraws or declines a request, on
+// both sides.
+func (s *Service) RemoveFriend(u *User, id string) error {
+	if friendIndex(u, id) < 0 {
+		return ErrFriendNotFound
+	}
+	unset := func(holder *User, id string) error {
+		holder.Friends = slices.DeleteFunc(holder.Friends, func(f Friend) bool { return f.UserId == id })
+		return nil
+	}
+	if _, err := s.store.UpdateUser(u.Email, func(me *User) error { return unset(me, id) }); err != nil {
+		return err
+	}
+	other, err := s.store.UserById(id)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.store.UpdateUser(other.Email, func(o *User) error { return unset(o, u.Id) })
+	return err
+}
+
+func friendIndex(u *User, id string) int {
+	for i := range u.Friends {
+		if u.Friends[i].UserId == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,69 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+
+	"purdue_schedule/internal/auth"
+)
+
+func TestAddFriendDoesNotRevealAccounts(t *testing.T) {
+	e := newEnv(t)
+	pete, _ := e.signedUp(t, "pete@purdue.edu", "boilerup1")
+	rowan, _ := e.signedUp(t, "rowan@purdue.edu", "boilerup1")
+
+	asked, err := e.svc.AddFriend(pete, "rowan@purdue.edu")
+	if err != nil {
+		t.Fatalf("AddFriend to an account: %v", err)
+	}
+	silent, err := e.svc.AddFriend(pete, "nobody@purdue.edu")
+	if err != nil {
+		t.Fatalf("AddFriend to an unknown address: got %v, want nil", err)
+	}
+	for _, f := range []*auth.Friend{asked, silent} {
+		if f.Status != auth.FriendOutgoing || f.Email == "" || len(f.UserId) != len(rowan.Id) {
+			t.Errorf("request %+v: want an outgoing request carrying the address", f)
+		}
+	}
+	again, err := e.svc.AddFriend(pete, "nobody@purdue.edu")
+	if err != nil || again.UserId != silent.UserId {
+		t.Errorf("asking the unknown address again: got %+v, %v; want the same request", again, err)
+	}
+
+	rowan, err = e.svc.User(rowan.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.svc.Friends(rowan); len(got) != 1 || got[0].Status != auth.FriendIncoming {
+		t.Errorf("rowan's requests: got %+v, want one incoming", got)
+	}
+
+	// Signing up later turns the silent request into a real one on the
+	// next ask
+	nobody, _ := e.signedUp(t, "nobody@purdue.edu", "boilerup1")
+	if got := e.svc.Friends(nobody); len(got) != 0 {
+		t.Errorf("requests delivered before asking again: got %+v", got)
+	}
+	pete, err = e.svc.User(pete.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := e.svc.AddFriend(pete, "nobody@purdue.edu")
+	if err != nil {
+		t.Fatalf("AddFriend after signup: %v", err)
+	}
+	if f.UserId != nobody.Id || f.Unmatched {
+		t.Errorf("request after signup: got %+v, want one to %s", f, nobody.Id)
+	}
+	pete, err = e.svc.User(pete.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(e.svc.Friends(pete)); n != 2 {
+		t.Errorf("pete's requests: got %d, want 2", n)
+	}
+
+	if _, err := e.svc.AddFriend(pete, "Pete@Purdue.edu"); !errors.Is(err, auth.ErrSelfFriend) {
+		t.Errorf("adding yourself: got %v, want ErrSelfFriend", err)
+	}
+}
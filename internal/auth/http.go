@@ -0,0 +1,417 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// CookieName is the session cookie set on login.
+const CookieName = "boiler_session"
+
+type ctxKey int
+
+const (
+	userKey ctxKey = iota
+	sessionKey
+)
+
+// UserFromContext returns the signed-in user attached by Middleware.
+func UserFromContext(ctx context.Context) (*User, bool) {
+	u, ok := ctx.Value(userKey).(*User)
+	return u, ok && u != nil
+}
+
+// SessionFromContext returns the session attached by Middleware.
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	s, ok := ctx.Value(sessionKey).(*Session)
+	return s, ok && s != nil
+}
+
+// Handler exposes the account flows over HTTP.
+type Handler struct {
+	svc *Service
+}
+
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// statusFor maps service errors to HTTP status codes; unknown errors are
+// logged and reported generically.
+func statusFor(w http.ResponseWriter, err error) {
+	var retry *RetryError
+	switch {
+	case errors.As(err, &retry):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.After.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, err)
+	case errors.Is(err, ErrInvalidEmail), errors.Is(err, ErrWeakPassword), errors.Is(err, ErrInvalidCode):
+		writeError(w, http.StatusBadRequest, err)
+	case errors.Is(err, ErrEmailTaken):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, ErrInvalidCredentials), errors.Is(err, ErrNoSession):
+		writeError(w, http.StatusUnauthorized, err)
+	case errors.Is(err, ErrSessionNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrNotVerified), errors.Is(err, ErrDisabled):
+		writeError(w, http.StatusForbidden, err)
+	default:
+		log.Printf("auth: %v", err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+	}
+}
+
+// tokenFromRequest reads the session cookie, falling back to a bearer token
+// for scripts.
+func tokenFromRequest(r *http.Request) string {
+	if c, err := r.Cookie(CookieName); err == nil && c.Value != "" {
+		return c.Value
+	}
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(h, "Bearer "))
+	}
+	return ""
+}
+
+// clientFrom describes the device making a request. The address is taken
+// from the connection, not from forwarding headers a client could forge.
+func clientFrom(r *http.Request) Client {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	ua := r.UserAgent()
+	if len(ua) > 512 {
+		ua = ua[:512]
+	}
+	return Client{UserAgent: ua, IP: ip}
+}
+
+func setSessionCookie(w http.ResponseWriter, r *http.Request, sess *Session) {
+	c := &http.Cookie{
+		Name:     CookieName,
+		Value:    sess.Token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	}
+	// Without remember-me the cookie lasts for the browser session only
+	if sess.RememberMe {
+		c.Expires = sess.ExpiresAt
+	}
+	http.SetCookie(w, c)
+}
+
+func clearSessionCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Middleware attaches the signed-in user, if any, to the request context.
+// It never rejects a request; use RequireUser for that.
+func (h *Handler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := tokenFromRequest(r)
+		if token != "" {
+			if u, sess, renewed, err := h.svc.authenticate(token); err == nil {
+				if renewed {
+					setSessionCookie(w, r, sess)
+				}
+				ctx := context.WithValue(r.Context(), userKey, u)
+				ctx = context.WithValue(ctx, sessionKey, sess)
+				r = r.WithContext(ctx)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireUser rejects requests without a signed-in user.
+func RequireUser(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := UserFromContext(r.Context()); !ok {
+			writeError(w, http.StatusUnauthorized, ErrNoSession)
+			return
+		}
+		next(w, r)
+	}
+}
+
+type signupRequest struct {
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// POST /api/auth/signup
+func (h *Handler) HandleSignup(w http.ResponseWriter, r *http.Request) {
+	var req signupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	u, err := h.svc.Signup(req.Email, req.Name, req.Password)
+	if err != nil {
+		statusFor(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"user": Public(u), "verificationSent": true})
+}
+
+type verifyRequest struct {
+	Email      string `json:"email"`
+	Code       string `json:"code"`
+	RememberMe bool   `json:"rememberMe"`
+}
+
+// POST /api/auth/verify
+func (h *Handler) HandleVerify(w http.ResponseWriter, r *http.Request) {
+	var req verifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	u, sess, err := h.svc.Verify(req.Email, req.Code, req.RememberMe, clientFrom(r))
+	if err != nil {
+		statusFor(w, err)
+		return
+	}
+	setSessionCookie(w, r, sess)
+	writeJSON(w, http.StatusOK, map[string]any{"user": Public(u)})
+}
+
+type resendRequest struct {
+	Email string `json:"email"`
+}
+
+// POST /api/auth/resend
+func (h *Handler) HandleResend(w http.ResponseWriter, r *http.Request) {
+	var req resendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	email, err := NormalizeEmail(req.Email)
+	if err != nil {
+		statusFor(w, err)
+		return
+	}
+	// Only pending accounts get codes; answer the same either way so the
+	// endpoint cannot be used to probe for accounts.
+	if u, err := h.svc.store.UserByEmail(email); err == nil && !u.IsVerified {
+		if err := h.svc.SendCode(email, PurposeSignup); err != nil {
+			statusFor(w, err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"verificationSent": true})
+}
+
+type loginRequest struct {
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	RememberMe bool   `json:"rememberMe"`
+}
+
+type resetRequest struct {
+	Email    string `json:"email"`
+	Code     string `json:"code"`
+	Password string `json:"password"`
+}
+
+// POST /api/auth/reset/request
+func (h *Handler) HandleResetRequest(w http.ResponseWriter, r *http.Request) {
+	var req resetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	// Same answer whether or not the account exists or is cooling down
+	if err := h.svc.RequestPasswordReset(req.Email); err != nil && !errors.Is(err, ErrCooldown) {
+		statusFor(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"resetSent": true})
+}
+
+// POST /api/auth/reset/confirm
+func (h *Handler) HandleResetConfirm(w http.ResponseWriter, r *http.Request) {
+	var req resetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	if err := h.svc.ResetPassword(req.Email, req.Code, req.Password, clientFrom(r)); err != nil {
+		statusFor(w, err)
+		return
+	}
+	clearSessionCookie(w, r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type emailChangeRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// POST /api/me/email
+func (h *Handler) HandleEmailChange(w http.ResponseWriter, r *http.Request) {
+	u, _ := UserFromContext(r.Context())
+	var req emailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	if err := h.svc.RequestEmailChange(u, req.Email, req.Password); err != nil {
+		statusFor(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"verificationSent": true})
+}
+
+// POST /api/me/email/confirm
+func (h *Handler) HandleEmailChangeConfirm(w http.ResponseWriter, r *http.Request) {
+	u, _ := UserFromContext(r.Context())
+	var req emailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	updated, err := h.svc.ConfirmEmailChange(u, req.Email, req.Code, clientFrom(r))
+	if err != nil {
+		statusFor(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"user": Public(updated)})
+}
+
+// POST /api/auth/login
+func (h *Handler) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	u, sess, err := h.svc.Login(req.Email, req.Password, req.RememberMe, clientFrom(r))
+	if err != nil {
+		statusFor(w, err)
+		return
+	}
+	setSessionCookie(w, r, sess)
+	writeJSON(w, http.StatusOK, map[string]any{"user": Public(u)})
+}
+
+// POST /api/auth/logout
+func (h *Handler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.Logout(tokenFromRequest(r)); err != nil {
+		statusFor(w, err)
+		return
+	}
+	clearSessionCookie(w, r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/auth/me
+func (h *Handler) HandleMe(w http.ResponseWriter, r *http.Request) {
+	u, _ := UserFromContext(r.Context())
+	writeJSON(w, http.StatusOK, map[string]any{"user": Public(u)})
+}
+
+// SessionView is a signed-in device as shown to its owner.
+type SessionView struct {
+	Id         string    `json:"id"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	RememberMe bool      `json:"rememberMe"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+}
+
+// GET /api/me/sessions
+func (h *Handler) HandleListSessions(w http.ResponseWriter, r *http.Request) {
+	u, _ := UserFromContext(r.Context())
+	current, _ := SessionFromContext(r.Context())
+	sessions, err := h.svc.Sessions(u)
+	if err != nil {
+		statusFor(w, err)
+		return
+	}
+	out := make([]SessionView, 0, len(sessions))
+	for _, sess := range sessions {
+		out = append(out, SessionView{
+			Id:         SessionId(sess.Token),
+			Current:    current != nil && sess.Token == current.Token,
+			CreatedAt:  sess.CreatedAt,
+			LastUsedAt: lastUse(sess),
+			ExpiresAt:  sess.ExpiresAt,
+			RememberMe: sess.RememberMe,
+			UserAgent:  sess.UserAgent,
+			IP:         sess.IP,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// DELETE /api/me/sessions/{id}
+func (h *Handler) HandleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	u, _ := UserFromContext(r.Context())
+	id := mux.Vars(r)["id"]
+	if err := h.svc.RevokeSession(u, id); err != nil {
+		statusFor(w, err)
+		return
+	}
+	if current, ok := SessionFromContext(r.Context()); ok && SessionId(current.Token) == id {
+		clearSessionCookie(w, r)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /api/me/sessions signs out every other device; ?all=true also ends
+// the current session.
+func (h *Handler) HandleRevokeSessions(w http.ResponseWriter, r *http.Request) {
+	u, _ := UserFromContext(r.Context())
+	keep := ""
+	all := r.URL.Query().Get("all") == "true"
+	if current, ok := SessionFromContext(r.Context()); ok && !all {
+		keep = current.Token
+	}
+	n, err := h.svc.RevokeSessions(u, keep)
+	if err != nil {
+		statusFor(w, err)
+		return
+	}
+	if all {
+		clearSessionCookie(w, r)
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"revoked": n})
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Mailer delivers plain-text email. Implementations must be safe for
+// concurrent use.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPMailer sends mail through an SMTP relay. Leave Username empty to send
+// without authentication, e.g. to a local stand-in server during tests.
+type SMTPMailer struct {
+	Addr     string // host:port
+	From     string
+	Username string
+	Password string
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	var a smtp.Auth
+	if m.Username != "" {
+		host := m.Addr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		a = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		m.From, to, subject, time.Now().Format(time.RFC1123Z), strings.ReplaceAll(body, "\n", "\r\n"))
+	return smtp.SendMail(m.Addr, a, m.From, []string{to}, []byte(msg))
+}
+
+// LogMailer writes messages to the server log instead of sending them. It is
+// the default when no SMTP server is configured. Verification codes are
+// redacted unless ShowCodes is set, for local development.
+type LogMailer struct {
+	ShowCodes bool
+}
+
+// loggedCode matches the line a code is mailed on.
+var loggedCode = regexp.MustCompile(`(?m)^([ \t]*)\d{6}[ \t]*$`)
+
+func (m LogMailer) Send(to, subject, body string) error {
+	if !m.ShowCodes {
+		body = loggedCode.ReplaceAllString(body, "${1}[code redacted]")
+	}
+	log.Printf("mail to %s: %s\n%s", to, subject, body)
+	return nil
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"time"
+
+	"purdue_schedule/internal/storage"
+)
+
+// Records are defined by the storage layer; the aliases keep auth callers
+// independent of where they are persisted.
+type (
+	User           = storage.User
+	SavedSchedule  = storage.SavedSchedule
+	SavedSection   = storage.SavedSection
+	Session        = storage.Session
+	OTP            = storage.OTP
+	Friend         = storage.Friend
+	Watch          = storage.Watch
+	NotifySettings = storage.NotifySettings
+	Alert          = storage.Alert
+	Plan           = storage.Plan
+	PlanTerm       = storage.PlanTerm
+	PlannedCourse  = storage.PlannedCourse
+)
+
+// PublicUser is the user shape returned to clients (no password hash)
+type PublicUser struct {
+	Id         string    `json:"id"`
+	Email      string    `json:"email"`
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"createdAt"`
+	IsVerified bool      `json:"isVerified"`
+}
+
+// Public strips secrets from a user record.
+func Public(u *User) PublicUser {
+	return PublicUser{
+		Id:         u.Id,
+		Email:      u.Email,
+		Name:       u.Name,
+		CreatedAt:  u.CreatedAt,
+		IsVerified: u.IsVerified,
+	}
+}
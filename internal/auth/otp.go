@@ -0,0 +1,342 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Code purposes. A code only redeems the flow it was issued for.
+const (
+	PurposeSignup        = "signup"
+	PurposePasswordReset = "password_reset"
+	PurposeEmailChange   = "email_change"
+)
+
+// Limits on code delivery and guessing. A code is burned after
+// MaxCodeAttempts wrong guesses; repeated failures lock out the address or
+// client for LockoutDuration.
+const (
+	ResendCooldown    = time.Minute
+	MaxCodeAttempts   = 5
+	EmailFailureLimit = 10
+	IPFailureLimit    = 50
+	FailureWindow     = 15 * time.Minute
+	LockoutDuration   = 15 * time.Minute
+)
+
+var (
+	ErrTooManyAttempts = errors.New("too many attempts, try again later")
+	ErrCooldown        = errors.New("a code was sent recently, wait before requesting another")
+)
+
+// RetryError wraps ErrTooManyAttempts or ErrCooldown with how long the
+// caller should wait.
+type RetryError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *RetryError) Error() string { return e.Err.Error() }
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// SetClock replaces the service's time source, for tests.
+func (s *Service) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// SendCode issues a new six-digit code of the given purpose for an email
+// and mails it. Only a bcrypt hash of the code is stored.
+func (s *Service) SendCode(email, purpose string) error {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	otp := &OTP{Email: email, Purpose: purpose}
+	if purpose == PurposeSignup {
+		// A resent signup code keeps the password of the signup it replaces
+		if prev, err := s.store.OTP(email); err == nil && !prev.Used && prev.Purpose == PurposeSignup {
+			otp.UserId, otp.Name, otp.PasswordHash = prev.UserId, prev.Name, prev.PasswordHash
+		}
+	}
+	return s.sendCode(otp)
+}
+
+// cooldown reports how long until another code may be sent to email.
+func (s *Service) cooldown(email string) (time.Duration, error) {
+	otp, err := s.store.OTP(email)
+	if errors.Is(err, ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if otp.Used || otp.SentAt.IsZero() {
+		return 0, nil
+	}
+	return max(0, otp.SentAt.Add(ResendCooldown).Sub(s.now())), nil
+}
+
+// sendCode fills in a fresh code for otp, saves it and mails the code to
+// otp.Email.
+func (s *Service) sendCode(otp *OTP) error {
+	email := otp.Email
+	wait, err := s.cooldown(email)
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		return &RetryError{Err: ErrCooldown, After: wait}
+	}
+	code, err := randomDigits(6)
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	otp.CodeHash = string(hash)
+	otp.ExpiresAt = now.Add(OTPTTL)
+	otp.SentAt = now
+	otp.Attempts, otp.Used = 0, false
+	if err := s.store.SaveOTP(otp); err != nil {
+		return err
+	}
+	subject, intro := codeMessage(otp.Purpose)
+	body := fmt.Sprintf("%s\n\n    %s\n\nIt expires in %d minutes. If you did not request it, you can ignore this email.\n", intro, code, int(OTPTTL.Minutes()))
+	return s.mailer.Send(email, subject, body)
+}
+
+func codeMessage(purpose string) (subject, intro string) {
+	switch purpose {
+	case PurposePasswordReset:
+		return "Reset your BoilerSchedule password", "Use this code to choose a new BoilerSchedule password:"
+	case PurposeEmailChange:
+		return "Confirm your new BoilerSchedule email", "Use this code to move your BoilerSchedule account to this address:"
+	default:
+		return "Your BoilerSchedule verification code", "Your BoilerSchedule verification code is:"
+	}
+}
+
+// redeemCode checks a code for email and purpose and marks it used. userId,
+// when set, must match the account the code was issued to. Every failure
+// counts against both the address and the client IP.
+func (s *Service) redeemCode(email, purpose, userId, code string, client Client) (*OTP, error) {
+	now := s.now()
+	if wait := max(s.emailFailures.locked(email, now), s.ipFailures.locked(client.IP, now)); wait > 0 {
+		return nil, &RetryError{Err: ErrTooManyAttempts, After: wait}
+	}
+	fail := func() error {
+		s.emailFailures.fail(email, now)
+		s.ipFailures.fail(client.IP, now)
+		return ErrInvalidCode
+	}
+
+	otp, err := s.store.OTP(email)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if otp == nil || otp.CodeHash == "" {
+		// Spend the same time as a real check
+		_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(code))
+		return nil, fail()
+	}
+	otpPurpose := otp.Purpose
+	if otpPurpose == "" {
+		otpPurpose = PurposeSignup
+	}
+	usable := !otp.Used && !now.After(otp.ExpiresAt) && otp.Attempts < MaxCodeAttempts &&
+		otpPurpose == purpose && (userId == "" || otp.UserId == userId)
+	// bcrypt compares in constant time; run it even for unusable codes so
+	// their state does not show in response times
+	match := bcrypt.CompareHashAndPassword([]byte(otp.CodeHash), []byte(strings.TrimSpace(code))) == nil
+	if !usable {
+		return nil, fail()
+	}
+	if !match {
+		otp.Attempts++
+		if err := s.store.SaveOTP(otp); err != nil {
+			return nil, err
+		}
+		return nil, fail()
+	}
+
+	otp.Used = true
+	if err := s.store.SaveOTP(otp); err != nil {
+		return nil, err
+	}
+	s.emailFailures.reset(email)
+	return otp, nil
+}
+
+// RequestPasswordReset mails a reset code to a verified account. It
+// returns nil for unknown addresses so callers cannot probe for accounts.
+func (s *Service) RequestPasswordReset(email string) error {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	u, err := s.store.UserByEmail(email)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !u.IsVerified {
+		return nil
+	}
+	return s.sendCode(&OTP{Email: email, Purpose: PurposePasswordReset, UserId: u.Id})
+}
+
+// ResetPassword sets a new password using a reset code and signs the
+// account out everywhere.
+func (s *Service) ResetPassword(email, code, password string, client Client) error {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	if len(password) < MinPasswordLength {
+		return ErrWeakPassword
+	}
+	otp, err := s.redeemCode(email, PurposePasswordReset, "", code, client)
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u, err := s.store.UpdateUser(email, func(u *User) error {
+		if u.Id != otp.UserId {
+			return ErrInvalidCode
+		}
+		u.PasswordHash = string(hash)
+		return nil
+	})
+	if errors.Is(err, ErrNotFound) {
+		return ErrInvalidCode
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.RevokeSessions(u, "")
+	return err
+}
+
+// RequestEmailChange confirms the user's password and mails a code to the
+// new address.
+func (s *Service) RequestEmailChange(u *User, newEmail, password string) error {
+	newEmail, err := NormalizeEmail(newEmail)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+		return ErrInvalidCredentials
+	}
+	if newEmail == u.Email {
+		return ErrInvalidEmail
+	}
+	if _, err := s.store.UserByEmail(newEmail); err == nil {
+		return ErrEmailTaken
+	} else if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return s.sendCode(&OTP{Email: newEmail, Purpose: PurposeEmailChange, UserId: u.Id})
+}
+
+// ConfirmEmailChange moves the account to newEmail once the code mailed
+// there is confirmed.
+func (s *Service) ConfirmEmailChange(u *User, newEmail, code string, client Client) (*User, error) {
+	newEmail, err := NormalizeEmail(newEmail)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.redeemCode(newEmail, PurposeEmailChange, u.Id, code, client); err != nil {
+		return nil, err
+	}
+	updated, err := s.store.UpdateUser(u.Email, func(u *User) error {
+		u.Email = newEmail
+		return nil
+	})
+	if errors.Is(err, ErrExists) {
+		return nil, ErrEmailTaken
+	}
+	return updated, err
+}
+
+// limiter counts failures per key within a window and locks the key once
+// the count reaches max.
+type limiter struct {
+	max     int
+	window  time.Duration
+	lockout time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*limitEntry
+}
+
+type limitEntry struct {
+	failures    int
+	since       time.Time
+	lockedUntil time.Time
+}
+
+func newLimiter(max int, window, lockout time.Duration) *limiter {
+	return &limiter{max: max, window: window, lockout: lockout, entries: make(map[string]*limitEntry)}
+}
+
+// locked returns how long key remains locked out, or 0.
+func (l *limiter) locked(key string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := l.entries[key]
+	if e == nil || !now.Before(e.lockedUntil) {
+		return 0
+	}
+	// Round up so a Retry-After header never says 0
+	return time.Duration(math.Ceil(e.lockedUntil.Sub(now).Seconds())) * time.Second
+}
+
+func (l *limiter) fail(key string, now time.Time) {
+	if key == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := l.entries[key]
+	if e == nil || now.Sub(e.since) > l.window {
+		e = &limitEntry{since: now}
+		l.entries[key] = e
+	}
+	e.failures++
+	if e.failures >= l.max {
+		e.lockedUntil = now.Add(l.lockout)
+		e.failures = 0
+		e.since = now
+	}
+}
+
+func (l *limiter) reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, key)
+}
+
+// prune drops entries whose window and lockout have both passed.
+func (l *limiter) prune(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for k, e := range l.entries {
+		if now.Sub(e.since) > l.window && !now.Before(e.lockedUntil) {
+			delete(l.entries, k)
+		}
+	}
+}
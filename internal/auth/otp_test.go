@@ -0,0 +1,158 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"purdue_schedule/internal/auth"
+)
+
+func TestCodeAttemptLimit(t *testing.T) {
+	e := newEnv(t)
+	const email = "pete@purdue.edu"
+	if _, err := e.svc.Signup(email, "Pete", "boilerup1"); err != nil {
+		t.Fatalf("Signup: %v", err)
+	}
+	code := e.mail.code(t, email)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	for i := 0; i < auth.MaxCodeAttempts; i++ {
+		if _, _, err := e.svc.Verify(email, wrong, false, client); !errors.Is(err, auth.ErrInvalidCode) {
+			t.Fatalf("wrong code %d: got %v, want ErrInvalidCode", i+1, err)
+		}
+	}
+	if _, _, err := e.svc.Verify(email, code, false, client); !errors.Is(err, auth.ErrInvalidCode) {
+		t.Fatalf("right code after %d misses: got %v, want ErrInvalidCode", auth.MaxCodeAttempts, err)
+	}
+
+	// A fresh code works again
+	e.clock.Advance(auth.ResendCooldown)
+	if err := e.svc.SendCode(email, auth.PurposeSignup); err != nil {
+		t.Fatalf("SendCode: %v", err)
+	}
+	if _, _, err := e.svc.Verify(email, e.mail.code(t, email), false, client); err != nil {
+		t.Fatalf("Verify with a fresh code: %v", err)
+	}
+}
+
+func TestCodeExpiry(t *testing.T) {
+	e := newEnv(t)
+	const email = "pete@purdue.edu"
+	if _, err := e.svc.Signup(email, "Pete", "boilerup1"); err != nil {
+		t.Fatalf("Signup: %v", err)
+	}
+	e.clock.Advance(auth.OTPTTL + time.Second)
+	if _, _, err := e.svc.Verify(email, e.mail.code(t, email), false, client); !errors.Is(err, auth.ErrInvalidCode) {
+		t.Fatalf("expired code: got %v, want ErrInvalidCode", err)
+	}
+}
+
+func TestResendCooldown(t *testing.T) {
+	e := newEnv(t)
+	const email = "pete@purdue.edu"
+	if _, err := e.svc.Signup(email, "Pete", "boilerup1"); err != nil {
+		t.Fatalf("Signup: %v", err)
+	}
+	e.clock.Advance(20 * time.Second)
+	err := e.svc.SendCode(email, auth.PurposeSignup)
+	var retry *auth.RetryError
+	if !errors.Is(err, auth.ErrCooldown) || !errors.As(err, &retry) {
+		t.Fatalf("resend within the cooldown: got %v, want ErrCooldown", err)
+	}
+	if want := auth.ResendCooldown - 20*time.Second; retry.After != want {
+		t.Errorf("retry after: got %v, want %v", retry.After, want)
+	}
+	if n := e.mail.count(); n != 1 {
+		t.Fatalf("mails sent: got %d, want 1", n)
+	}
+
+	e.clock.Advance(retry.After)
+	if err := e.svc.SendCode(email, auth.PurposeSignup); err != nil {
+		t.Fatalf("resend after the cooldown: %v", err)
+	}
+	// The resent code carries the signup's password
+	if _, _, err := e.svc.Verify(email, e.mail.code(t, email), false, client); err != nil {
+		t.Fatalf("Verify with the resent code: %v", err)
+	}
+	if _, _, err := e.svc.Login(email, "boilerup1", false, client); err != nil {
+		t.Errorf("Login: %v", err)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	e := newEnv(t)
+	const email = "pete@purdue.edu"
+	_, sess := e.signedUp(t, email, "boilerup1")
+
+	sent := e.mail.count()
+	if err := e.svc.RequestPasswordReset("nobody@purdue.edu"); err != nil {
+		t.Fatalf("reset for an unknown address: got %v, want nil", err)
+	}
+	if e.mail.count() != sent {
+		t.Fatal("reset for an unknown address sent mail")
+	}
+
+	e.clock.Advance(auth.ResendCooldown)
+	if err := e.svc.RequestPasswordReset(email); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	code := e.mail.code(t, email)
+	if _, _, err := e.svc.Verify(email, code, false, client); !errors.Is(err, auth.ErrInvalidCode) {
+		t.Errorf("reset code used to verify: got %v, want ErrInvalidCode", err)
+	}
+	if err := e.svc.ResetPassword(email, code, "short", client); !errors.Is(err, auth.ErrWeakPassword) {
+		t.Errorf("weak new password: got %v, want ErrWeakPassword", err)
+	}
+	if err := e.svc.ResetPassword(email, code, "boilerup2", client); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+
+	if _, _, err := e.svc.Authenticate(sess.Token); !errors.Is(err, auth.ErrNoSession) {
+		t.Errorf("session from before the reset: got %v, want ErrNoSession", err)
+	}
+	if _, _, err := e.svc.Login(email, "boilerup1", false, client); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("Login with the old password: got %v, want ErrInvalidCredentials", err)
+	}
+	if _, _, err := e.svc.Login(email, "boilerup2", false, client); err != nil {
+		t.Errorf("Login with the new password: %v", err)
+	}
+	if err := e.svc.ResetPassword(email, code, "boilerup3", client); !errors.Is(err, auth.ErrInvalidCode) {
+		t.Errorf("reusing the reset code: got %v, want ErrInvalidCode", err)
+	}
+}
+
+func TestEmailChange(t *testing.T) {
+	e := newEnv(t)
+	u, _ := e.signedUp(t, "pete@purdue.edu", "boilerup1")
+	other, _ := e.signedUp(t, "purdue.pete@purdue.edu", "boilerup1")
+
+	if err := e.svc.RequestEmailChange(u, "pete@gmail.com", "wrongpass"); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("wrong password: got %v, want ErrInvalidCredentials", err)
+	}
+	if err := e.svc.RequestEmailChange(u, other.Email, "boilerup1"); !errors.Is(err, auth.ErrEmailTaken) {
+		t.Errorf("taken address: got %v, want ErrEmailTaken", err)
+	}
+	if err := e.svc.RequestEmailChange(u, "pete@gmail.com", "boilerup1"); err != nil {
+		t.Fatalf("RequestEmailChange: %v", err)
+	}
+	code := e.mail.code(t, "pete@gmail.com")
+	if _, err := e.svc.ConfirmEmailChange(other, "pete@gmail.com", code, client); !errors.Is(err, auth.ErrInvalidCode) {
+		t.Errorf("code redeemed by another account: got %v, want ErrInvalidCode", err)
+	}
+	moved, err := e.svc.ConfirmEmailChange(u, "pete@gmail.com", code, client)
+	if err != nil {
+		t.Fatalf("ConfirmEmailChange: %v", err)
+	}
+	if moved.Email != "pete@gmail.com" || moved.Id != u.Id {
+		t.Errorf("moved account: got %s (%s), want pete@gmail.com (%s)", moved.Email, moved.Id, u.Id)
+	}
+	if _, _, err := e.svc.Login("pete@purdue.edu", "boilerup1", false, client); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("Login at the old address: got %v, want ErrInvalidCredentials", err)
+	}
+	if _, _, err := e.svc.Login("pete@gmail.com", "boilerup1", false, client); err != nil {
+		t.Errorf("Login at the new address: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+)
+
+// Plan limits
+const (
+	MaxPlanTerms     = 16 // four years of fall, spring, summer and a spare
+	MaxPlanCourses   = 12 // per term
+	MaxPlanSections  = 8  // per course: a lecture, its lab and recitation, and spares
+	MaxPlanCompleted = 100
+)
+
+var (
+	ErrPlanNotFound = errors.New("no plan of study saved")
+	ErrPlanTerm     = errors.New("terms need a unique, non-empty name")
+	ErrPlanSize     = errors.New("plan has too many terms, courses or sections")
+	ErrPlanCredits  = errors.New("credits must be between 0 and 40 per term and 300 in total")
+	ErrPlanCourse   = errors.New(`courses must be named like "CS 18000"`)
+)
+
+// Plan returns the user's plan of study, or ErrPlanNotFound.
+func (s *Service) Plan(u *User) (*Plan, error) {
+	if u.Plan == nil {
+		return nil, ErrPlanNotFound
+	}
+	return u.Plan, nil
+}
+
+// SavePlan validates the shape of p and replaces the user's plan with it.
+// Course names are expected to be normalized already; whether the plan
+// makes sense is checked elsewhere.
+func (s *Service) SavePlan(u *User, p Plan) (*Plan, error) {
+	if len(p.Terms) > MaxPlanTerms || len(p.Completed) > MaxPlanCompleted {
+		return nil, ErrPlanSize
+	}
+	if p.CompletedCredits < 0 || p.CompletedCredits > 300 || p.TargetCredits < 0 || p.TargetCredits > 300 {
+		return nil, ErrPlanCredits
+	}
+	seen := make(map[string]bool)
+	for i := range p.Terms {
+		t := &p.Terms[i]
+		t.Name = strings.Join(strings.Fields(t.Name), " ")
+		key := strings.ToLower(t.Name)
+		if t.Name == "" || seen[key] {
+			return nil, ErrPlanTerm
+		}
+		seen[key] = true
+		if len(t.Courses) > MaxPlanCourses {
+			return nil, ErrPlanSize
+		}
+		if t.MinCredits < 0 || t.MaxCredits < 0 || t.MinCredits > 40 || t.MaxCredits > 40 ||
+			(t.MaxCredits > 0 && t.MinCredits > t.MaxCredits) {
+			return nil, ErrPlanCredits
+		}
+		if t.Courses == nil {
+			t.Courses = []PlannedCourse{}
+		}
+		for _, c := range t.Courses {
+			if c.Course == "" {
+				return nil, ErrPlanCourse
+			}
+			if c.Credits < 0 || c.Credits > 40 {
+				return nil, ErrPlanCredits
+			}
+			if len(c.Sections) > MaxPlanSections {
+				return nil, ErrPlanSize
+			}
+		}
+	}
+	if p.Terms == nil {
+		p.Terms = []PlanTerm{}
+	}
+	p.UpdatedAt = s.now()
+	_, err := s.store.UpdateUser(u.Email, func(u *User) error {
+		u.Plan = &p
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// DeletePlan removes the user's plan of study.
+func (s *Service) DeletePlan(u *User) error {
+	_, err := s.store.UpdateUser(u.Email, func(u *User) error {
+		if u.Plan == nil {
+			return ErrPlanNotFound
+		}
+		u.Plan = nil
+		return nil
+	})
+	return err
+}
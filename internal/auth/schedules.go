@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+)
+
+// Limits on what one account may save; every schedule is rewritten with the
+// account record.
+const (
+	MaxSchedules           = 50
+	MaxScheduleSections    = 40
+	MaxScheduleNameLength  = 100  // bytes, for the name and the term
+	MaxScheduleNotesLength = 4000 // bytes
+)
+
+var (
+	ErrScheduleNotFound = errors.New("schedule not found")
+	ErrScheduleName     = errors.New("schedule name is required")
+	ErrTooManySchedules = errors.New("too many saved schedules")
+	ErrScheduleSize     = errors.New("schedule name, term or notes too long, or too many sections")
+	ErrVisibility       = errors.New(`visibility must be "private" or "friends"`)
+)
+
+// Schedule visibilities
+const (
+	VisibilityPrivate = "private"
+	VisibilityFriends = "friends"
+)
+
+// ScheduleChanges describes an edit to a saved schedule. Nil fields are left
+// unchanged.
+type ScheduleChanges struct {
+	Name       *string
+	Term       *string
+	Notes      *string
+	Primary    *bool
+	Visibility *string
+	Sections   []SavedSection
+}
+
+// Shared reports whether friends may see the schedule.
+func Shared(sc SavedSchedule) bool {
+	return sc.Visibility == VisibilityFriends
+}
+
+// Schedules returns the user's saved schedules.
+func (s *Service) Schedules(u *User) []SavedSchedule {
+	if u.Schedules == nil {
+		return []SavedSchedule{}
+	}
+	return u.Schedules
+}
+
+// CreateSchedule saves a new schedule. The first schedule becomes primary.
+// An empty visibility keeps the schedule private.
+func (s *Service) CreateSchedule(u *User, name, term, notes, visibility string, primary bool, sections []SavedSection) (*SavedSchedule, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrScheduleName
+	}
+	if visibility != "" && visibility != VisibilityPrivate && visibility != VisibilityFriends {
+		return nil, ErrVisibility
+	}
+	if err := checkScheduleSize(&name, &term, &notes, sections); err != nil {
+		return nil, err
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	var created SavedSchedule
+	_, err = s.store.UpdateUser(u.Email, func(u *User) error {
+		if len(u.Schedules) >= MaxSchedules {
+			return ErrTooManySchedules
+		}
+		now := s.now()
+		created = SavedSchedule{
+			Id:         id,
+			Name:       name,
+			Term:       strings.TrimSpace(term),
+			Notes:      notes,
+			Visibility: visibility,
+			Primary:    primary || len(u.Schedules) == 0,
+			Sections:   nonNilSections(sections),
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		u.Schedules = append(u.Schedules, created)
+		if created.Primary {
+			setPrimary(u, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateSchedule renames, re-terms, annotates, replaces sections of or
+// promotes a saved schedule.
+func (s *Service) UpdateSchedule(u *User, id string, c ScheduleChanges) (*SavedSchedule, error) {
+	if c.Name != nil && strings.TrimSpace(*c.Name) == "" {
+		return nil, ErrScheduleName
+	}
+	if c.Visibility != nil && *c.Visibility != VisibilityPrivate && *c.Visibility != VisibilityFriends {
+		return nil, ErrVisibility
+	}
+	if err := checkScheduleSize(c.Name, c.Term, c.Notes, c.Sections); err != nil {
+		return nil, err
+	}
+	var updated SavedSchedule
+	_, err := s.store.UpdateUser(u.Email, func(u *User) error {
+		i := scheduleIndex(u, id)
+		if i < 0 {
+			return ErrScheduleNotFound
+		}
+		sc := &u.Schedules[i]
+		if c.Name != nil {
+			sc.Name = strings.TrimSpace(*c.Name)
+		}
+		if c.Term != nil {
+			sc.Term = strings.TrimSpace(*c.Term)
+		}
+		if c.Notes != nil {
+			sc.Notes = *c.Notes
+		}
+		if c.Sections != nil {
+			sc.Sections = c.Sections
+		}
+		if c.Visibility != nil {
+			sc.Visibility = *c.Visibility
+		}
+		sc.UpdatedAt = s.now()
+		if c.Primary != nil && *c.Primary {
+			setPrimary(u, id)
+		}
+		updated = u.Schedules[i]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DuplicateSchedule copies a schedule under a new name. The copy is never
+// primary.
+func (s *Service) DuplicateSchedule(u *User, id, name string) (*SavedSchedule, error) {
+	if err := checkScheduleSize(&name, nil, nil, nil); err != nil {
+		return nil, err
+	}
+	newId, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	var dup SavedSchedule
+	_, err = s.store.UpdateUser(u.Email, func(u *User) error {
+		i := scheduleIndex(u, id)
+		if i < 0 {
+			return ErrScheduleNotFound
+		}
+		if len(u.Schedules) >= MaxSchedules {
+			return ErrTooManySchedules
+		}
+		src := u.Schedules[i]
+		now := s.now()
+		dup = src
+		dup.Id = newId
+		dup.Name = strings.TrimSpace(name)
+		if dup.Name == "" {
+			dup.Name = src.Name + " (copy)"
+		}
+		dup.Primary = false
+		dup.Visibility = ""
+		dup.Sections = append([]SavedSection{}, src.Sections...)
+		dup.CreatedAt = now
+		dup.UpdatedAt = now
+		u.Schedules = append(u.Schedules, dup)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dup, nil
+}
+
+// DeleteSchedule removes a schedule. Deleting the primary schedule promotes
+// the oldest remaining one.
+func (s *Service) DeleteSchedule(u *User, id string) error {
+	_, err := s.store.UpdateUser(u.Email, func(u *User) error {
+		i := scheduleIndex(u, id)
+		if i < 0 {
+			return ErrScheduleNotFound
+		}
+		wasPrimary := u.Schedules[i].Primary
+		u.Schedules = append(u.Schedules[:i], u.Schedules[i+1:]...)
+		if wasPrimary && len(u.Schedules) > 0 {
+			setPrimary(u, u.Schedules[0].Id)
+		}
+		return nil
+	})
+	return err
+}
+
+// checkScheduleSize enforces the MaxSchedule* limits on whichever fields are
+// given.
+func checkScheduleSize(name, term, notes *string, sections []SavedSection) error {
+	for _, s := range []*string{name, term} {
+		if s != nil && len(strings.TrimSpace(*s)) > MaxScheduleNameLength {
+			return ErrScheduleSize
+		}
+	}
+	if notes != nil && len(*notes) > MaxScheduleNotesLength || len(sections) > MaxScheduleSections {
+		return ErrScheduleSize
+	}
+	return nil
+}
+
+func scheduleIndex(u *User, id string) int {
+	for i := range u.Schedules {
+		if u.Schedules[i].Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// setPrimary makes id the only primary schedule.
+func setPrimary(u *User, id string) {
+	for i := range u.Schedules {
+		u.Schedules[i].Primary = u.Schedules[i].Id == id
+	}
+}
+
+func nonNilSections(sections []SavedSection) []SavedSection {
+	if sections == nil {
+		return []SavedSection{}
+	}
+	return sections
+}
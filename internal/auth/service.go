@@ -0,0 +1,286 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"purdue_schedule/internal/storage"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Lifetimes match the records already written under data/
+const (
+	SessionTTL         = 24 * time.Hour
+	RememberSessionTTL = 10 * 24 * time.Hour
+	OTPTTL             = 10 * time.Minute
+	MinPasswordLength  = 8
+)
+
+var (
+	ErrNotFound           = storage.ErrNotFound
+	ErrExists             = storage.ErrExists
+	ErrInvalidEmail       = errors.New("invalid email address")
+	ErrWeakPassword       = fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	ErrEmailTaken         = errors.New("an account with this email already exists")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrNotVerified        = errors.New("email address has not been verified")
+	ErrDisabled           = errors.New("this account has been disabled")
+	ErrInvalidCode        = errors.New("invalid or expired verification code")
+	ErrNoSession          = errors.New("not signed in")
+)
+
+// Service implements account flows on top of a storage backend.
+type Service struct {
+	store  storage.Store
+	mailer Mailer
+	now    func() time.Time
+
+	emailFailures *limiter
+	ipFailures    *limiter
+
+	webhookHostAllowed func(host string) bool
+}
+
+// NewService wires a store and mailer. A nil mailer logs messages instead.
+func NewService(store storage.Store, mailer Mailer) *Service {
+	if mailer == nil {
+		mailer = LogMailer{}
+	}
+	return &Service{
+		store:         store,
+		mailer:        mailer,
+		now:           time.Now,
+		emailFailures: newLimiter(EmailFailureLimit, FailureWindow, LockoutDuration),
+		ipFailures:    newLimiter(IPFailureLimit, FailureWindow, LockoutDuration),
+	}
+}
+
+// NormalizeEmail lowercases and validates an email address.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || strings.ContainsAny(email, `/\`) {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
+
+// Signup creates an unverified account and emails a verification code.
+// Signing up again before verifying sends a fresh code carrying the new
+// name and password; the account takes them only when that code is
+// redeemed, so whoever signs up with someone else's address never learns
+// the password the account ends up with.
+func (s *Service) Signup(email, name, password string) (*User, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if len(password) < MinPasswordLength {
+		return nil, ErrWeakPassword
+	}
+	existing, err := s.store.UserByEmail(email)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if existing != nil && existing.IsVerified {
+		return nil, ErrEmailTaken
+	}
+	// Refuse before hashing anything if no code can be sent yet
+	if wait, err := s.cooldown(email); err != nil {
+		return nil, err
+	} else if wait > 0 {
+		return nil, &RetryError{Err: ErrCooldown, After: wait}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	u := existing
+	if u == nil {
+		id, err := randomHex(16)
+		if err != nil {
+			return nil, err
+		}
+		u = &User{Id: id, Email: email, Name: name, PasswordHash: string(hash), CreatedAt: s.now()}
+		if err := s.store.SaveUser(u); err != nil {
+			return nil, err
+		}
+	}
+	otp := &OTP{Email: email, Purpose: PurposeSignup, UserId: u.Id, Name: name, PasswordHash: string(hash)}
+	if err := s.sendCode(otp); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Verify checks a code, marks the account verified and signs the user in.
+func (s *Service) Verify(email, code string, rememberMe bool, client Client) (*User, *Session, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil, nil, err
+	}
+	otp, err := s.redeemCode(email, PurposeSignup, "", code, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	u, err := s.store.UpdateUser(email, func(u *User) error {
+		if u.Disabled {
+			return ErrDisabled
+		}
+		if otp.PasswordHash != "" {
+			u.Name, u.PasswordHash = otp.Name, otp.PasswordHash
+		}
+		u.IsVerified = true
+		return nil
+	})
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil, ErrInvalidCode
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	sess, err := s.newSession(u, rememberMe, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, sess, nil
+}
+
+// Login checks a password and starts a session.
+func (s *Service) Login(email, password string, rememberMe bool, client Client) (*User, *Session, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil, nil, ErrInvalidCredentials
+	}
+	u, err := s.store.UserByEmail(email)
+	if errors.Is(err, ErrNotFound) {
+		// Spend the same time as a real check so timing does not reveal accounts
+		_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return nil, nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+		return nil, nil, ErrInvalidCredentials
+	}
+	if u.Disabled {
+		return nil, nil, ErrDisabled
+	}
+	if !u.IsVerified {
+		return nil, nil, ErrNotVerified
+	}
+	sess, err := s.newSession(u, rememberMe, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, sess, nil
+}
+
+// Logout ends a session.
+func (s *Service) Logout(token string) error {
+	if token == "" {
+		return nil
+	}
+	return s.store.DeleteSession(token)
+}
+
+// Authenticate resolves a session token to its user. Expired sessions are
+// deleted on sight; live ones are touched, which slides the expiry of
+// remember-me sessions forward.
+func (s *Service) Authenticate(token string) (*User, *Session, error) {
+	u, sess, _, err := s.authenticate(token)
+	return u, sess, err
+}
+
+// authenticate is Authenticate that also reports whether the session's
+// expiry moved, so the caller can refresh the cookie.
+func (s *Service) authenticate(token string) (*User, *Session, bool, error) {
+	if token == "" {
+		return nil, nil, false, ErrNoSession
+	}
+	sess, err := s.store.Session(token)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil, false, ErrNoSession
+	}
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if s.now().After(sess.ExpiresAt) {
+		_ = s.store.DeleteSession(token)
+		return nil, nil, false, ErrNoSession
+	}
+	u, err := s.store.UserById(sess.UserId)
+	if errors.Is(err, ErrNotFound) || (err == nil && u.Disabled) {
+		return nil, nil, false, ErrNoSession
+	}
+	if err != nil {
+		return nil, nil, false, err
+	}
+	renewed, err := s.touch(sess)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return u, sess, renewed, nil
+}
+
+func (s *Service) newSession(u *User, rememberMe bool, client Client) (*Session, error) {
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	ttl := SessionTTL
+	if rememberMe {
+		ttl = RememberSessionTTL
+	}
+	now := s.now()
+	sess := &Session{
+		Token:      token,
+		UserId:     u.Id,
+		ExpiresAt:  now.Add(ttl),
+		RememberMe: rememberMe,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+	}
+	if err := s.store.SaveSession(sess); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+var dummyHash = sync.OnceValue(func() []byte {
+	h, _ := bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+	return h
+})
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func randomDigits(n int) (string, error) {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(byte('0' + d.Int64()))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,248 @@
+package auth_test
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"purdue_schedule/internal/auth"
+	"purdue_schedule/internal/storage"
+)
+
+// clock is a fake time source the tests move by hand.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// outbox records mail instead of sending it.
+type outbox struct {
+	mu   sync.Mutex
+	sent []mail
+}
+
+type mail struct{ to, subject, body string }
+
+func (o *outbox) Send(to, subject, body string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.sent = append(o.sent, mail{to, subject, body})
+	return nil
+}
+
+func (o *outbox) count() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.sent)
+}
+
+var mailedCode = regexp.MustCompile(`(?m)^\s*(\d{6})\s*$`)
+
+// code returns the last code mailed to address.
+func (o *outbox) code(t *testing.T, to string) string {
+	t.Helper()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := len(o.sent) - 1; i >= 0; i-- {
+		if o.sent[i].to != to {
+			continue
+		}
+		if m := mailedCode.FindStringSubmatch(o.sent[i].body); m != nil {
+			return m[1]
+		}
+	}
+	t.Fatalf("no code mailed to %s", to)
+	return ""
+}
+
+type env struct {
+	svc   *auth.Service
+	mail  *outbox
+	clock *clock
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	e := &env{mail: &outbox{}, clock: &clock{now: time.Date(2025, 8, 25, 9, 0, 0, 0, time.UTC)}}
+	e.svc = auth.NewService(store, e.mail)
+	e.svc.SetClock(e.clock.Now)
+	return e
+}
+
+var client = auth.Client{UserAgent: "test", IP: "192.0.2.1"}
+
+// signedUp creates a verified account and returns it with its session.
+func (e *env) signedUp(t *testing.T, email, password string) (*auth.User, *auth.Session) {
+	t.Helper()
+	if _, err := e.svc.Signup(email, "Pete", password); err != nil {
+		t.Fatalf("Signup: %v", err)
+	}
+	u, sess, err := e.svc.Verify(email, e.mail.code(t, email), false, client)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	return u, sess
+}
+
+// smtpStandIn accepts mail on a local port and hands each message's data
+// to the returned channel.
+func smtpStandIn(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	msgs := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, msgs)
+		}
+	}()
+	return ln.Addr().String(), msgs
+}
+
+func serveSMTP(conn net.Conn, msgs chan<- string) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 localhost ready")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		switch verb, _, _ := strings.Cut(strings.ToUpper(line), " "); verb {
+		case "EHLO", "HELO", "MAIL", "RCPT", "RSET", "NOOP":
+			_ = tp.PrintfLine("250 ok")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msgs <- string(data)
+			_ = tp.PrintfLine("250 queued")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func TestSignupVerifyOverSMTP(t *testing.T) {
+	addr, msgs := smtpStandIn(t)
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	svc := auth.NewService(store, &auth.SMTPMailer{Addr: addr, From: "BoilerSchedule <no-reply@localhost>"})
+
+	if _, err := svc.Signup(" Pete@Purdue.edu ", "Pete", "boilerup1"); err != nil {
+		t.Fatalf("Signup: %v", err)
+	}
+	var msg string
+	select {
+	case msg = <-msgs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail reached the SMTP stand-in")
+	}
+	r := textproto.NewReader(bufio.NewReader(strings.NewReader(msg)))
+	header, err := r.ReadMIMEHeader()
+	if err != nil {
+		t.Fatalf("reading mailed headers: %v", err)
+	}
+	if got := header.Get("To"); got != "pete@purdue.edu" {
+		t.Errorf("To: got %q, want the normalized address", got)
+	}
+	m := mailedCode.FindStringSubmatch(strings.ReplaceAll(msg, "\r\n", "\n"))
+	if m == nil {
+		t.Fatalf("no code in mailed message:\n%s", msg)
+	}
+
+	if _, _, err := svc.Login("pete@purdue.edu", "boilerup1", false, client); !errors.Is(err, auth.ErrNotVerified) {
+		t.Fatalf("Login before verifying: got %v, want ErrNotVerified", err)
+	}
+	u, sess, err := svc.Verify("pete@purdue.edu", m[1], true, client)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !u.IsVerified || !sess.RememberMe {
+		t.Errorf("Verify: got verified=%v rememberMe=%v, want both", u.IsVerified, sess.RememberMe)
+	}
+	if got, _, err := svc.Authenticate(sess.Token); err != nil || got.Id != u.Id {
+		t.Errorf("Authenticate: got %v, %v; want the verified user", got, err)
+	}
+	if _, _, err := svc.Verify("pete@purdue.edu", m[1], false, client); !errors.Is(err, auth.ErrInvalidCode) {
+		t.Errorf("Verify with a used code: got %v, want ErrInvalidCode", err)
+	}
+	if _, err := svc.Signup("pete@purdue.edu", "Pete", "boilerup2"); !errors.Is(err, auth.ErrEmailTaken) {
+		t.Errorf("Signup of a verified address: got %v, want ErrEmailTaken", err)
+	}
+}
+
+func TestSignupAgainAppliesPasswordOnRedeem(t *testing.T) {
+	e := newEnv(t)
+	const email = "pete@purdue.edu"
+	if _, err := e.svc.Signup(email, "Pete", "firstpass"); err != nil {
+		t.Fatalf("Signup: %v", err)
+	}
+	first := e.mail.code(t, email)
+	e.clock.Advance(auth.ResendCooldown)
+	if _, err := e.svc.Signup(email, "Someone Else", "secondpass"); err != nil {
+		t.Fatalf("second Signup: %v", err)
+	}
+	second := e.mail.code(t, email)
+
+	// Until a code is redeemed the account keeps its first password
+	if _, _, err := e.svc.Login(email, "secondpass", false, client); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("Login with the unredeemed password: got %v, want ErrInvalidCredentials", err)
+	}
+	if first != second {
+		if _, _, err := e.svc.Verify(email, first, false, client); !errors.Is(err, auth.ErrInvalidCode) {
+			t.Fatalf("Verify with the replaced code: got %v, want ErrInvalidCode", err)
+		}
+	}
+	u, _, err := e.svc.Verify(email, second, false, client)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if u.Name != "Someone Else" {
+		t.Errorf("name: got %q, want the second signup's", u.Name)
+	}
+	if _, _, err := e.svc.Login(email, "firstpass", false, client); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("Login with the first password: got %v, want ErrInvalidCredentials", err)
+	}
+	if _, _, err := e.svc.Login(email, "secondpass", false, client); err != nil {
+		t.Errorf("Login with the redeemed password: %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sort"
+	"time"
+)
+
+// touchInterval limits how often a session's last use is written back, so
+// a page load that fires a dozen API calls costs one write, not twelve.
+const touchInterval = time.Minute
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Client describes the device signing in.
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// SessionId is the public handle for a session. The token itself is never
+// shown back, so a leaked device list cannot be replayed.
+func SessionId(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
+// touch records a use of sess. Remember-me sessions slide: each use pushes
+// the expiry a full RememberSessionTTL out. It reports whether the expiry
+// moved.
+func (s *Service) touch(sess *Session) (bool, error) {
+	now := s.now()
+	if now.Sub(sess.LastUsedAt) < touchInterval {
+		return false, nil
+	}
+	updated, err := s.store.UpdateSession(sess.Token, func(stored *Session) error {
+		stored.LastUsedAt = now
+		if stored.RememberMe {
+			stored.ExpiresAt = now.Add(RememberSessionTTL)
+		}
+		return nil
+	})
+	if errors.Is(err, ErrNotFound) {
+		// Revoked between the lookup and now
+		return false, ErrNoSession
+	}
+	if err != nil {
+		return false, err
+	}
+	*sess = *updated
+	return sess.RememberMe, nil
+}
+
+// Sessions lists a user's live sessions, most recently used first.
+func (s *Service) Sessions(u *User) ([]*Session, error) {
+	all, err := s.store.SessionsByUser(u.Id)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	live := make([]*Session, 0, len(all))
+	for _, sess := range all {
+		if now.After(sess.ExpiresAt) {
+			continue
+		}
+		live = append(live, sess)
+	}
+	sort.Slice(live, func(i, j int) bool {
+		return lastUse(live[i]).After(lastUse(live[j]))
+	})
+	return live, nil
+}
+
+// lastUse falls back to the creation time for sessions written before last
+// use was tracked.
+func lastUse(sess *Session) time.Time {
+	if sess.LastUsedAt.IsZero() {
+		return sess.CreatedAt
+	}
+	return sess.LastUsedAt
+}
+
+// RevokeSession ends one of the user's sessions by its SessionId.
+func (s *Service) RevokeSession(u *User, id string) error {
+	all, err := s.store.SessionsByUser(u.Id)
+	if err != nil {
+		return err
+	}
+	for _, sess := range all {
+		if SessionId(sess.Token) == id {
+			return s.store.DeleteSession(sess.Token)
+		}
+	}
+	return ErrSessionNotFound
+}
+
+// RevokeSessions ends every session of the user except the one with token
+// keep, which may be empty to sign out everywhere. It returns how many
+// sessions were ended.
+func (s *Service) RevokeSessions(u *User, keep string) (int, error) {
+	all, err := s.store.SessionsByUser(u.Id)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, sess := range all {
+		if sess.Token == keep {
+			continue
+		}
+		if err := s.store.DeleteSession(sess.Token); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Sweep deletes expired sessions and codes that are expired or used, and
+// forgets attempt counters that have run out.
+func (s *Service) Sweep() (sessions, otps int, err error) {
+	now := s.now()
+	s.emailFailures.prune(now)
+	s.ipFailures.prune(now)
+	all, err := s.store.Sessions()
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, sess := range all {
+		if now.After(sess.ExpiresAt) {
+			if err := s.store.DeleteSession(sess.Token); err != nil {
+				return sessions, otps, err
+			}
+			sessions++
+		}
+	}
+	codes, err := s.store.OTPs()
+	if err != nil {
+		return sessions, otps, err
+	}
+	for _, o := range codes {
+		if o.Used || now.After(o.ExpiresAt) {
+			if err := s.store.DeleteOTP(o.Email); err != nil {
+				return sessions, otps, err
+			}
+			otps++
+		}
+	}
+	return sessions, otps, nil
+}
+
+// RunSweeper calls Sweep every interval until ctx is done.
+func (s *Service) RunSweeper(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		sessions, otps, err := s.Sweep()
+		if err != nil {
+			log.Printf("auth: sweep failed: %v", err)
+		} else if sessions > 0 || otps > 0 {
+			log.Printf("auth: swept %d expired sessions and %d codes", sessions, otps)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"purdue_schedule/internal/auth"
+)
+
+func TestRememberMeSlides(t *testing.T) {
+	e := newEnv(t)
+	const email = "pete@purdue.edu"
+	e.signedUp(t, email, "boilerup1")
+	_, remembered, err := e.svc.Login(email, "boilerup1", true, client)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	_, plain, err := e.svc.Login(email, "boilerup1", false, client)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	// Each use pushes a remember-me session a full lifetime out
+	step := auth.RememberSessionTTL - 24*time.Hour
+	for i := 0; i < 3; i++ {
+		e.clock.Advance(step)
+		_, sess, err := e.svc.Authenticate(remembered.Token)
+		if err != nil {
+			t.Fatalf("remember-me session after %v: %v", time.Duration(i+1)*step, err)
+		}
+		if want := e.clock.Now().Add(auth.RememberSessionTTL); !sess.ExpiresAt.Equal(want) {
+			t.Fatalf("expiry: got %v, want %v", sess.ExpiresAt, want)
+		}
+	}
+	if _, _, err := e.svc.Authenticate(plain.Token); !errors.Is(err, auth.ErrNoSession) {
+		t.Errorf("plain session after %v: got %v, want ErrNoSession", 3*step, err)
+	}
+
+	e.clock.Advance(auth.RememberSessionTTL + time.Second)
+	if _, _, err := e.svc.Authenticate(remembered.Token); !errors.Is(err, auth.ErrNoSession) {
+		t.Errorf("remember-me session left unused: got %v, want ErrNoSession", err)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	e := newEnv(t)
+	const email = "pete@purdue.edu"
+	u, _ := e.signedUp(t, email, "boilerup1")
+	_, remembered, err := e.svc.Login(email, "boilerup1", true, client)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	// The verify session has expired, its code was used
+	e.clock.Advance(auth.SessionTTL + time.Second)
+	if _, err := e.svc.Signup("new@purdue.edu", "New", "boilerup1"); err != nil {
+		t.Fatalf("Signup: %v", err)
+	}
+	sweep := func(what string, wantSessions, wantOTPs int) {
+		t.Helper()
+		sessions, otps, err := e.svc.Sweep()
+		if err != nil {
+			t.Fatalf("Sweep: %v", err)
+		}
+		if sessions != wantSessions || otps != wantOTPs {
+			t.Errorf("%s: got %d sessions and %d codes, want %d and %d", what, sessions, otps, wantSessions, wantOTPs)
+		}
+	}
+	sweep("first sweep", 1, 1)
+	live, err := e.svc.Sessions(u)
+	if err != nil {
+		t.Fatalf("Sessions: %v", err)
+	}
+	if len(live) != 1 || live[0].Token != remembered.Token {
+		t.Errorf("sessions left: got %d, want only the remember-me one", len(live))
+	}
+
+	e.clock.Advance(auth.OTPTTL + time.Second)
+	sweep("after the signup code expired", 0, 1)
+	if _, _, err := e.svc.Verify("new@purdue.edu", e.mail.code(t, "new@purdue.edu"), false, client); !errors.Is(err, auth.ErrInvalidCode) {
+		t.Errorf("Verify with a swept code: got %v, want ErrInvalidCode", err)
+	}
+
+	e.clock.Advance(auth.RememberSessionTTL)
+	sweep("after the remember-me session expired", 1, 0)
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxWatches caps how many sections one account may watch.
+const MaxWatches = 50
+
+// Notification channels
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// DefaultTimeZone applies to quiet hours when the user has not picked one.
+const DefaultTimeZone = "America/Indiana/Indianapolis"
+
+var (
+	ErrWatchNotFound  = errors.New("watch not found")
+	ErrTooManyWatches = errors.New("too many watched sections")
+	ErrChannel        = errors.New(`channels must be "email" or "webhook"`)
+	ErrWebhookURL     = errors.New("webhook channel needs an http or https URL")
+	ErrWebhookPrivate = errors.New("webhook URL must reach a public address")
+	ErrQuietHours     = errors.New("quiet hours must be HH:MM, both or neither")
+	ErrTimeZone       = errors.New("unknown time zone")
+)
+
+// Watches returns the sections the user is watching.
+func (s *Service) Watches(u *User) []Watch {
+	if u.Watches == nil {
+		return []Watch{}
+	}
+	return u.Watches
+}
+
+// AddWatch starts watching w.SectionId. The counts on w are the baseline
+// later changes are measured against. Watching a section twice returns the
+// existing watch.
+func (s *Service) AddWatch(u *User, w Watch) (*Watch, error) {
+	var added Watch
+	_, err := s.store.UpdateUser(u.Email, func(u *User) error {
+		if i := watchIndex(u, w.SectionId); i >= 0 {
+			added = u.Watches[i]
+			return nil
+		}
+		if len(u.Watches) >= MaxWatches {
+			return ErrTooManyWatches
+		}
+		w.CreatedAt = s.now()
+		w.LastEvent = ""
+		w.NotifiedAt = time.Time{}
+		u.Watches = append(u.Watches, w)
+		added = w
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &added, nil
+}
+
+// RemoveWatch stops watching a section.
+func (s *Service) RemoveWatch(u *User, sectionId string) error {
+	_, err := s.store.UpdateUser(u.Email, func(u *User) error {
+		i := watchIndex(u, sectionId)
+		if i < 0 {
+			return ErrWatchNotFound
+		}
+		u.Watches = slices.Delete(u.Watches, i, i+1)
+		return nil
+	})
+	return err
+}
+
+// UpdateWatch applies fn to one of the user's watches, e.g. to move its
+// baseline after a notification.
+func (s *Service) UpdateWatch(u *User, sectionId string, fn func(*Watch)) error {
+	return s.updateById(u, func(u *User) error {
+		i := watchIndex(u, sectionId)
+		if i < 0 {
+			return ErrWatchNotFound
+		}
+		fn(&u.Watches[i])
+		return nil
+	})
+}
+
+// SetNotifySettings validates and saves the user's notification settings.
+func (s *Service) SetNotifySettings(u *User, n NotifySettings) (*NotifySettings, error) {
+	seen := make(map[string]bool)
+	channels := make([]string, 0, len(n.Channels))
+	for _, c := range n.Channels {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c != ChannelEmail && c != ChannelWebhook {
+			return nil, ErrChannel
+		}
+		if !seen[c] {
+			seen[c] = true
+			channels = append(channels, c)
+		}
+	}
+	n.Channels = channels
+	n.WebhookURL = strings.TrimSpace(n.WebhookURL)
+	if seen[ChannelWebhook] || n.WebhookURL != "" {
+		p, err := url.Parse(n.WebhookURL)
+		if err != nil || (p.Scheme != "http" && p.Scheme != "https") || p.Host == "" {
+			return nil, ErrWebhookURL
+		}
+		if s.webhookHostAllowed != nil && !s.webhookHostAllowed(p.Hostname()) {
+			return nil, ErrWebhookPrivate
+		}
+	}
+	if (n.QuietStart == "") != (n.QuietEnd == "") {
+		return nil, ErrQuietHours
+	}
+	if n.QuietStart != "" {
+		if _, ok := clockMinutes(n.QuietStart); !ok {
+			return nil, ErrQuietHours
+		}
+		if _, ok := clockMinutes(n.QuietEnd); !ok {
+			return nil, ErrQuietHours
+		}
+	}
+	if n.TimeZone != "" {
+		if _, err := time.LoadLocation(n.TimeZone); err != nil {
+			return nil, ErrTimeZone
+		}
+	}
+	_, err := s.store.UpdateUser(u.Email, func(u *User) error {
+		u.Notify = n
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// CheckWebhookHosts sets the test a webhook URL's host must pass to be
+// saved, e.g. notify.PublicHost so a webhook cannot be used to reach the
+// server's own network. nil, the default, accepts any host.
+func (s *Service) CheckWebhookHosts(allowed func(host string) bool) {
+	s.webhookHostAllowed = allowed
+}
+
+// NotifyChannels returns the channels to use, email when none are set.
+func NotifyChannels(n NotifySettings) []string {
+	if len(n.Channels) == 0 {
+		return []string{ChannelEmail}
+	}
+	return n.Channels
+}
+
+// InQuietHours reports whether t falls in the user's quiet hours. A window
+// such as 22:00-07:00 wraps past midnight.
+func InQuietHours(n NotifySettings, t time.Time) bool {
+	start, ok1 := clockMinutes(n.QuietStart)
+	end, ok2 := clockMinutes(n.QuietEnd)
+	if !ok1 || !ok2 || start == end {
+		return false
+	}
+	tz := n.TimeZone
+	if tz == "" {
+		tz = DefaultTimeZone
+	}
+	if loc, err := time.LoadLocation(tz); err == nil {
+		t = t.In(loc)
+	}
+	now := t.Hour()*60 + t.Minute()
+	if start < end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+// clockMinutes parses "HH:MM" into minutes after midnight.
+func clockMinutes(s string) (int, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+func watchIndex(u *User, sectionId string) int {
+	for i := range u.Watches {
+		if u.Watches[i].SectionId == sectionId {
+			return i
+		}
+	}
+	return -1
+}
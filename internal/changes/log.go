@@ -0,0 +1,103 @@
+// Package changes keeps a feed of catalog changes. Each dataset reload that
+// changed something appends the diff against the previous load.
+package changes
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"purdue_schedule/internal/data"
+)
+
+// Entry is the diff recorded at one reload.
+type Entry struct {
+	Time time.Time `json:"time"`
+	data.Changeset
+}
+
+// Log is an append-only JSON lines file of entries, held in memory.
+type Log struct {
+	mu      sync.RWMutex
+	f       *os.File
+	entries []Entry
+}
+
+// Open reads the log at path, creating it if needed. A torn last line is
+// cut off, as in the seat history.
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	l := &Log{f: f}
+	dec := json.NewDecoder(bufio.NewReader(f))
+	var good int64
+	for {
+		var e Entry
+		err := dec.Decode(&e)
+		if errors.Is(err, io.EOF) {
+			return l, nil
+		}
+		if err != nil {
+			break
+		}
+		l.entries = append(l.entries, e)
+		good = dec.InputOffset()
+	}
+	if err := f.Truncate(good); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if good > 0 {
+		if _, err := f.WriteString("\n"); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// Append records d at time at. Empty diffs are skipped.
+func (l *Log) Append(d data.Changeset, at time.Time) error {
+	if d.Empty() {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := Entry{Time: at, Changeset: d}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := l.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	l.entries = append(l.entries, e)
+	return l.f.Sync()
+}
+
+// Since returns the entries recorded after t, oldest first.
+func (l *Log) Since(t time.Time) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := []Entry{}
+	for _, e := range l.entries {
+		if e.Time.After(t) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (l *Log) Close() error {
+	return l.f.Close()
+}
@@ -0,0 +1,117 @@
+package data
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Catalogs names the dataset loaded for each term, for features such as the
+// plan of study that look beyond the term being served.
+type Catalogs struct {
+	mu    sync.RWMutex
+	terms []string
+	byKey map[string]*Dataset
+}
+
+func NewCatalogs() *Catalogs {
+	return &Catalogs{byKey: make(map[string]*Dataset)}
+}
+
+// Add registers d as the catalog for term, replacing any earlier one.
+func (c *Catalogs) Add(term string, d *Dataset) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := TermKey(term)
+	if _, ok := c.byKey[key]; !ok {
+		c.terms = append(c.terms, strings.Join(strings.Fields(term), " "))
+	}
+	c.byKey[key] = d
+}
+
+// Rekey moves d's catalog to term, keeping its place in Terms, for a
+// dataset whose term changed on reload. An empty term drops the catalog.
+func (c *Catalogs) Rekey(d *Dataset, term string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	at := len(c.terms)
+	for i := 0; i < len(c.terms); i++ {
+		key := TermKey(c.terms[i])
+		if c.byKey[key] != d {
+			continue
+		}
+		delete(c.byKey, key)
+		c.terms = append(c.terms[:i], c.terms[i+1:]...)
+		at = min(at, i)
+		i--
+	}
+	if term == "" {
+		return
+	}
+	key := TermKey(term)
+	if _, ok := c.byKey[key]; !ok {
+		c.terms = slices.Insert(c.terms, at, strings.Join(strings.Fields(term), " "))
+	}
+	c.byKey[key] = d
+}
+
+// Store returns the store currently served for term, or nil when no catalog
+// was loaded for it.
+func (c *Catalogs) Store(term string) *Store {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	d, ok := c.byKey[TermKey(term)]
+	if !ok {
+		return nil
+	}
+	return d.Store()
+}
+
+// Terms lists the terms with a catalog, in the order they were added.
+func (c *Catalogs) Terms() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string{}, c.terms...)
+}
+
+// TermKey folds a term name for comparison: "fall  2025" matches "Fall 2025".
+func TermKey(term string) string {
+	return strings.ToLower(strings.Join(strings.Fields(term), " "))
+}
+
+var courseName = regexp.MustCompile(`^([A-Za-z]{2,5})\s*-?\s*(\d{3,5}[A-Za-z]?)$`)
+
+// NormalizeCourse formats a course name typed as "cs18000", "CS-18000" or
+// "cs 18000" as "CS 18000". It reports false for anything else.
+func NormalizeCourse(s string) (string, bool) {
+	m := courseName.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return "", false
+	}
+	return strings.ToUpper(m[1]) + " " + strings.ToUpper(m[2]), true
+}
+
+// FindCourse looks a course up by its normalized name, e.g. "CS 18000".
+// When subject names were never fetched, a course number that is unique in
+// the catalog matches on its own.
+func (s *Store) FindCourse(name string) (CourseSummary, bool) {
+	_, number, ok := strings.Cut(name, " ")
+	if !ok {
+		return CourseSummary{}, false
+	}
+	var byNumber []CourseSummary
+	for _, c := range s.courses {
+		label := CourseLabel(s, c)
+		if strings.EqualFold(label, name) {
+			return c, true
+		}
+		if label == c.Number && strings.EqualFold(c.Number, number) {
+			byNumber = append(byNumber, c)
+		}
+	}
+	if len(byNumber) == 1 {
+		return byNumber[0], true
+	}
+	return CourseSummary{}, false
+}
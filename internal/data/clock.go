@@ -0,0 +1,41 @@
+package data
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParseClock reads a clock time as minutes from midnight. It takes the
+// catalog's 24-hour "14:30" as well as "2:30 PM" and "2:30pm", which the
+// web client sends. MeetingInfo.StartMinutes and layout.ParseTime both
+// read times through it.
+func ParseClock(s string) (int, bool) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	pm := strings.HasSuffix(s, "PM")
+	twelveHour := pm || strings.HasSuffix(s, "AM")
+	if twelveHour {
+		s = strings.TrimSpace(s[:len(s)-2])
+	}
+	hh, mm, ok := strings.Cut(s, ":")
+	if !ok || len(mm) != 2 {
+		return 0, false
+	}
+	h, err1 := strconv.Atoi(hh)
+	m, err2 := strconv.Atoi(mm)
+	if err1 != nil || err2 != nil || h < 0 || m < 0 || m > 59 {
+		return 0, false
+	}
+	if twelveHour {
+		if h < 1 || h > 12 {
+			return 0, false
+		}
+		h %= 12
+		if pm {
+			h += 12
+		}
+	}
+	if h > 23 {
+		return 0, false
+	}
+	return h*60 + m, true
+}
@@ -0,0 +1,74 @@
+package data
+
+// StartMinutes returns the meeting start as minutes from midnight.
+func (m MeetingInfo) StartMinutes() (int, bool) {
+	return ParseClock(m.Start)
+}
+
+// IsScheduled reports whether the meeting has days and a concrete time.
+func (m MeetingInfo) IsScheduled() bool {
+	_, ok := m.StartMinutes()
+	return ok && len(m.Days) > 0 && m.DurationMin > 0
+}
+
+// MeetingsOverlap reports whether two meetings share a day and overlap in time.
+func MeetingsOverlap(a, b MeetingInfo) bool {
+	if !a.IsScheduled() || !b.IsScheduled() {
+		return false
+	}
+	if !sharesDay(a.Days, b.Days) {
+		return false
+	}
+	as, _ := a.StartMinutes()
+	bs, _ := b.StartMinutes()
+	return as < bs+b.DurationMin && bs < as+a.DurationMin
+}
+
+// DatesOverlap reports whether two sections are in session during a common
+// part of the term. Missing dates are treated as spanning the whole term.
+func DatesOverlap(a, b SectionInfo) bool {
+	aStart, aEnd := dateKey(a.StartDate), dateKey(a.EndDate)
+	bStart, bEnd := dateKey(b.StartDate), dateKey(b.EndDate)
+	if aStart != "" && bEnd != "" && aStart > bEnd {
+		return false
+	}
+	if bStart != "" && aEnd != "" && bStart > aEnd {
+		return false
+	}
+	return true
+}
+
+// SectionsConflict reports whether any meeting of a overlaps a meeting of b
+// while both sections are in session.
+func SectionsConflict(a, b SectionInfo) bool {
+	if a.Id == b.Id || !DatesOverlap(a, b) {
+		return false
+	}
+	for _, ma := range a.Meetings {
+		for _, mb := range b.Meetings {
+			if MeetingsOverlap(ma, mb) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sharesDay(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dateKey trims an ISO date or timestamp to YYYY-MM-DD for comparison.
+func dateKey(s string) string {
+	if len(s) >= 10 {
+		return s[:10]
+	}
+	return s
+}
@@ -0,0 +1,134 @@
+package data
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Dataset holds the Store currently being served and replaces it when the
+// JSON file on disk changes. Readers call Store for every request and never
+// see a half-built index.
+type Dataset struct {
+	path    string
+	current atomic.Pointer[Store]
+
+	mu        sync.Mutex // serializes reloads and hook registration
+	modTime   time.Time
+	loadHooks []func(old, next *Store)
+	hooks     []func(old, next *Store)
+}
+
+// OpenDataset loads path and remembers it for later reloads.
+func OpenDataset(path string) (*Dataset, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	store, err := LoadStore(path)
+	if err != nil {
+		return nil, err
+	}
+	d := &Dataset{path: path, modTime: info.ModTime()}
+	d.current.Store(store)
+	return d, nil
+}
+
+// Store returns the Store currently being served.
+func (d *Dataset) Store() *Store {
+	return d.current.Load()
+}
+
+// OnLoad registers fn to run on every reload after the new store is built
+// and before it is served, so fn may still fill it in, e.g. with names
+// fetched over the network. Stores are never changed once served.
+func (d *Dataset) OnLoad(fn func(old, next *Store)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.loadHooks = append(d.loadHooks, fn)
+}
+
+// OnSwap registers fn to run after every reload, with the store that was
+// replaced and the one now served. Hooks run in registration order on the
+// reloading goroutine.
+func (d *Dataset) OnSwap(fn func(old, next *Store)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.hooks = append(d.hooks, fn)
+}
+
+// Reload re-reads the file if its modification time changed and swaps the
+// new Store in. It reports whether a swap happened.
+func (d *Dataset) Reload(force bool) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return false, err
+	}
+	if !force && info.ModTime().Equal(d.modTime) {
+		return false, nil
+	}
+	next, err := LoadStore(d.path)
+	if err != nil {
+		return false, err
+	}
+	old := d.current.Load()
+	next.inheritNames(old)
+	for _, fn := range d.loadHooks {
+		fn(old, next)
+	}
+	d.current.Store(next)
+	d.modTime = info.ModTime()
+	for _, fn := range d.hooks {
+		fn(old, next)
+	}
+	return true, nil
+}
+
+// Watch polls the file every interval until ctx is done.
+func (d *Dataset) Watch(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		swapped, err := d.Reload(false)
+		if err != nil {
+			log.Printf("dataset reload failed: %v", err)
+		} else if swapped {
+			log.Printf("reloaded %s: %d courses", d.path, d.Store().CourseCount())
+		}
+	}
+}
+
+// inheritNames carries subject abbreviations, campus names and the term
+// name fetched for the previous store over to a freshly loaded one, so a
+// reload does not need the network.
+func (s *Store) inheritNames(old *Store) {
+	if old == nil {
+		return
+	}
+	if len(s.subjectAbbrById) == 0 && len(old.subjectAbbrById) > 0 {
+		s.subjectAbbrById = old.subjectAbbrById
+		for i := range s.courses {
+			s.courses[i].SubjectAbbr = s.subjectAbbrById[s.courses[i].SubjectId]
+		}
+		for id, c := range s.courseBySectionId {
+			c.SubjectAbbr = s.subjectAbbrById[c.SubjectId]
+			s.courseBySectionId[id] = c
+		}
+	}
+	if len(s.campusNameById) == 0 && len(old.campusNameById) > 0 {
+		s.campusNameById = old.campusNameById
+	}
+	if s.termName == "" && s.termId == old.termId {
+		s.termName = old.termName
+	}
+}
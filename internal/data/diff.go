@@ -0,0 +1,241 @@
+package data
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Fields reported by SectionChange
+const (
+	FieldType       = "type"
+	FieldDays       = "days"
+	FieldTime       = "time"
+	FieldRoom       = "room"
+	FieldInstructor = "instructor"
+	FieldMeetings   = "meetings" // meetings were added or removed
+)
+
+// Changeset lists what changed between two loads of the catalog.
+type Changeset struct {
+	CoursesAdded    []CourseRef     `json:"coursesAdded"`
+	CoursesRemoved  []CourseRef     `json:"coursesRemoved"`
+	SectionsAdded   []SectionRef    `json:"sectionsAdded"`
+	SectionsRemoved []SectionRef    `json:"sectionsRemoved"`
+	SectionsChanged []SectionChange `json:"sectionsChanged"`
+}
+
+// CourseRef names a course in a diff.
+type CourseRef struct {
+	Id     string `json:"id"`
+	Course string `json:"course"` // "CS 18000"
+	Title  string `json:"title"`
+}
+
+// SectionRef names a section in a diff.
+type SectionRef struct {
+	Id     string `json:"id"`
+	Crn    string `json:"crn"`
+	Course string `json:"course"`
+	Type   string `json:"type"`
+}
+
+// SectionChange lists the field changes of a section present in both loads.
+type SectionChange struct {
+	SectionRef
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange is one changed field. Meeting is the meeting's position in
+// the section, or -1 for section-wide fields.
+type FieldChange struct {
+	Field   string `json:"field"`
+	Meeting int    `json:"meeting"`
+	Old     string `json:"old"`
+	New     string `json:"new"`
+}
+
+// Empty reports whether the loads were equivalent.
+func (d Changeset) Empty() bool {
+	return len(d.CoursesAdded) == 0 && len(d.CoursesRemoved) == 0 &&
+		len(d.SectionsAdded) == 0 && len(d.SectionsRemoved) == 0 && len(d.SectionsChanged) == 0
+}
+
+// Diff compares two stores by course and section id. Seat counts are not
+// compared; the seat history tracks those.
+func Diff(old, next *Store) Changeset {
+	d := Changeset{
+		CoursesAdded:    []CourseRef{},
+		CoursesRemoved:  []CourseRef{},
+		SectionsAdded:   []SectionRef{},
+		SectionsRemoved: []SectionRef{},
+		SectionsChanged: []SectionChange{},
+	}
+	oldCourses := old.courseIndex()
+	nextCourses := next.courseIndex()
+	for id, c := range nextCourses {
+		if _, ok := oldCourses[id]; !ok {
+			d.CoursesAdded = append(d.CoursesAdded, next.courseRef(c))
+		}
+	}
+	for id, c := range oldCourses {
+		if _, ok := nextCourses[id]; !ok {
+			d.CoursesRemoved = append(d.CoursesRemoved, old.courseRef(c))
+		}
+	}
+
+	for id, sec := range next.sectionById {
+		prev, ok := old.sectionById[id]
+		if !ok {
+			d.SectionsAdded = append(d.SectionsAdded, next.sectionRef(sec))
+			continue
+		}
+		if changes := diffSection(prev, sec); len(changes) > 0 {
+			d.SectionsChanged = append(d.SectionsChanged, SectionChange{SectionRef: next.sectionRef(sec), Changes: changes})
+		}
+	}
+	for id, sec := range old.sectionById {
+		if _, ok := next.sectionById[id]; !ok {
+			d.SectionsRemoved = append(d.SectionsRemoved, old.sectionRef(sec))
+		}
+	}
+
+	byCourse := func(a, b CourseRef) int { return strings.Compare(a.Course+a.Id, b.Course+b.Id) }
+	bySection := func(a, b SectionRef) int { return strings.Compare(a.Course+a.Crn+a.Id, b.Course+b.Crn+b.Id) }
+	slices.SortFunc(d.CoursesAdded, byCourse)
+	slices.SortFunc(d.CoursesRemoved, byCourse)
+	slices.SortFunc(d.SectionsAdded, bySection)
+	slices.SortFunc(d.SectionsRemoved, bySection)
+	slices.SortFunc(d.SectionsChanged, func(a, b SectionChange) int { return bySection(a.SectionRef, b.SectionRef) })
+	return d
+}
+
+func (s *Store) courseIndex() map[string]CourseSummary {
+	out := make(map[string]CourseSummary, len(s.courses))
+	for _, c := range s.courses {
+		out[c.Id] = c
+	}
+	return out
+}
+
+func (s *Store) courseRef(c CourseSummary) CourseRef {
+	return CourseRef{Id: c.Id, Course: CourseLabel(s, c), Title: c.Title}
+}
+
+func (s *Store) sectionRef(sec SectionInfo) SectionRef {
+	ref := SectionRef{Id: sec.Id, Crn: sec.Crn, Type: sec.Type}
+	if c, ok := s.courseBySectionId[sec.Id]; ok {
+		ref.Course = CourseLabel(s, c)
+	}
+	return ref
+}
+
+// diffSection compares meetings by position, which is stable between
+// exports of the same section.
+func diffSection(old, next SectionInfo) []FieldChange {
+	var out []FieldChange
+	add := func(field string, meeting int, a, b string) {
+		if a != b {
+			out = append(out, FieldChange{Field: field, Meeting: meeting, Old: a, New: b})
+		}
+	}
+	add(FieldType, -1, old.Type, next.Type)
+	if len(old.Meetings) != len(next.Meetings) {
+		add(FieldMeetings, -1, describeMeetings(old.Meetings), describeMeetings(next.Meetings))
+		return out
+	}
+	for i := range next.Meetings {
+		a, b := old.Meetings[i], next.Meetings[i]
+		add(FieldDays, i, DayLetters(a.Days), DayLetters(b.Days))
+		add(FieldTime, i, meetingTime(a), meetingTime(b))
+		add(FieldRoom, i, meetingRoom(a), meetingRoom(b))
+		add(FieldInstructor, i, instructors(a), instructors(b))
+	}
+	return out
+}
+
+func meetingTime(m MeetingInfo) string {
+	start, ok := m.StartMinutes()
+	if !ok || m.DurationMin <= 0 {
+		return "TBA"
+	}
+	end := start + m.DurationMin
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", start/60, start%60, end/60, end%60)
+}
+
+func meetingRoom(m MeetingInfo) string {
+	room := strings.TrimSpace(m.BuildingCode + " " + m.RoomNumber)
+	if room == "" {
+		return "TBA"
+	}
+	return room
+}
+
+func instructors(m MeetingInfo) string {
+	if len(m.Instructors) == 0 {
+		return "TBA"
+	}
+	names := slices.Clone(m.Instructors)
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func describeMeetings(ms []MeetingInfo) string {
+	if len(ms) == 0 {
+		return "none"
+	}
+	parts := make([]string, 0, len(ms))
+	for _, m := range ms {
+		parts = append(parts, fmt.Sprintf("%s %s %s", DayLetters(m.Days), meetingTime(m), meetingRoom(m)))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Summary describes the diff in plain sentences, one per line, e.g.
+// "CS 18000 Laboratory (CRN 12345) moved from LWSN B146 to HAAS G040."
+func (d Changeset) Summary() []string {
+	var out []string
+	for _, c := range d.CoursesAdded {
+		out = append(out, fmt.Sprintf("New course %s: %s.", c.Course, c.Title))
+	}
+	for _, c := range d.CoursesRemoved {
+		out = append(out, fmt.Sprintf("Course %s (%s) was removed.", c.Course, c.Title))
+	}
+	for _, s := range d.SectionsAdded {
+		out = append(out, fmt.Sprintf("New section: %s.", s.Label()))
+	}
+	for _, s := range d.SectionsRemoved {
+		out = append(out, fmt.Sprintf("%s was removed.", s.Label()))
+	}
+	for _, sc := range d.SectionsChanged {
+		for _, fc := range sc.Changes {
+			out = append(out, sc.Label()+" "+fc.Sentence()+".")
+		}
+	}
+	return out
+}
+
+// Label names the section as "CS 18000 Lecture (CRN 12345)".
+func (s SectionRef) Label() string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s (CRN %s)", s.Course, s.Type, s.Crn))
+}
+
+// Sentence describes the change as a predicate, e.g. "moved from LWSN
+// B146 to HAAS G040".
+func (fc FieldChange) Sentence() string {
+	switch fc.Field {
+	case FieldRoom:
+		return fmt.Sprintf("moved from %s to %s", fc.Old, fc.New)
+	case FieldTime:
+		return fmt.Sprintf("now meets %s instead of %s", fc.New, fc.Old)
+	case FieldDays:
+		return fmt.Sprintf("now meets on %s instead of %s", fc.New, fc.Old)
+	case FieldInstructor:
+		return fmt.Sprintf("is now taught by %s instead of %s", fc.New, fc.Old)
+	case FieldType:
+		return fmt.Sprintf("changed type from %s to %s", fc.Old, fc.New)
+	default:
+		return fmt.Sprintf("meetings changed from %s to %s", fc.Old, fc.New)
+	}
+}
@@ -39,6 +39,7 @@ func LoadStore(path string) (*Store, error) {
 		campusNameById:    make(map[string]string, 0),
 	}
 
+	classesByTerm := make(map[string]int)
 	for dec.More() {
 		var rc rawCourse
 		if err := dec.Decode(&rc); err != nil {
@@ -54,6 +55,7 @@ func LoadStore(path string) (*Store, error) {
 			Number:    rc.Number,
 			Title:     rc.Title,
 			SubjectId: rc.SubjectId,
+			Credits:   rc.CreditHours,
 		}
 		store.courses = append(store.courses, cs)
 
@@ -67,6 +69,9 @@ func LoadStore(path string) (*Store, error) {
 			if cls.CampusId != "" {
 				store.courseToCampusSet[rc.Id][cls.CampusId] = struct{}{}
 			}
+			if cls.TermId != "" {
+				classesByTerm[cls.TermId]++
+			}
 			for _, sec := range cls.Sections {
 				s := SectionInfo{
 					Id:        sec.Id,
@@ -75,6 +80,8 @@ func LoadStore(path string) (*Store, error) {
 					StartDate: sec.StartDate,
 					EndDate:   sec.EndDate,
 					CampusId:  cls.CampusId,
+					ClassId:   cls.Id,
+					Seats:     parseSeats(sec),
 				}
 				// Meetings
 				for _, m := range sec.Meetings {
@@ -111,6 +118,12 @@ func LoadStore(path string) (*Store, error) {
 	// Drain closing bracket
 	_, _ = dec.Token()
 
+	for id, n := range classesByTerm {
+		if best := classesByTerm[store.termId]; n > best || n == best && id < store.termId {
+			store.termId = id
+		}
+	}
+
 	// Sort courses by title for default browsing
 	sort.Slice(store.courses, func(i, j int) bool {
 		if store.courses[i].Title == store.courses[j].Title {
@@ -122,6 +135,31 @@ func LoadStore(path string) (*Store, error) {
 	return store, nil
 }
 
+// parseSeats returns nil unless the section carries a capacity.
+func parseSeats(sec rawSection) *Seats {
+	if sec.Capacity == nil {
+		return nil
+	}
+	val := func(p *int) int {
+		if p == nil {
+			return 0
+		}
+		return *p
+	}
+	seats := &Seats{
+		Capacity:         *sec.Capacity,
+		Enrolled:         val(sec.Enrolled),
+		WaitlistCapacity: val(sec.WaitListCapacity),
+		WaitlistCount:    val(sec.WaitListCount),
+	}
+	if sec.RemainingSpace != nil {
+		seats.Remaining = *sec.RemainingSpace
+	} else {
+		seats.Remaining = max(0, seats.Capacity-seats.Enrolled)
+	}
+	return seats
+}
+
 func parseDays(s string) []string {
 	s = strings.TrimSpace(s)
 	if s == "" || strings.EqualFold(s, "None") {
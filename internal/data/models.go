@@ -2,6 +2,7 @@ package data
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"sort"
 	"strings"
@@ -10,11 +11,12 @@ import (
 
 // Raw structures mirror only fields we need from JSON file
 type rawCourse struct {
-	Id        string     `json:"Id"`
-	Number    string     `json:"Number"`
-	SubjectId string     `json:"SubjectId"`
-	Title     string     `json:"Title"`
-	Classes   []rawClass `json:"Classes"`
+	Id          string     `json:"Id"`
+	Number      string     `json:"Number"`
+	SubjectId   string     `json:"SubjectId"`
+	Title       string     `json:"Title"`
+	CreditHours float64    `json:"CreditHours"` // absent from older exports
+	Classes     []rawClass `json:"Classes"`
 }
 
 type rawClass struct {
@@ -33,6 +35,12 @@ type rawSection struct {
 	StartDate string       `json:"StartDate"`
 	EndDate   string       `json:"EndDate"`
 	Meetings  []rawMeeting `json:"Meetings"`
+	// Seat counts are only present in exports taken with enrollment data
+	Capacity         *int `json:"Capacity"`
+	Enrolled         *int `json:"Enrolled"`
+	RemainingSpace   *int `json:"RemainingSpace"`
+	WaitListCapacity *int `json:"WaitListCapacity"`
+	WaitListCount    *int `json:"WaitListCount"`
 }
 
 type rawMeeting struct {
@@ -69,11 +77,12 @@ type rawPerson struct {
 
 // Public API models and store
 type CourseSummary struct {
-	Id          string `json:"id"`
-	Number      string `json:"number"`
-	Title       string `json:"title"`
-	SubjectId   string `json:"subjectId"`
-	SubjectAbbr string `json:"subjectAbbr"`
+	Id          string  `json:"id"`
+	Number      string  `json:"number"`
+	Title       string  `json:"title"`
+	SubjectId   string  `json:"subjectId"`
+	SubjectAbbr string  `json:"subjectAbbr"`
+	Credits     float64 `json:"credits,omitempty"` // 0 when the dataset does not say
 }
 
 type MeetingInfo struct {
@@ -86,6 +95,27 @@ type MeetingInfo struct {
 	Type         string   `json:"type"`
 }
 
+var dayLetter = map[string]string{
+	"Monday": "M", "Tuesday": "T", "Wednesday": "W", "Thursday": "R",
+	"Friday": "F", "Saturday": "S", "Sunday": "U",
+}
+
+// DayLetters abbreviates days the way the registrar does: "MWF", "TR".
+func DayLetters(days []string) string {
+	if len(days) == 0 {
+		return "TBA"
+	}
+	var b strings.Builder
+	for _, d := range days {
+		if l, ok := dayLetter[d]; ok {
+			b.WriteString(l)
+		} else {
+			b.WriteString(d)
+		}
+	}
+	return b.String()
+}
+
 type SectionInfo struct {
 	Id        string        `json:"id"`
 	Crn       string        `json:"crn"`
@@ -94,6 +124,26 @@ type SectionInfo struct {
 	EndDate   string        `json:"endDate"`
 	Meetings  []MeetingInfo `json:"meetings"`
 	CampusId  string        `json:"campusId"`
+	// ClassId groups linked sections (e.g. a lecture and its labs) that
+	// must be registered together.
+	ClassId string `json:"classId"`
+	// Seats is nil when the dataset carries no enrollment numbers
+	Seats *Seats `json:"seats,omitempty"`
+}
+
+// Seats is a section's capacity and enrollment at load time.
+type Seats struct {
+	Capacity         int `json:"capacity"`
+	Enrolled         int `json:"enrolled"`
+	Remaining        int `json:"remaining"`
+	WaitlistCapacity int `json:"waitlistCapacity"`
+	WaitlistCount    int `json:"waitlistCount"`
+}
+
+// Full reports whether a section has no open seats. Sections without seat
+// data are never considered full.
+func (s SectionInfo) Full() bool {
+	return s.Seats != nil && s.Seats.Remaining <= 0
 }
 
 type Store struct {
@@ -110,6 +160,9 @@ type Store struct {
 	courseToCampusSet map[string]map[string]struct{}
 	// CampusId -> Campus Name
 	campusNameById map[string]string
+	// The term most classes belong to, and its name once fetched
+	termId   string
+	termName string
 }
 
 func (s *Store) CourseCount() int {
@@ -166,6 +219,28 @@ func (s *Store) SectionsByCourse(courseId string) []SectionInfo {
 	return s.courseToSections[courseId]
 }
 
+// OpenSections drops full sections.
+func OpenSections(secs []SectionInfo) []SectionInfo {
+	out := make([]SectionInfo, 0, len(secs))
+	for _, sec := range secs {
+		if !sec.Full() {
+			out = append(out, sec)
+		}
+	}
+	return out
+}
+
+// HasOpenSection reports whether a course has a section with seats left,
+// optionally on one campus.
+func (s *Store) HasOpenSection(courseId, campusId string) bool {
+	for _, sec := range s.courseToSections[courseId] {
+		if (campusId == "" || sec.CampusId == campusId) && !sec.Full() {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Store) SectionsByIds(ids []string) []SectionInfo {
 	out := make([]SectionInfo, 0, len(ids))
 	for _, id := range ids {
@@ -176,6 +251,35 @@ func (s *Store) SectionsByIds(ids []string) []SectionInfo {
 	return out
 }
 
+// ResolveSections looks up ids like SectionsByIds but also reports the ids
+// that are no longer in the catalog instead of silently dropping them.
+func (s *Store) ResolveSections(ids []string) (found []SectionInfo, missing []string) {
+	found = make([]SectionInfo, 0, len(ids))
+	for _, id := range ids {
+		if sec, ok := s.sectionById[id]; ok {
+			found = append(found, sec)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return found, missing
+}
+
+// AllSections returns every section, in no particular order.
+func (s *Store) AllSections() []SectionInfo {
+	out := make([]SectionInfo, 0, len(s.sectionById))
+	for _, sec := range s.sectionById {
+		out = append(out, sec)
+	}
+	return out
+}
+
+// SectionById returns a single section by id.
+func (s *Store) SectionById(id string) (SectionInfo, bool) {
+	sec, ok := s.sectionById[id]
+	return sec, ok
+}
+
 func (s *Store) CourseBySectionId(id string) (CourseSummary, bool) {
 	c, ok := s.courseBySectionId[id]
 	return c, ok
@@ -189,6 +293,17 @@ func (s *Store) SubjectAbbr(subjectId string) string {
 	return s.subjectAbbrById[subjectId]
 }
 
+// CourseLabel formats a course as "CS 18000", looking the subject up in s
+// when c does not carry it and falling back to the number alone when
+// subject names were not fetched. s may be nil.
+func CourseLabel(s *Store, c CourseSummary) string {
+	abbr := c.SubjectAbbr
+	if abbr == "" {
+		abbr = s.SubjectAbbr(c.SubjectId)
+	}
+	return strings.TrimSpace(abbr + " " + c.Number)
+}
+
 // Subject payloads for enrichment
 type subjectResp struct {
 	Value []subject `json:"value"`
@@ -296,6 +411,16 @@ func (s *Store) GetCampuses() []Campus {
 	return campuses
 }
 
+// CampusName returns the display name for a campus id, falling back to the id.
+func (s *Store) CampusName(campusId string) string {
+	if s != nil && s.campusNameById != nil {
+		if name := s.campusNameById[campusId]; name != "" {
+			return name
+		}
+	}
+	return campusId
+}
+
 // GetAllDepartmentsByCampus filters departments by campus id
 func (s *Store) GetAllDepartmentsByCampus(campusId string) []Department {
 	if campusId == "" {
@@ -371,6 +496,45 @@ func (s *Store) SectionsByCourseCampus(courseId, campusId string) []SectionInfo
 	return filtered
 }
 
+// TermId is the Purdue API id of the term the dataset covers: the one most
+// of its classes belong to.
+func (s *Store) TermId() string {
+	return s.termId
+}
+
+// TermName is the name of the dataset's term, e.g. "Fall 2025", or "" until
+// MaybeFetchTerm has looked it up.
+func (s *Store) TermName() string {
+	return s.termName
+}
+
+// MaybeFetchTerm names the dataset's term via Purdue API if not yet named.
+func (s *Store) MaybeFetchTerm() error {
+	if s.termName != "" || s.termId == "" {
+		return nil
+	}
+	client := &http.Client{Timeout: 15 * time.Second}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.purdue.io/odata/Terms", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var tr struct {
+		Value []struct{ Id, Name string } `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return err
+	}
+	for _, v := range tr.Value {
+		if v.Id == s.termId {
+			s.termName = v.Name
+			return nil
+		}
+	}
+	return fmt.Errorf("term %s is not in the Purdue API", s.termId)
+}
+
 // MaybeFetchCampuses populates campus names via Purdue API if empty
 func (s *Store) MaybeFetchCampuses() error {
 	if s.campusNameById != nil && len(s.campusNameById) > 0 {
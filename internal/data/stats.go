@@ -0,0 +1,67 @@
+package data
+
+import "sort"
+
+// Stats summarizes a loaded dataset for operators.
+type Stats struct {
+	Courses                 int            `json:"courses"`
+	Sections                int            `json:"sections"`
+	Meetings                int            `json:"meetings"`
+	MeetingsByCampus        map[string]int `json:"meetingsByCampus"`
+	SectionsWithoutMeetings int            `json:"sectionsWithoutMeetings"`
+	// UnscheduledMeetings have no days or start time (TBA, online, arranged)
+	UnscheduledMeetings int `json:"unscheduledMeetings"`
+	// UnknownBuildingMeetings are scheduled but name no building
+	UnknownBuildingMeetings int            `json:"unknownBuildingMeetings"`
+	Buildings               []BuildingUse  `json:"buildings"`
+	SectionTypes            map[string]int `json:"sectionTypes"`
+}
+
+// BuildingUse counts scheduled meetings per building code.
+type BuildingUse struct {
+	Code     string `json:"code"`
+	Meetings int    `json:"meetings"`
+}
+
+// Stats walks every section once. Campuses are keyed by id; use CampusName
+// for display.
+func (s *Store) Stats() Stats {
+	st := Stats{
+		Courses:          len(s.courses),
+		MeetingsByCampus: make(map[string]int),
+		SectionTypes:     make(map[string]int),
+	}
+	buildings := make(map[string]int)
+	for _, sec := range s.sectionById {
+		st.Sections++
+		st.SectionTypes[sec.Type]++
+		if len(sec.Meetings) == 0 {
+			st.SectionsWithoutMeetings++
+			continue
+		}
+		for _, m := range sec.Meetings {
+			st.Meetings++
+			st.MeetingsByCampus[sec.CampusId]++
+			if !m.IsScheduled() {
+				st.UnscheduledMeetings++
+				continue
+			}
+			if m.BuildingCode == "" {
+				st.UnknownBuildingMeetings++
+				continue
+			}
+			buildings[m.BuildingCode]++
+		}
+	}
+	st.Buildings = make([]BuildingUse, 0, len(buildings))
+	for code, n := range buildings {
+		st.Buildings = append(st.Buildings, BuildingUse{Code: code, Meetings: n})
+	}
+	sort.Slice(st.Buildings, func(i, j int) bool {
+		if st.Buildings[i].Meetings != st.Buildings[j].Meetings {
+			return st.Buildings[i].Meetings > st.Buildings[j].Meetings
+		}
+		return st.Buildings[i].Code < st.Buildings[j].Code
+	})
+	return st
+}
@@ -0,0 +1,301 @@
+package data
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Severities, most serious first
+const (
+	SeverityError   = "error"   // the schedule cannot be registered as is
+	SeverityWarning = "warning" // allowed, but probably not what the student wants
+	SeverityInfo    = "info"
+)
+
+// Finding codes. They are stable so clients can match on them.
+const (
+	CodeUnknownSection      = "unknown_section"
+	CodeTimeConflict        = "time_conflict"
+	CodePartialTermConflict = "partial_term_conflict" // clash during only part of the term
+	CodePartialTermOverlap  = "partial_term_overlap"  // same time slot, different parts of the term
+	CodeDuplicateSection    = "duplicate_section"
+	CodeLinkedGroups        = "mixed_linked_groups"
+	CodeMissingComponent    = "missing_component"
+	CodeMixedCampus         = "mixed_campus"
+	CodeCreditsLow          = "credits_below_min"
+	CodeCreditsHigh         = "credits_above_max"
+	CodeTBA                 = "tba_meeting"
+)
+
+// Credit limits for a full-time undergraduate term without overload approval
+const (
+	DefaultMinCredits = 12
+	DefaultMaxCredits = 18
+	// assumedCredits stands in for courses whose credit hours are unknown
+	assumedCredits = 3
+)
+
+// ValidateOptions tunes Validate. Zero values use the defaults.
+type ValidateOptions struct {
+	MinCredits float64
+	MaxCredits float64
+}
+
+// Finding is one problem found in a schedule.
+type Finding struct {
+	Code     string   `json:"code"`
+	Severity string   `json:"severity"`
+	Message  string   `json:"message"`
+	Course   string   `json:"course,omitempty"`
+	Sections []string `json:"sections,omitempty"` // ids of the sections involved
+}
+
+// Report is the outcome of validating a schedule.
+type Report struct {
+	Valid            bool           `json:"valid"` // no errors
+	Credits          float64        `json:"credits"`
+	CreditsEstimated bool           `json:"creditsEstimated"` // some courses assumed 3 credits
+	MinCredits       float64        `json:"minCredits"`
+	MaxCredits       float64        `json:"maxCredits"`
+	Counts           map[string]int `json:"counts"` // findings per severity
+	Findings         []Finding      `json:"findings"`
+}
+
+// Has reports whether the report contains a finding with code.
+func (r Report) Has(code string) bool {
+	return slices.ContainsFunc(r.Findings, func(f Finding) bool { return f.Code == code })
+}
+
+// Validate checks a schedule given as section ids. Findings are ordered by
+// severity, then by the order the sections were given in.
+func (s *Store) Validate(ids []string, opts ValidateOptions) Report {
+	if opts.MinCredits <= 0 {
+		opts.MinCredits = DefaultMinCredits
+	}
+	if opts.MaxCredits <= 0 {
+		opts.MaxCredits = DefaultMaxCredits
+	}
+	// A lowered maximum also lowers the default minimum
+	opts.MinCredits = min(opts.MinCredits, opts.MaxCredits)
+	r := Report{
+		MinCredits: opts.MinCredits,
+		MaxCredits: opts.MaxCredits,
+		Counts:     map[string]int{SeverityError: 0, SeverityWarning: 0, SeverityInfo: 0},
+		Findings:   []Finding{},
+	}
+	add := func(f Finding) {
+		r.Findings = append(r.Findings, f)
+	}
+
+	var sections []SectionInfo
+	seen := make(map[string]bool)
+	for _, raw := range ids {
+		id := strings.TrimSpace(raw)
+		if id == "" {
+			continue
+		}
+		if seen[id] {
+			add(Finding{Code: CodeDuplicateSection, Severity: SeverityWarning, Message: fmt.Sprintf("Section %s is listed more than once.", id), Sections: []string{id}})
+			continue
+		}
+		seen[id] = true
+		sec, ok := s.sectionById[id]
+		if !ok {
+			add(Finding{Code: CodeUnknownSection, Severity: SeverityError, Message: fmt.Sprintf("Section %s is not in the catalog.", id), Sections: []string{id}})
+			continue
+		}
+		sections = append(sections, sec)
+	}
+
+	// Per course: duplicates, linked groups, missing components, credits
+	var order []string
+	byCourse := make(map[string][]SectionInfo)
+	for _, sec := range sections {
+		c := s.courseBySectionId[sec.Id]
+		if _, ok := byCourse[c.Id]; !ok {
+			order = append(order, c.Id)
+		}
+		byCourse[c.Id] = append(byCourse[c.Id], sec)
+	}
+	for _, courseId := range order {
+		chosen := byCourse[courseId]
+		c := s.courseBySectionId[chosen[0].Id]
+		label := CourseLabel(s, c)
+		if c.Credits > 0 {
+			r.Credits += c.Credits
+		} else {
+			r.Credits += assumedCredits
+			r.CreditsEstimated = true
+		}
+
+		byType := make(map[string][]string)
+		classes := make(map[string]bool)
+		for _, sec := range chosen {
+			byType[sec.Type] = append(byType[sec.Type], sec.Id)
+			classes[sec.ClassId] = true
+		}
+		for _, t := range sortedKeys(byType) {
+			if len(byType[t]) > 1 {
+				add(Finding{Code: CodeDuplicateSection, Severity: SeverityError, Course: label, Sections: byType[t],
+					Message: fmt.Sprintf("%s has %d %s sections; register for one.", label, len(byType[t]), strings.ToLower(typeName(t)))})
+			}
+		}
+		if len(classes) > 1 {
+			add(Finding{Code: CodeLinkedGroups, Severity: SeverityWarning, Course: label, Sections: sectionIds(chosen),
+				Message: fmt.Sprintf("%s sections come from different linked groups and may be rejected at registration.", label)})
+			continue
+		}
+		// Every section type offered in the chosen linked group is required
+		for _, t := range s.classTypes(courseId, chosen[0].ClassId) {
+			if _, ok := byType[t]; !ok {
+				add(Finding{Code: CodeMissingComponent, Severity: SeverityError, Course: label, Sections: sectionIds(chosen),
+					Message: fmt.Sprintf("%s also needs a %s section.", label, strings.ToLower(typeName(t)))})
+			}
+		}
+	}
+
+	// Pairwise: conflicts and partial-term overlaps
+	for i, a := range sections {
+		for _, b := range sections[i+1:] {
+			if !meetingsClash(a, b) {
+				continue
+			}
+			pair := []string{a.Id, b.Id}
+			names := fmt.Sprintf("%s and %s", s.sectionName(a), s.sectionName(b))
+			switch {
+			case !DatesOverlap(a, b):
+				add(Finding{Code: CodePartialTermOverlap, Severity: SeverityInfo, Sections: pair,
+					Message: fmt.Sprintf("%s share a time slot but meet in different parts of the term.", names)})
+			case sameDates(a, b):
+				add(Finding{Code: CodeTimeConflict, Severity: SeverityError, Sections: pair,
+					Message: fmt.Sprintf("%s meet at the same time.", names)})
+			default:
+				add(Finding{Code: CodePartialTermConflict, Severity: SeverityError, Sections: pair,
+					Message: fmt.Sprintf("%s meet at the same time during part of the term (%s).", names, overlapDates(a, b))})
+			}
+		}
+	}
+
+	// Whole schedule: campuses, TBA meetings, credit limits
+	campuses := make(map[string]bool)
+	for _, sec := range sections {
+		if sec.CampusId != "" {
+			campuses[sec.CampusId] = true
+		}
+	}
+	if len(campuses) > 1 {
+		names := make([]string, 0, len(campuses))
+		for _, id := range sortedKeys(campuses) {
+			if n := s.CampusName(id); n != "" {
+				names = append(names, n)
+			} else {
+				names = append(names, id)
+			}
+		}
+		add(Finding{Code: CodeMixedCampus, Severity: SeverityWarning, Sections: sectionIds(sections),
+			Message: fmt.Sprintf("Sections are on %d campuses: %s.", len(names), strings.Join(names, ", "))})
+	}
+	for _, sec := range sections {
+		for _, m := range sec.Meetings {
+			if !m.IsScheduled() {
+				add(Finding{Code: CodeTBA, Severity: SeverityInfo, Sections: []string{sec.Id},
+					Message: fmt.Sprintf("%s has a meeting with no set time; it is left off the calendar.", s.sectionName(sec))})
+				break
+			}
+		}
+	}
+	if len(sections) > 0 {
+		est := ""
+		if r.CreditsEstimated {
+			est = " (estimated)"
+		}
+		switch {
+		case r.Credits < opts.MinCredits:
+			add(Finding{Code: CodeCreditsLow, Severity: SeverityWarning,
+				Message: fmt.Sprintf("%g credits%s is below the %g needed for full-time status.", r.Credits, est, opts.MinCredits)})
+		case r.Credits > opts.MaxCredits:
+			add(Finding{Code: CodeCreditsHigh, Severity: SeverityWarning,
+				Message: fmt.Sprintf("%g credits%s is above %g and needs overload approval.", r.Credits, est, opts.MaxCredits)})
+		}
+	}
+
+	rank := map[string]int{SeverityError: 0, SeverityWarning: 1, SeverityInfo: 2}
+	slices.SortStableFunc(r.Findings, func(a, b Finding) int { return rank[a.Severity] - rank[b.Severity] })
+	for _, f := range r.Findings {
+		r.Counts[f.Severity]++
+	}
+	r.Valid = r.Counts[SeverityError] == 0
+	return r
+}
+
+// classTypes lists the section types offered in one linked group of a
+// course.
+func (s *Store) classTypes(courseId, classId string) []string {
+	set := make(map[string]bool)
+	for _, sec := range s.courseToSections[courseId] {
+		if sec.ClassId == classId && sec.Type != "" {
+			set[sec.Type] = true
+		}
+	}
+	return sortedKeys(set)
+}
+
+// sectionName describes a section as "CS 18000 Lecture (CRN 12345)".
+func (s *Store) sectionName(sec SectionInfo) string {
+	return s.sectionRef(sec).Label()
+}
+
+// meetingsClash reports whether any meetings of a and b overlap in time,
+// ignoring dates.
+func meetingsClash(a, b SectionInfo) bool {
+	for _, ma := range a.Meetings {
+		for _, mb := range b.Meetings {
+			if MeetingsOverlap(ma, mb) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sameDates(a, b SectionInfo) bool {
+	return dateKey(a.StartDate) == dateKey(b.StartDate) && dateKey(a.EndDate) == dateKey(b.EndDate)
+}
+
+// overlapDates formats the dates both sections are in session.
+func overlapDates(a, b SectionInfo) string {
+	start := max(dateKey(a.StartDate), dateKey(b.StartDate))
+	end := dateKey(a.EndDate)
+	if e := dateKey(b.EndDate); end == "" || (e != "" && e < end) {
+		end = e
+	}
+	if start == "" || end == "" {
+		return "dates not listed"
+	}
+	return start + " to " + end
+}
+
+func typeName(t string) string {
+	if t == "" {
+		return "Unspecified"
+	}
+	return t
+}
+
+func sectionIds(secs []SectionInfo) []string {
+	out := make([]string, 0, len(secs))
+	for _, sec := range secs {
+		out = append(out, sec.Id)
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,104 @@
+package layout
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"purdue_schedule/internal/data"
+)
+
+// DayNames names the columns a schedule grid can have, by day index.
+var DayNames = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// DayIndex returns the index of a day name as the catalog spells it
+// ("Monday"), or -1.
+func DayIndex(day string) int {
+	return slices.Index(DayNames, day)
+}
+
+// DefaultDays picks the day columns for a schedule: Monday to Friday, plus
+// Saturday and Sunday when something meets on them.
+func DefaultDays(sections []data.SectionInfo) []int {
+	days := []int{0, 1, 2, 3, 4}
+	for _, s := range sections {
+		for _, m := range s.Meetings {
+			if !m.IsScheduled() {
+				continue
+			}
+			for _, d := range m.Days {
+				if i := DayIndex(d); i > 4 && !slices.Contains(days, i) {
+					days = append(days, i)
+				}
+			}
+		}
+	}
+	slices.Sort(days)
+	return days
+}
+
+// ParseDays reads the days= parameter. It takes "weekdays", "weekend" or
+// "all", day names and three-letter abbreviations separated by commas
+// ("mon,wed,sat"), ranges ("mon-sat"), or the letters the catalog prints
+// ("MTWRFSU"). An empty value returns nil, meaning the days come from the
+// schedule.
+func ParseDays(s string) ([]int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "":
+		return nil, nil
+	case "weekdays":
+		return []int{0, 1, 2, 3, 4}, nil
+	case "weekend":
+		return []int{5, 6}, nil
+	case "all", "week":
+		return []int{0, 1, 2, 3, 4, 5, 6}, nil
+	}
+	var days []int
+	add := func(i int) {
+		if !slices.Contains(days, i) {
+			days = append(days, i)
+		}
+	}
+	for _, tok := range strings.Split(s, ",") {
+		tok = strings.TrimSpace(tok)
+		if from, to, ok := strings.Cut(tok, "-"); ok {
+			a, b := dayByName(from), dayByName(to)
+			if a < 0 || b < 0 || a > b {
+				return nil, fmt.Errorf("invalid day range %q", tok)
+			}
+			for i := a; i <= b; i++ {
+				add(i)
+			}
+			continue
+		}
+		if i := dayByName(tok); i >= 0 {
+			add(i)
+			continue
+		}
+		if tok == "" {
+			return nil, fmt.Errorf("invalid days %q", s)
+		}
+		for _, r := range tok {
+			i := strings.IndexRune("mtwrfsu", r)
+			if i < 0 {
+				return nil, fmt.Errorf("invalid day %q", tok)
+			}
+			add(i)
+		}
+	}
+	slices.Sort(days)
+	return days, nil
+}
+
+// dayByName matches a lowercase day name or its first three letters.
+func dayByName(s string) int {
+	s = strings.TrimSpace(s)
+	for i, d := range DayNames {
+		d = strings.ToLower(d)
+		if s == d || s == d[:3] {
+			return i
+		}
+	}
+	return -1
+}
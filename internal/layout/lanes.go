@@ -0,0 +1,63 @@
+package layout
+
+import "sort"
+
+// assignLanes groups blocks that overlap in time on the same day into
+// clusters and gives each block the first lane in its cluster that is free
+// when it starts, as calendar apps do. Every block in a cluster is as wide
+// as the cluster has lanes, so blocks line up.
+func assignLanes(blocks []Block) {
+	order := make([]int, len(blocks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		x, y := blocks[order[a]], blocks[order[b]]
+		if x.Column != y.Column {
+			return x.Column < y.Column
+		}
+		if x.Start != y.Start {
+			return x.Start < y.Start
+		}
+		return x.End > y.End // longer first
+	})
+
+	var (
+		cluster  []int
+		laneEnds []int // when each lane of the cluster frees up
+		column   int
+		end      int
+	)
+	finish := func() {
+		for _, i := range cluster {
+			blocks[i].Lanes = len(laneEnds)
+			blocks[i].Conflict = len(cluster) > 1
+		}
+		cluster, laneEnds = cluster[:0], laneEnds[:0]
+	}
+	for _, i := range order {
+		b := blocks[i]
+		if len(cluster) > 0 && (b.Column != column || b.Start >= end) {
+			finish()
+		}
+		if len(cluster) == 0 {
+			column, end = b.Column, b.End
+		}
+		lane := len(laneEnds)
+		for l, e := range laneEnds {
+			if e <= b.Start {
+				lane = l
+				break
+			}
+		}
+		if lane == len(laneEnds) {
+			laneEnds = append(laneEnds, b.End)
+		} else {
+			laneEnds[lane] = b.End
+		}
+		blocks[i].Lane = lane
+		cluster = append(cluster, i)
+		end = max(end, b.End)
+	}
+	finish()
+}
@@ -0,0 +1,251 @@
+// Package layout places a schedule's meetings on a week grid. It decides the
+// day columns, the visible time span and where each class block goes, once,
+// so every export format paints the same picture. Positions are fractions of
+// the grid body; a renderer scales them to its own units.
+package layout
+
+import (
+	"slices"
+	"strings"
+
+	"purdue_schedule/internal/data"
+)
+
+// Block is one meeting on one day.
+type Block struct {
+	Section data.SectionInfo
+	Course  data.CourseSummary // zero when the section's course is unknown
+	Meeting data.MeetingInfo
+	Day     int // 0=Monday through 6=Sunday
+	Column  int // index into Schedule.Days
+	Start   int // minutes from midnight
+	End     int
+
+	// Blocks that overlap share their day column side by side: this one
+	// takes lane Lane of Lanes equal slices.
+	Lane, Lanes int
+	Conflict    bool // overlaps another block
+
+	meeting int // index of Meeting in Section.Meetings
+}
+
+// Label is the course as the catalog prints it, "CS 18000".
+func (b Block) Label() string {
+	return courseLabel(b.Course)
+}
+
+// Instructor is the first instructor of the meeting, or "TBA".
+func (b Block) Instructor() string {
+	if len(b.Meeting.Instructors) > 0 {
+		return b.Meeting.Instructors[0]
+	}
+	return "TBA"
+}
+
+// Location is the building and room, or "" when either is unknown.
+func (b Block) Location() string {
+	if b.Meeting.BuildingCode == "" || b.Meeting.RoomNumber == "" {
+		return ""
+	}
+	return b.Meeting.BuildingCode + " " + b.Meeting.RoomNumber
+}
+
+// ColorKey identifies the block's course for picking a color. Sections
+// without a known course are colored by section.
+func (b Block) ColorKey() string {
+	if b.Course.Id != "" {
+		return b.Course.Id
+	}
+	return b.Section.Id
+}
+
+// Unscheduled is a meeting the grid has no place for: its time is not set,
+// some of its days are not shown, or it falls outside the hours shown.
+type Unscheduled struct {
+	Title   string // "CS 18000 Lecture"
+	Meeting data.MeetingInfo
+	Missing []string // days not shown
+	Outside bool     // entirely before or after the hours shown
+}
+
+// TBA reports whether the meeting has no time to put on a grid.
+func (u Unscheduled) TBA() bool {
+	return len(u.Missing) == 0 && !u.Outside
+}
+
+// Schedule is a laid-out week.
+type Schedule struct {
+	Days        []int // day columns, 0=Monday through 6=Sunday
+	Start, End  int   // visible span in minutes from midnight, on whole hours
+	Blocks      []Block
+	Unscheduled []Unscheduled
+}
+
+// Options adjust a layout. The zero value picks everything from the
+// sections.
+type Options struct {
+	Days []int // day columns; nil means DefaultDays
+
+	// Start and End fix either end of the visible span, in minutes from
+	// midnight on the hour; nil computes it from the blocks.
+	Start, End *int
+}
+
+// Build lays out the sections. courseBySection maps section ids to their
+// courses.
+func Build(sections []data.SectionInfo, courseBySection map[string]data.CourseSummary, opts Options) *Schedule {
+	s := &Schedule{Days: opts.Days}
+	if len(s.Days) == 0 {
+		s.Days = DefaultDays(sections)
+	}
+	for _, sec := range sections {
+		course := courseBySection[sec.Id]
+		title := strings.TrimSpace(courseLabel(course) + " " + sec.Type)
+		if len(sec.Meetings) == 0 {
+			s.Unscheduled = append(s.Unscheduled, Unscheduled{Title: title})
+		}
+		for mi, m := range sec.Meetings {
+			start, ok := ParseTime(m.Start)
+			if !ok || len(m.Days) == 0 || m.DurationMin <= 0 {
+				s.Unscheduled = append(s.Unscheduled, Unscheduled{Title: title, Meeting: m})
+				continue
+			}
+			var missing []string
+			for _, d := range m.Days {
+				day := DayIndex(d)
+				column := slices.Index(s.Days, day)
+				if column < 0 {
+					missing = append(missing, d)
+					continue
+				}
+				s.Blocks = append(s.Blocks, Block{
+					Section: sec,
+					Course:  course,
+					Meeting: m,
+					Day:     day,
+					Column:  column,
+					Start:   start,
+					End:     start + m.DurationMin,
+					meeting: mi,
+				})
+			}
+			if len(missing) > 0 {
+				s.Unscheduled = append(s.Unscheduled, Unscheduled{Title: title, Meeting: m, Missing: missing})
+			}
+		}
+	}
+
+	s.Start, s.End = span(s.Blocks)
+	if opts.Start != nil || opts.End != nil {
+		s.fixSpan(opts.Start, opts.End)
+	}
+	assignLanes(s.Blocks)
+	return s
+}
+
+// fixSpan applies the requested ends of the span, keeping at least an hour
+// visible, and moves meetings that end up entirely out of view to
+// Unscheduled.
+func (s *Schedule) fixSpan(start, end *int) {
+	switch {
+	case start != nil && end != nil:
+		s.Start, s.End = *start, max(*end, *start+60)
+	case start != nil:
+		s.Start = *start
+		if s.End-s.Start < 4*60 {
+			s.End = min(s.Start+4*60, 24*60)
+		}
+	default:
+		s.End = *end
+		if s.End-s.Start < 4*60 {
+			s.Start = max(s.End-4*60, 0)
+		}
+	}
+
+	type key struct {
+		section string
+		meeting int
+	}
+	outside := make(map[key]bool)
+	blocks := s.Blocks[:0]
+	for _, b := range s.Blocks {
+		if b.End > s.Start && b.Start < s.End {
+			blocks = append(blocks, b)
+			continue
+		}
+		k := key{b.Section.Id, b.meeting}
+		if !outside[k] {
+			outside[k] = true
+			s.Unscheduled = append(s.Unscheduled, Unscheduled{
+				Title:   strings.TrimSpace(b.Label() + " " + b.Section.Type),
+				Meeting: b.Meeting,
+				Outside: true,
+			})
+		}
+	}
+	s.Blocks = blocks
+}
+
+// Window is the part of the schedule from start to end, for drawing a long
+// day over several pages. Blocks that overlap it keep their lanes; Place
+// cuts them off at its ends.
+func (s *Schedule) Window(start, end int) *Schedule {
+	w := &Schedule{Days: s.Days, Start: start, End: end, Unscheduled: s.Unscheduled}
+	for _, b := range s.Blocks {
+		if b.End > start && b.Start < end {
+			w.Blocks = append(w.Blocks, b)
+		}
+	}
+	return w
+}
+
+// Hours is the number of hour rows in the visible span.
+func (s *Schedule) Hours() int {
+	return (s.End - s.Start + 59) / 60
+}
+
+// Y is how far down the grid body a time falls, from 0 at Start to 1 at End.
+func (s *Schedule) Y(minute int) float64 {
+	return float64(minute-s.Start) / float64(s.End-s.Start)
+}
+
+// Place returns where a block goes in the grid body, as fractions of its
+// width (across all day columns) and height. Blocks that run past either
+// end of the span are cut off there.
+func (s *Schedule) Place(b Block) (x, y, w, h float64) {
+	column := 1 / float64(len(s.Days))
+	w = column / float64(max(b.Lanes, 1))
+	x = float64(b.Column)*column + float64(b.Lane)*w
+	y = max(s.Y(b.Start), 0)
+	h = min(s.Y(b.End), 1) - y
+	return x, y, w, h
+}
+
+// span picks the visible hours: from the hour before the earliest block
+// starts to the hour after the latest ends, keeping at least half an hour
+// clear on either side and showing at least four hours. An empty schedule
+// shows 8 AM to 5 PM.
+func span(blocks []Block) (start, end int) {
+	if len(blocks) == 0 {
+		return 8 * 60, 17 * 60
+	}
+	start, end = 24*60, 0
+	for _, b := range blocks {
+		start = min(start, b.Start)
+		end = max(end, b.End)
+	}
+	start = max((start-30)/60*60, 0)
+	end = min((end+30+59)/60*60, 24*60)
+	if end-start < 4*60 {
+		end = min(start+4*60, 24*60)
+		start = end - 4*60
+	}
+	return start, end
+}
+
+func courseLabel(c data.CourseSummary) string {
+	if label := data.CourseLabel(nil, c); label != "" {
+		return label
+	}
+	return "Unknown course"
+}
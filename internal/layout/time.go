@@ -0,0 +1,10 @@
+package layout
+
+import "purdue_schedule/internal/data"
+
+// ParseTime reads a clock time as minutes from midnight, as
+// data.ParseClock does, so the grid places meetings where the conflict
+// checks and exports time them.
+func ParseTime(s string) (int, bool) {
+	return data.ParseClock(s)
+}
@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+
+	"purdue_schedule/internal/auth"
+	"purdue_schedule/internal/data"
+)
+
+// CheckSchedules records an alert for every section of a saved schedule
+// that d removed or changed, then delivers pending alerts to users who
+// asked for them. It returns how many alerts were recorded.
+func (w *Watcher) CheckSchedules(d data.Changeset) (int, error) {
+	if len(d.SectionsRemoved) == 0 && len(d.SectionsChanged) == 0 {
+		return 0, nil
+	}
+	removed := make(map[string]data.SectionRef, len(d.SectionsRemoved))
+	for _, ref := range d.SectionsRemoved {
+		removed[ref.Id] = ref
+	}
+	changed := make(map[string]data.SectionChange, len(d.SectionsChanged))
+	for _, sc := range d.SectionsChanged {
+		changed[sc.Id] = sc
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	users, err := w.accounts.Users()
+	if err != nil {
+		return 0, err
+	}
+	recorded := 0
+	for _, u := range users {
+		if u.Disabled || len(u.Schedules) == 0 {
+			continue
+		}
+		// A section saved in several schedules gets one alert naming them all
+		var order []string
+		holders := make(map[string][]string)
+		for _, sc := range u.Schedules {
+			for _, s := range sc.Sections {
+				if _, ok := holders[s.Id]; !ok {
+					order = append(order, s.Id)
+				}
+				holders[s.Id] = append(holders[s.Id], sc.Name)
+			}
+		}
+		var alerts []auth.Alert
+		for _, id := range order {
+			if ref, ok := removed[id]; ok {
+				alerts = append(alerts, auth.Alert{
+					SectionId: id,
+					Crn:       ref.Crn,
+					Course:    ref.Course,
+					Schedules: holders[id],
+					Field:     auth.AlertRemoved,
+					Message:   fmt.Sprintf("Your %s was removed from the catalog.", ref.Label()),
+				})
+			}
+			if sc, ok := changed[id]; ok {
+				for _, fc := range sc.Changes {
+					alerts = append(alerts, auth.Alert{
+						SectionId: id,
+						Crn:       sc.Crn,
+						Course:    sc.Course,
+						Schedules: holders[id],
+						Field:     fc.Field,
+						Old:       fc.Old,
+						New:       fc.New,
+						Message:   fmt.Sprintf("Your %s %s.", sc.Label(), fc.Sentence()),
+					})
+				}
+			}
+		}
+		if len(alerts) == 0 {
+			continue
+		}
+		if err := w.accounts.AddAlerts(u, alerts); err != nil {
+			log.Printf("notify: record alerts for %s: %v", u.Id, err)
+			continue
+		}
+		recorded += len(alerts)
+	}
+	w.deliverAlerts()
+	return recorded, nil
+}
+
+// deliverAlerts sends pending alerts to users outside their quiet hours.
+// Alerts stay pending when every channel fails. The caller holds w.mu.
+func (w *Watcher) deliverAlerts() {
+	users, err := w.accounts.Users()
+	if err != nil {
+		log.Printf("notify: deliver alerts: %v", err)
+		return
+	}
+	now := w.now()
+	for _, u := range users {
+		if u.Disabled || !u.IsVerified || !u.Notify.ScheduleAlerts || auth.InQuietHours(u.Notify, now) {
+			continue
+		}
+		n := Notification{Time: now}
+		var ids []string
+		for _, a := range u.Alerts {
+			if a.Pending {
+				n.Alerts = append(n.Alerts, a)
+				n.Lines = append(n.Lines, a.Message)
+				ids = append(ids, a.Id)
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		n.Subject = "A section in your schedule changed"
+		if len(ids) > 1 {
+			n.Subject = fmt.Sprintf("%d changes to sections in your schedules", len(ids))
+		}
+		if !w.deliver(u, n) {
+			continue
+		}
+		if err := w.accounts.AlertsDelivered(u, ids); err != nil {
+			log.Printf("notify: mark alerts delivered for %s: %v", u.Id, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"purdue_schedule/internal/auth"
+	"purdue_schedule/internal/data"
+)
+
+func TestCheckSchedulesAlerts(t *testing.T) {
+	e := newEnv(t)
+	h := newHook(t)
+	lecture := auth.SavedSection{Id: "s1", Crn: "12345", Course: "CS 18000", Type: "Lecture"}
+	lab := auth.SavedSection{Id: "s2", Crn: "12346", Course: "CS 18000", Type: "Laboratory"}
+	save := func(u *auth.User, name string, sections ...auth.SavedSection) {
+		t.Helper()
+		if _, err := e.svc.CreateSchedule(u, name, "Fall 2025", "", "", false, sections); err != nil {
+			t.Fatalf("CreateSchedule: %v", err)
+		}
+	}
+	// Delivered by webhook at once
+	awake := e.user(t, "pete@purdue.edu", auth.NotifySettings{Channels: []string{auth.ChannelWebhook}, WebhookURL: h.URL, ScheduleAlerts: true})
+	save(awake, "Plan A", lecture, lab)
+	save(awake, "Plan B", lecture)
+	// Delivered by email once quiet hours are over
+	asleep := e.user(t, "night@purdue.edu", auth.NotifySettings{QuietStart: "11:00", QuietEnd: "13:00", TimeZone: "UTC", ScheduleAlerts: true})
+	save(asleep, "Only", lecture)
+	// Listed in the app only
+	quiet := e.user(t, "quiet@purdue.edu", auth.NotifySettings{})
+	save(quiet, "Mine", lab)
+
+	d := data.Changeset{
+		SectionsRemoved: []data.SectionRef{{Id: "s2", Crn: "12346", Course: "CS 18000", Type: "Laboratory"}},
+		SectionsChanged: []data.SectionChange{{
+			SectionRef: data.SectionRef{Id: "s1", Crn: "12345", Course: "CS 18000", Type: "Lecture"},
+			Changes:    []data.FieldChange{{Field: data.FieldTime, Meeting: 0, Old: "09:30-10:20", New: "10:30-11:20"}},
+		}},
+	}
+	n, err := e.w.CheckSchedules(d)
+	if err != nil {
+		t.Fatalf("CheckSchedules: %v", err)
+	}
+	// pete: the lecture's time and the lab; night: the lecture; quiet: the lab
+	if n != 4 {
+		t.Errorf("alerts recorded: got %d, want 4", n)
+	}
+
+	got := h.payloads()
+	if len(got) != 1 {
+		t.Fatalf("webhook posts: got %d, want 1", len(got))
+	}
+	p := got[0]
+	if p.Subject != "2 changes to sections in your schedules" || len(p.Alerts) != 2 {
+		t.Fatalf("webhook payload: got %+v", p)
+	}
+	moved, removed := p.Alerts[0], p.Alerts[1]
+	if moved.Field != data.FieldTime || moved.Message != "Your CS 18000 Lecture (CRN 12345) now meets 10:30-11:20 instead of 09:30-10:20." ||
+		!slices.Equal(moved.Schedules, []string{"Plan A", "Plan B"}) {
+		t.Errorf("time alert: got %+v", moved)
+	}
+	if removed.Field != auth.AlertRemoved || removed.Message != "Your CS 18000 Laboratory (CRN 12346) was removed from the catalog." ||
+		!slices.Equal(removed.Schedules, []string{"Plan A"}) {
+		t.Errorf("removed alert: got %+v", removed)
+	}
+
+	pending := func(email string) int {
+		t.Helper()
+		u, err := e.svc.User(email)
+		if err != nil {
+			t.Fatalf("User: %v", err)
+		}
+		n := 0
+		for _, a := range u.Alerts {
+			if a.Pending {
+				n++
+			}
+		}
+		return n
+	}
+	if p := pending(awake.Email); p != 0 {
+		t.Errorf("pending alerts after delivery: got %d, want 0", p)
+	}
+	if p := pending(asleep.Email); p != 1 {
+		t.Errorf("pending alerts in quiet hours: got %d, want 1", p)
+	}
+	if p := pending(quiet.Email); p != 0 {
+		t.Errorf("pending alerts without scheduleAlerts: got %d, want 0", p)
+	}
+	if n := len(e.mail.mails()); n != 0 {
+		t.Fatalf("mails during quiet hours: got %d, want 0", n)
+	}
+
+	// The periodic check delivers what quiet hours held back, once
+	e.now = e.now.Add(90 * time.Minute)
+	for range 2 {
+		e.w.mu.Lock()
+		e.w.deliverAlerts()
+		e.w.mu.Unlock()
+	}
+	mails := e.mail.mails()
+	if len(mails) != 1 || mails[0].to != asleep.Email || mails[0].subject != "A section in your schedule changed" {
+		t.Fatalf("mails after quiet hours: got %+v", mails)
+	}
+	if p := pending(asleep.Email); p != 0 {
+		t.Errorf("pending alerts after quiet hours: got %d, want 0", p)
+	}
+	if n := len(h.payloads()); n != 1 {
+		t.Errorf("webhook posts: got %d, want no repeat of delivered alerts", n)
+	}
+}
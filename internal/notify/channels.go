@@ -0,0 +1,190 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+
+	"purdue_schedule/internal/auth"
+)
+
+// Channel delivers a notification to one user. Implementations must be safe
+// for concurrent use.
+type Channel interface {
+	Notify(u *auth.User, n Notification) error
+}
+
+// Email sends notifications as plain-text mail, through the same Mailer
+// used for verification codes.
+type Email struct {
+	Mailer auth.Mailer
+}
+
+func (e Email) Notify(u *auth.User, n Notification) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hi %s,\n\n", u.Name)
+	for _, line := range n.Lines {
+		fmt.Fprintf(&b, "- %s\n", line)
+	}
+	if len(n.Events) > 0 {
+		b.WriteString("\nRegister soon: seats can go quickly.")
+	}
+	b.WriteString("\nManage notifications on BoilerSchedule.\n")
+	return e.Mailer.Send(u.Email, n.Subject, b.String())
+}
+
+// Webhook POSTs notifications as JSON to the URL in the user's settings,
+// e.g. a chat integration or a local stand-in during tests.
+type Webhook struct {
+	Client *http.Client // nil uses a client with a 10 second timeout
+	// AllowPrivate lets the default client connect to loopback, private and
+	// link-local addresses. Without it they are refused at connect time, so
+	// neither a redirect nor a DNS change can reach them.
+	AllowPrivate bool
+}
+
+var defaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// publicClient is defaultClient refusing connections to private addresses.
+// It goes direct: through a proxy the address it checks would be the proxy's.
+var publicClient = func() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || PrivateAddr(ip) {
+				return fmt.Errorf("webhook address %s is not public", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: 10 * time.Second, Transport: transport}
+}()
+
+// cgnat is the carrier-grade NAT range, shared address space that is not
+// reachable from the internet either.
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// PrivateAddr reports whether ip is loopback, private, link-local,
+// unspecified or multicast: anything a webhook must not reach unless the
+// operator allows it.
+func PrivateAddr(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return true
+	}
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() || cgnat.Contains(addr)
+}
+
+// PublicHost reports whether host names only public addresses. A name that
+// does not resolve is refused too. Delivery checks the address it connects
+// to again, since DNS can change after the settings are saved.
+func PublicHost(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return !PrivateAddr(ip)
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil || len(ips) == 0 {
+		return false
+	}
+	for _, ip := range ips {
+		if PrivateAddr(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// webhookPayload is the body posted to webhooks.
+type webhookPayload struct {
+	Subject string         `json:"subject"`
+	Text    string         `json:"text"` // one line per event or alert, for chat integrations
+	User    string         `json:"user"`
+	Events  []Event        `json:"events"`
+	Alerts  []webhookAlert `json:"alerts"`
+	SentAt  time.Time      `json:"sentAt"`
+}
+
+// webhookAlert is a schedule alert as posted to webhooks.
+type webhookAlert struct {
+	Id        string   `json:"id"`
+	SectionId string   `json:"sectionId"`
+	Crn       string   `json:"crn"`
+	Course    string   `json:"course"`
+	Schedules []string `json:"schedules"`
+	Field     string   `json:"field"`
+	Old       string   `json:"old"`
+	New       string   `json:"new"`
+	Message   string   `json:"message"`
+}
+
+func (wh Webhook) Notify(u *auth.User, n Notification) error {
+	if u.Notify.WebhookURL == "" {
+		return fmt.Errorf("no webhook URL set")
+	}
+	payload := webhookPayload{
+		Subject: n.Subject,
+		Text:    strings.Join(n.Lines, "\n"),
+		User:    u.Email,
+		Events:  n.Events,
+		Alerts:  make([]webhookAlert, 0, len(n.Alerts)),
+		SentAt:  n.Time,
+	}
+	if payload.Events == nil {
+		payload.Events = []Event{}
+	}
+	for _, a := range n.Alerts {
+		payload.Alerts = append(payload.Alerts, webhookAlert{
+			Id:        a.Id,
+			SectionId: a.SectionId,
+			Crn:       a.Crn,
+			Course:    a.Course,
+			Schedules: a.Schedules,
+			Field:     a.Field,
+			Old:       a.Old,
+			New:       a.New,
+			Message:   a.Message,
+		})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	client := wh.Client
+	switch {
+	case client != nil:
+	case wh.AllowPrivate:
+		client = defaultClient
+	default:
+		client = publicClient
+	}
+	req, err := http.NewRequest(http.MethodPost, u.Notify.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "BoilerSchedule-Notify")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+// Package notify tells users when sections they watch gain open seats or
+// their waitlist moves, and when a reload changes sections in their saved
+// schedules. Checks run against a freshly loaded dataset and deliver
+// through pluggable channels (email, webhook).
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"purdue_schedule/internal/auth"
+	"purdue_schedule/internal/data"
+)
+
+// DedupWindow is how long an identical event for the same watch is
+// suppressed, so seats flapping between two refreshes notify once.
+const DedupWindow = 6 * time.Hour
+
+// Event kinds
+const (
+	SeatsOpen     = "seats_open"
+	WaitlistMoved = "waitlist_moved"
+)
+
+// Event is one watched section's change.
+type Event struct {
+	Kind              string `json:"kind"`
+	SectionId         string `json:"sectionId"`
+	Crn               string `json:"crn"`
+	Course            string `json:"course"`
+	Remaining         int    `json:"remaining"`
+	WaitlistCount     int    `json:"waitlistCount"`
+	PrevRemaining     int    `json:"prevRemaining"`
+	PrevWaitlistCount int    `json:"prevWaitlistCount"`
+}
+
+// Summary describes the event in one line.
+func (e Event) Summary() string {
+	label := fmt.Sprintf("%s (CRN %s)", e.Course, e.Crn)
+	switch e.Kind {
+	case SeatsOpen:
+		return fmt.Sprintf("%s: %d %s open", label, e.Remaining, plural(e.Remaining, "seat", "seats"))
+	default:
+		return fmt.Sprintf("%s: waitlist moved from %d to %d", label, e.PrevWaitlistCount, e.WaitlistCount)
+	}
+}
+
+// key identifies the event for de-duplication.
+func (e Event) key() string {
+	return fmt.Sprintf("%s:%d:%d", e.Kind, e.Remaining, e.WaitlistCount)
+}
+
+// Notification is everything sent to one user after one check.
+type Notification struct {
+	Subject string
+	Lines   []string // one per event or alert, for plain-text channels
+	Events  []Event
+	Alerts  []auth.Alert
+	Time    time.Time
+}
+
+// Watcher compares watched sections against new datasets.
+type Watcher struct {
+	accounts *auth.Service
+	channels map[string]Channel
+	now      func() time.Time
+
+	mu sync.Mutex // one check at a time, so nothing is sent twice
+}
+
+// NewWatcher delivers through channels, keyed by auth.ChannelEmail and
+// auth.ChannelWebhook. A channel missing from the map is skipped.
+func NewWatcher(accounts *auth.Service, channels map[string]Channel) *Watcher {
+	return &Watcher{accounts: accounts, channels: channels, now: time.Now}
+}
+
+// compare reports the event, if any, between a watch's baseline and the
+// current counts, and whether the counts changed at all.
+func compare(w auth.Watch, cur data.Seats) (*Event, bool) {
+	ev := Event{
+		SectionId:         w.SectionId,
+		Crn:               w.Crn,
+		Course:            w.Course,
+		Remaining:         cur.Remaining,
+		WaitlistCount:     cur.WaitlistCount,
+		PrevRemaining:     w.Remaining,
+		PrevWaitlistCount: w.WaitlistCount,
+	}
+	changed := cur.Remaining != w.Remaining || cur.WaitlistCount != w.WaitlistCount
+	switch {
+	case w.Remaining <= 0 && cur.Remaining > 0:
+		ev.Kind = SeatsOpen
+	case cur.WaitlistCount < w.WaitlistCount:
+		ev.Kind = WaitlistMoved
+	default:
+		return nil, changed
+	}
+	return &ev, true
+}
+
+// Check compares every user's watches with store and notifies them of seats
+// that opened or waitlists that moved. Users in their quiet hours are
+// skipped without moving their baseline, so a later check reports the
+// change once they are over. It returns how many users were notified.
+func (w *Watcher) Check(store *data.Store) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	users, err := w.accounts.Users()
+	if err != nil {
+		return 0, err
+	}
+	now := w.now()
+	sent := 0
+	for _, u := range users {
+		if u.Disabled || !u.IsVerified || len(u.Watches) == 0 {
+			continue
+		}
+		quiet := auth.InQuietHours(u.Notify, now)
+		var events []Event
+		for _, wt := range u.Watches {
+			sec, ok := store.SectionById(wt.SectionId)
+			if !ok || sec.Seats == nil {
+				continue
+			}
+			ev, changed := compare(wt, *sec.Seats)
+			switch {
+			case ev == nil && changed, ev != nil && ev.key() == wt.LastEvent && now.Sub(wt.NotifiedAt) < DedupWindow:
+				// Nothing to report; follow the counts so the next change
+				// is measured from here
+				w.settle(u, wt.SectionId, *sec.Seats, "", time.Time{})
+			case ev != nil && !quiet:
+				events = append(events, *ev)
+			}
+		}
+		if len(events) == 0 {
+			continue
+		}
+		n := Notification{Subject: subject(events), Events: events, Time: now}
+		for _, ev := range events {
+			n.Lines = append(n.Lines, ev.Summary())
+		}
+		if !w.deliver(u, n) {
+			// Baselines stay put, so the next check tries again
+			continue
+		}
+		for _, ev := range events {
+			w.settle(u, ev.SectionId, data.Seats{Remaining: ev.Remaining, WaitlistCount: ev.WaitlistCount}, ev.key(), now)
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// settle moves a watch's baseline to cur. A non-empty key records a
+// notification sent at at.
+func (w *Watcher) settle(u *auth.User, sectionId string, cur data.Seats, key string, at time.Time) {
+	err := w.accounts.UpdateWatch(u, sectionId, func(wt *auth.Watch) {
+		wt.Remaining = cur.Remaining
+		wt.WaitlistCount = cur.WaitlistCount
+		if key != "" {
+			wt.LastEvent = key
+			wt.NotifiedAt = at
+		}
+	})
+	if err != nil {
+		log.Printf("notify: update watch %s for %s: %v", sectionId, u.Id, err)
+	}
+}
+
+// deliver sends n on each of the user's channels. It reports whether any
+// channel succeeded.
+func (w *Watcher) deliver(u *auth.User, n Notification) bool {
+	ok := false
+	for _, name := range auth.NotifyChannels(u.Notify) {
+		ch := w.channels[name]
+		if ch == nil {
+			continue
+		}
+		if err := ch.Notify(u, n); err != nil {
+			log.Printf("notify: %s to %s failed: %v", name, u.Id, err)
+			continue
+		}
+		ok = true
+	}
+	return ok
+}
+
+// Run re-checks the served store and pending alerts every interval until
+// ctx is done, which delivers notifications held back by quiet hours or
+// failed channels.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration, current func() *data.Store) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if _, err := w.Check(current()); err != nil {
+			log.Printf("notify: check failed: %v", err)
+		}
+		w.mu.Lock()
+		w.deliverAlerts()
+		w.mu.Unlock()
+	}
+}
+
+func subject(events []Event) string {
+	if len(events) > 1 {
+		return fmt.Sprintf("%d watched sections changed", len(events))
+	}
+	if events[0].Kind == SeatsOpen {
+		return "Seats open in " + events[0].Course
+	}
+	return "Waitlist moved in " + events[0].Course
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
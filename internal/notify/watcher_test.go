@@ -0,0 +1,287 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"purdue_schedule/internal/auth"
+	"purdue_schedule/internal/data"
+	"purdue_schedule/internal/storage"
+)
+
+// outbox is a Mailer that records what it is asked to send.
+type outbox struct {
+	mu   sync.Mutex
+	sent []mail
+}
+
+type mail struct{ to, subject, body string }
+
+func (o *outbox) Send(to, subject, body string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.sent = append(o.sent, mail{to, subject, body})
+	return nil
+}
+
+func (o *outbox) mails() []mail {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]mail{}, o.sent...)
+}
+
+// hook is a local webhook stand-in answering with status.
+type hook struct {
+	URL string
+
+	mu     sync.Mutex
+	status int
+	got    []webhookPayload
+}
+
+func newHook(t *testing.T) *hook {
+	t.Helper()
+	h := &hook{status: http.StatusNoContent}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p webhookPayload
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("webhook request: got %s with %q, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("webhook body: %v", err)
+		}
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.got = append(h.got, p)
+		w.WriteHeader(h.status)
+	}))
+	t.Cleanup(srv.Close)
+	h.URL = srv.URL
+	return h
+}
+
+func (h *hook) payloads() []webhookPayload {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]webhookPayload{}, h.got...)
+}
+
+func (h *hook) respond(status int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.status = status
+}
+
+type env struct {
+	w    *Watcher
+	svc  *auth.Service
+	mail *outbox
+	now  time.Time
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	e := &env{mail: &outbox{}, now: time.Date(2025, 8, 25, 12, 0, 0, 0, time.UTC)}
+	// Verification codes go elsewhere; e.mail sees only notifications
+	e.svc = auth.NewService(store, &outbox{})
+	e.svc.SetClock(func() time.Time { return e.now })
+	e.w = NewWatcher(e.svc, map[string]Channel{
+		auth.ChannelEmail:   Email{Mailer: e.mail},
+		auth.ChannelWebhook: Webhook{AllowPrivate: true},
+	})
+	e.w.now = func() time.Time { return e.now }
+	return e
+}
+
+// user saves a verified account with the given notification settings.
+func (e *env) user(t *testing.T, email string, n auth.NotifySettings) *auth.User {
+	t.Helper()
+	u, err := e.svc.Signup(email, "Pete", "boilerup1")
+	if err != nil {
+		t.Fatalf("Signup: %v", err)
+	}
+	if u, err = e.svc.MarkVerified(u.Email); err != nil {
+		t.Fatalf("MarkVerified: %v", err)
+	}
+	if _, err := e.svc.SetNotifySettings(u, n); err != nil {
+		t.Fatalf("SetNotifySettings: %v", err)
+	}
+	return u
+}
+
+func (e *env) watch(t *testing.T, u *auth.User, remaining, waitlist int) {
+	t.Helper()
+	_, err := e.svc.AddWatch(u, auth.Watch{SectionId: "s1", Crn: "12345", Course: "CS 18000", Remaining: remaining, WaitlistCount: waitlist})
+	if err != nil {
+		t.Fatalf("AddWatch: %v", err)
+	}
+}
+
+func (e *env) check(t *testing.T, store *data.Store, want int) {
+	t.Helper()
+	n, err := e.w.Check(store)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if n != want {
+		t.Fatalf("Check at %s: notified %d users, want %d", e.now.Format(time.Kitchen), n, want)
+	}
+}
+
+// seatsStore loads a one-section catalog in which section s1 has remaining
+// of its 30 seats open and waitlist people waiting.
+func seatsStore(t *testing.T, remaining, waitlist int) *data.Store {
+	t.Helper()
+	raw := fmt.Sprintf(`[{"Id":"c1","Number":"18000","SubjectId":"cs","Title":"Problem Solving And Object-Oriented Programming",
+		"Classes":[{"Id":"k1","CourseId":"c1","TermId":"t","CampusId":"wl","Sections":[{"Id":"s1","Crn":"12345","ClassId":"k1","Type":"Lecture",
+		"Capacity":30,"Enrolled":%d,"RemainingSpace":%d,"WaitListCapacity":10,"WaitListCount":%d}]}]}]`, 30-remaining, remaining, waitlist)
+	path := filepath.Join(t.TempDir(), "courses.json")
+	if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := data.LoadStore(path)
+	if err != nil {
+		t.Fatalf("LoadStore: %v", err)
+	}
+	return store
+}
+
+func TestCheckDeliversByEmailAndWebhook(t *testing.T) {
+	e := newEnv(t)
+	h := newHook(t)
+	u := e.user(t, "pete@purdue.edu", auth.NotifySettings{Channels: []string{auth.ChannelEmail, auth.ChannelWebhook}, WebhookURL: h.URL})
+	e.watch(t, u, 0, 4)
+
+	e.check(t, seatsStore(t, 0, 4), 0)
+	e.check(t, seatsStore(t, 3, 4), 1)
+
+	mails := e.mail.mails()
+	if len(mails) != 1 {
+		t.Fatalf("mails: got %d, want 1", len(mails))
+	}
+	if m := mails[0]; m.to != u.Email || m.subject != "Seats open in CS 18000" || !strings.Contains(m.body, "- CS 18000 (CRN 12345): 3 seats open\n") {
+		t.Errorf("mail: got %+v", m)
+	}
+	got := h.payloads()
+	if len(got) != 1 {
+		t.Fatalf("webhook posts: got %d, want 1", len(got))
+	}
+	p := got[0]
+	if p.User != u.Email || len(p.Events) != 1 || p.Text != "CS 18000 (CRN 12345): 3 seats open" || !p.SentAt.Equal(e.now) {
+		t.Errorf("webhook payload: got %+v", p)
+	}
+	if ev := p.Events[0]; ev.Kind != SeatsOpen || ev.Remaining != 3 || ev.PrevRemaining != 0 || ev.WaitlistCount != 4 {
+		t.Errorf("webhook event: got %+v", ev)
+	}
+
+	// The baseline moved, so the same counts are not reported again
+	e.now = e.now.Add(time.Hour)
+	e.check(t, seatsStore(t, 3, 4), 0)
+	e.check(t, seatsStore(t, 3, 2), 1)
+	if p := h.payloads()[1]; len(p.Events) != 1 || p.Events[0].Kind != WaitlistMoved || p.Events[0].PrevWaitlistCount != 4 {
+		t.Errorf("waitlist event: got %+v", p.Events)
+	}
+}
+
+func TestCheckRetriesFailedWebhook(t *testing.T) {
+	e := newEnv(t)
+	h := newHook(t)
+	u := e.user(t, "pete@purdue.edu", auth.NotifySettings{Channels: []string{auth.ChannelWebhook}, WebhookURL: h.URL})
+	e.watch(t, u, 0, 0)
+
+	h.respond(http.StatusInternalServerError)
+	e.check(t, seatsStore(t, 2, 0), 0)
+	h.respond(http.StatusOK)
+	e.check(t, seatsStore(t, 2, 0), 1)
+	if n := len(h.payloads()); n != 2 {
+		t.Errorf("webhook posts: got %d, want the failed one and its retry", n)
+	}
+	if n := len(e.mail.mails()); n != 0 {
+		t.Errorf("mails: got %d, want none for a webhook-only user", n)
+	}
+}
+
+func TestCheckDedup(t *testing.T) {
+	e := newEnv(t)
+	u := e.user(t, "pete@purdue.edu", auth.NotifySettings{})
+	e.watch(t, u, 0, 0)
+
+	e.check(t, seatsStore(t, 3, 0), 1)
+	// Seats flap closed and open again: the same event is not repeated
+	e.now = e.now.Add(time.Hour)
+	e.check(t, seatsStore(t, 0, 0), 0)
+	e.now = e.now.Add(time.Hour)
+	e.check(t, seatsStore(t, 3, 0), 0)
+	// A different count is a new event
+	e.check(t, seatsStore(t, 0, 0), 0)
+	e.check(t, seatsStore(t, 5, 0), 1)
+	e.check(t, seatsStore(t, 0, 0), 0)
+	e.check(t, seatsStore(t, 5, 0), 0)
+	// Once the window has passed it is reported again
+	e.now = e.now.Add(DedupWindow)
+	e.check(t, seatsStore(t, 0, 0), 0)
+	e.check(t, seatsStore(t, 5, 0), 1)
+
+	if n := len(e.mail.mails()); n != 3 {
+		t.Errorf("mails: got %d, want 3", n)
+	}
+}
+
+func TestCheckHoldsDuringQuietHours(t *testing.T) {
+	e := newEnv(t)
+	u := e.user(t, "pete@purdue.edu", auth.NotifySettings{QuietStart: "22:00", QuietEnd: "07:00", TimeZone: "UTC"})
+	e.watch(t, u, 0, 0)
+
+	e.now = time.Date(2025, 8, 25, 23, 0, 0, 0, time.UTC)
+	e.check(t, seatsStore(t, 4, 0), 0)
+	e.now = e.now.Add(3 * time.Hour)
+	e.check(t, seatsStore(t, 4, 0), 0)
+	if n := len(e.mail.mails()); n != 0 {
+		t.Fatalf("mails during quiet hours: got %d, want 0", n)
+	}
+	// 07:30: the held change is reported from the old baseline
+	e.now = e.now.Add(5*time.Hour + 30*time.Minute)
+	e.check(t, seatsStore(t, 4, 0), 1)
+	if mails := e.mail.mails(); len(mails) != 1 || !strings.Contains(mails[0].body, "4 seats open") {
+		t.Errorf("mail after quiet hours: got %+v", mails)
+	}
+}
+
+func TestWebhookRefusesPrivateAddresses(t *testing.T) {
+	h := newHook(t)
+	u := &auth.User{Email: "pete@purdue.edu", Notify: auth.NotifySettings{WebhookURL: h.URL}}
+	n := Notification{Subject: "Seats open in CS 18000", Time: time.Now()}
+	if err := (Webhook{}).Notify(u, n); err == nil {
+		t.Error("webhook to a loopback address was delivered without AllowPrivate")
+	}
+	if got := h.payloads(); len(got) != 0 {
+		t.Errorf("webhook posts: got %d, want none", len(got))
+	}
+	if err := (Webhook{AllowPrivate: true}).Notify(u, n); err != nil {
+		t.Errorf("webhook with AllowPrivate: %v", err)
+	}
+
+	for ip, want := range map[string]bool{
+		"127.0.0.1": true, "10.1.2.3": true, "172.16.0.1": true, "192.168.1.1": true, "169.254.169.254": true,
+		"100.64.0.1": true, "0.0.0.0": true, "::1": true, "fe80::1": true, "::ffff:127.0.0.1": true,
+		"128.210.7.200": false, "8.8.8.8": false, "2001:4860:4860::8888": false,
+	} {
+		if got := PrivateAddr(net.ParseIP(ip)); got != want {
+			t.Errorf("PrivateAddr(%s): got %v, want %v", ip, got, want)
+		}
+	}
+}
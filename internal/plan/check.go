@@ -0,0 +1,286 @@
+package plan
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"purdue_schedule/internal/auth"
+	"purdue_schedule/internal/data"
+)
+
+// Finding codes. Severities are those of data.Finding.
+const (
+	CodePrereqMissing    = "prereq_missing"    // no earlier term or completed course meets a requirement
+	CodePrereqConcurrent = "prereq_concurrent" // met only by a course in the same term
+	CodeDuplicateCourse  = "duplicate_course"
+	CodeNotOffered       = "not_offered" // the term's catalog has no such course
+	CodeUnknownSection   = "unknown_section"
+	CodeTermCreditsLow   = "term_credits_below_min"
+	CodeTermCreditsHigh  = "term_credits_above_max"
+	CodeCreditsShort     = "credits_below_target"
+	CodeTermOrder        = "term_order"
+)
+
+// assumedCredits stands in for courses whose credit hours are unknown, as
+// in data.Validate.
+const assumedCredits = 3
+
+// Finding is one problem found in a plan.
+type Finding struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Term     string `json:"term,omitempty"`
+	Course   string `json:"course,omitempty"`
+}
+
+// Report is the outcome of checking a plan.
+type Report struct {
+	Valid            bool           `json:"valid"` // no errors
+	Credits          float64        `json:"credits"`
+	CompletedCredits float64        `json:"completedCredits"`
+	TargetCredits    float64        `json:"targetCredits"`
+	CreditsEstimated bool           `json:"creditsEstimated"`
+	PrereqsChecked   bool           `json:"prereqsChecked"` // false when no prerequisite data is loaded
+	Terms            []TermReport   `json:"terms"`
+	Counts           map[string]int `json:"counts"`
+	Findings         []Finding      `json:"findings"`
+}
+
+// TermReport describes one planned term.
+type TermReport struct {
+	Name             string         `json:"name"`
+	Catalog          bool           `json:"catalog"` // catalog data is loaded for the term
+	Credits          float64        `json:"credits"`
+	CreditsEstimated bool           `json:"creditsEstimated"`
+	MinCredits       float64        `json:"minCredits"`
+	MaxCredits       float64        `json:"maxCredits"`
+	Courses          []CourseReport `json:"courses"`
+}
+
+// CourseReport describes one planned course. CourseId and the section
+// fields are set when the term's catalog has the course, so clients can
+// fetch its sections with /api/course/{id}/sections?term=.
+type CourseReport struct {
+	Course           string             `json:"course"`
+	Title            string             `json:"title,omitempty"`
+	Credits          float64            `json:"credits"`
+	CreditsEstimated bool               `json:"creditsEstimated"`
+	CourseId         string             `json:"courseId,omitempty"`
+	SectionCount     int                `json:"sectionCount"`
+	Sections         []data.SectionInfo `json:"sections"` // the linked sections
+}
+
+// Check checks p term by term. A term's catalog, when loaded, confirms the
+// course is offered and resolves its linked sections; credit hours come
+// from the plan, then that catalog, then any loaded catalog.
+func Check(p auth.Plan, catalogs *data.Catalogs, prereqs Prereqs) Report {
+	r := Report{
+		CompletedCredits: p.CompletedCredits,
+		TargetCredits:    p.TargetCredits,
+		PrereqsChecked:   prereqs != nil,
+		Terms:            []TermReport{},
+		Counts:           map[string]int{data.SeverityError: 0, data.SeverityWarning: 0, data.SeverityInfo: 0},
+		Findings:         []Finding{},
+	}
+	add := func(f Finding) {
+		r.Findings = append(r.Findings, f)
+	}
+
+	const completed = ""
+	taken := make(map[string]string) // course -> term it is planned in
+	for _, c := range p.Completed {
+		taken[c] = completed
+	}
+	prevTerm := ""
+	for _, t := range p.Terms {
+		if prevTerm != "" && !termBefore(prevTerm, t.Name) {
+			add(Finding{Code: CodeTermOrder, Severity: data.SeverityWarning, Term: t.Name,
+				Message: fmt.Sprintf("%s is listed after %s.", t.Name, prevTerm)})
+		}
+		prevTerm = t.Name
+
+		store := catalogs.Store(t.Name)
+		tr := TermReport{Name: t.Name, Catalog: store != nil, Courses: []CourseReport{}}
+		tr.MinCredits, tr.MaxCredits = termLimits(t)
+		this := make(map[string]bool)
+		for _, pc := range t.Courses {
+			cr := CourseReport{Course: pc.Course, Credits: pc.Credits, Sections: []data.SectionInfo{}}
+			if this[pc.Course] {
+				add(Finding{Code: CodeDuplicateCourse, Severity: data.SeverityWarning, Term: t.Name, Course: pc.Course,
+					Message: fmt.Sprintf("%s is planned twice in %s.", pc.Course, t.Name)})
+			} else if when, ok := taken[pc.Course]; ok {
+				already := "already planned in " + when
+				if when == completed {
+					already = "already completed"
+				}
+				add(Finding{Code: CodeDuplicateCourse, Severity: data.SeverityWarning, Term: t.Name, Course: pc.Course,
+					Message: fmt.Sprintf("%s is planned in %s but %s.", pc.Course, t.Name, already)})
+			}
+			this[pc.Course] = true
+
+			if store != nil {
+				if c, ok := store.FindCourse(pc.Course); ok {
+					cr.CourseId = c.Id
+					cr.Title = c.Title
+					cr.SectionCount = len(store.SectionsByCourse(c.Id))
+					if cr.Credits == 0 {
+						cr.Credits = c.Credits
+					}
+					for _, id := range pc.Sections {
+						owner, ok := store.CourseBySectionId(id)
+						if !ok || owner.Id != c.Id {
+							add(Finding{Code: CodeUnknownSection, Severity: data.SeverityError, Term: t.Name, Course: pc.Course,
+								Message: fmt.Sprintf("Section %s is not a section of %s in %s.", id, pc.Course, t.Name)})
+							continue
+						}
+						sec, _ := store.SectionById(id)
+						cr.Sections = append(cr.Sections, sec)
+					}
+				} else {
+					add(Finding{Code: CodeNotOffered, Severity: data.SeverityWarning, Term: t.Name, Course: pc.Course,
+						Message: fmt.Sprintf("%s is not in the %s catalog.", pc.Course, t.Name)})
+				}
+			}
+			if cr.Credits == 0 {
+				cr.Title, cr.Credits = catalogCredits(catalogs, pc.Course, cr.Title)
+			}
+			if cr.Credits == 0 {
+				cr.Credits = assumedCredits
+				cr.CreditsEstimated = true
+				tr.CreditsEstimated = true
+			}
+			tr.Credits += cr.Credits
+			tr.Courses = append(tr.Courses, cr)
+		}
+
+		// Prerequisites are checked once the whole term is known, so a
+		// requirement met in the same term is told apart from a missing one
+		checked := make(map[string]bool)
+		for _, pc := range t.Courses {
+			if checked[pc.Course] {
+				continue
+			}
+			checked[pc.Course] = true
+			for _, group := range prereqs[pc.Course] {
+				if slices.ContainsFunc(group, func(c string) bool { _, ok := taken[c]; return ok }) {
+					continue
+				}
+				if slices.ContainsFunc(group, func(c string) bool { return this[c] }) {
+					add(Finding{Code: CodePrereqConcurrent, Severity: data.SeverityWarning, Term: t.Name, Course: pc.Course,
+						Message: fmt.Sprintf("%s and its prerequisite %s are both planned in %s.", pc.Course, alternatives(group), t.Name)})
+					continue
+				}
+				add(Finding{Code: CodePrereqMissing, Severity: data.SeverityError, Term: t.Name, Course: pc.Course,
+					Message: fmt.Sprintf("%s in %s needs %s in an earlier term.", pc.Course, t.Name, alternatives(group))})
+			}
+		}
+		for c := range this {
+			if _, ok := taken[c]; !ok {
+				taken[c] = t.Name
+			}
+		}
+
+		// Terms left empty are still being planned
+		if len(t.Courses) > 0 {
+			if tr.Credits < tr.MinCredits {
+				add(Finding{Code: CodeTermCreditsLow, Severity: data.SeverityWarning, Term: t.Name,
+					Message: fmt.Sprintf("%s has %s credits, below %s.", t.Name, credits(tr.Credits), credits(tr.MinCredits))})
+			}
+			if tr.Credits > tr.MaxCredits {
+				add(Finding{Code: CodeTermCreditsHigh, Severity: data.SeverityWarning, Term: t.Name,
+					Message: fmt.Sprintf("%s has %s credits, above %s.", t.Name, credits(tr.Credits), credits(tr.MaxCredits))})
+			}
+		}
+		r.Credits += tr.Credits
+		r.CreditsEstimated = r.CreditsEstimated || tr.CreditsEstimated
+		r.Terms = append(r.Terms, tr)
+	}
+
+	if total := r.Credits + r.CompletedCredits; r.TargetCredits > 0 && total < r.TargetCredits {
+		add(Finding{Code: CodeCreditsShort, Severity: data.SeverityInfo,
+			Message: fmt.Sprintf("The plan reaches %s of %s credits.", credits(total), credits(r.TargetCredits))})
+	}
+
+	rank := map[string]int{data.SeverityError: 0, data.SeverityWarning: 1, data.SeverityInfo: 2}
+	slices.SortStableFunc(r.Findings, func(a, b Finding) int { return rank[a.Severity] - rank[b.Severity] })
+	for _, f := range r.Findings {
+		r.Counts[f.Severity]++
+	}
+	r.Valid = r.Counts[data.SeverityError] == 0
+	return r
+}
+
+// termLimits returns a term's credit range. Summer terms have no default
+// minimum.
+func termLimits(t auth.PlanTerm) (float64, float64) {
+	lo, hi := t.MinCredits, t.MaxCredits
+	if lo == 0 && !strings.HasPrefix(data.TermKey(t.Name), "summer") {
+		lo = data.DefaultMinCredits
+	}
+	if hi == 0 {
+		hi = max(data.DefaultMaxCredits, lo)
+	}
+	return lo, hi
+}
+
+// catalogCredits looks for the course's credit hours in any loaded
+// catalog. The title is filled in when still empty.
+func catalogCredits(catalogs *data.Catalogs, course, title string) (string, float64) {
+	for _, term := range catalogs.Terms() {
+		store := catalogs.Store(term)
+		if store == nil {
+			continue
+		}
+		if c, ok := store.FindCourse(course); ok {
+			if title == "" {
+				title = c.Title
+			}
+			if c.Credits > 0 {
+				return title, c.Credits
+			}
+		}
+	}
+	return title, 0
+}
+
+var seasons = map[string]int{"spring": 0, "summer": 1, "fall": 2}
+
+// termBefore reports whether term a comes before b. Names that are not a
+// season and a year ("Spring 2026") cannot be ordered and always pass.
+func termBefore(a, b string) bool {
+	ya, sa, ok1 := parseTerm(a)
+	yb, sb, ok2 := parseTerm(b)
+	if !ok1 || !ok2 {
+		return true
+	}
+	return ya < yb || (ya == yb && sa < sb)
+}
+
+func parseTerm(name string) (year, season int, ok bool) {
+	f := strings.Fields(data.TermKey(name))
+	if len(f) != 2 {
+		return 0, 0, false
+	}
+	season, ok = seasons[f[0]]
+	if !ok {
+		return 0, 0, false
+	}
+	year, err := strconv.Atoi(f[1])
+	return year, season, err == nil
+}
+
+// alternatives names a prerequisite group: "CS 18200" or "one of MA 16100,
+// MA 16500".
+func alternatives(group []string) string {
+	if len(group) == 1 {
+		return group[0]
+	}
+	return "one of " + strings.Join(group, ", ")
+}
+
+func credits(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
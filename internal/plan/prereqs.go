@@ -0,0 +1,54 @@
+// Package plan checks multi-term plans of study against prerequisite data,
+// credit targets and the catalogs loaded for each planned term.
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"purdue_schedule/internal/data"
+)
+
+// Prereqs maps a course to the groups of courses it requires. Every group
+// must be met, by any one of its courses:
+//
+//	{"CS 25100": [["CS 18200"], ["CS 24000"]],
+//	 "MA 16200": [["MA 16100", "MA 16500"]]}
+//
+// The catalog exports carry no requisites, so they come from a separate
+// file maintained alongside the data.
+type Prereqs map[string][][]string
+
+// LoadPrereqs reads a prerequisites file and normalizes its course names.
+func LoadPrereqs(path string) (Prereqs, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string][][]string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	out := make(Prereqs, len(raw))
+	for course, groups := range raw {
+		name, ok := data.NormalizeCourse(course)
+		if !ok {
+			return nil, fmt.Errorf("%s: bad course name %q", path, course)
+		}
+		for _, group := range groups {
+			alts := make([]string, 0, len(group))
+			for _, c := range group {
+				alt, ok := data.NormalizeCourse(c)
+				if !ok {
+					return nil, fmt.Errorf("%s: bad course name %q in prerequisites of %s", path, c, name)
+				}
+				alts = append(alts, alt)
+			}
+			if len(alts) > 0 {
+				out[name] = append(out[name], alts)
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,138 @@
+// Package seats records section enrollment over time. Each dataset load
+// appends a point for every section whose numbers changed since the last
+// point, so the history stays small between refreshes.
+package seats
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"purdue_schedule/internal/data"
+)
+
+// Point is a section's seat counts at one load.
+type Point struct {
+	Time             time.Time `json:"time"`
+	Capacity         int       `json:"capacity"`
+	Enrolled         int       `json:"enrolled"`
+	Remaining        int       `json:"remaining"`
+	WaitlistCapacity int       `json:"waitlistCapacity"`
+	WaitlistCount    int       `json:"waitlistCount"`
+}
+
+// record is one line of the history file.
+type record struct {
+	Section string `json:"section"`
+	Point
+}
+
+func pointOf(at time.Time, s *data.Seats) Point {
+	return Point{
+		Time:             at,
+		Capacity:         s.Capacity,
+		Enrolled:         s.Enrolled,
+		Remaining:        s.Remaining,
+		WaitlistCapacity: s.WaitlistCapacity,
+		WaitlistCount:    s.WaitlistCount,
+	}
+}
+
+func (p Point) same(q Point) bool {
+	p.Time, q.Time = time.Time{}, time.Time{}
+	return p == q
+}
+
+// History is an append-only JSON lines file of points, indexed in memory.
+type History struct {
+	mu        sync.RWMutex
+	f         *os.File
+	bySection map[string][]Point
+}
+
+// Open reads the history at path, creating it if needed. A torn last line,
+// e.g. after a crash mid-write, is cut off so later appends stay readable.
+func Open(path string) (*History, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	h := &History{f: f, bySection: make(map[string][]Point)}
+	dec := json.NewDecoder(bufio.NewReader(f))
+	var good int64
+	for {
+		var rec record
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			return h, nil
+		}
+		if err != nil {
+			break
+		}
+		h.bySection[rec.Section] = append(h.bySection[rec.Section], rec.Point)
+		good = dec.InputOffset()
+	}
+	if err := f.Truncate(good); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if good > 0 {
+		// Keep one record per line
+		if _, err := f.WriteString("\n"); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// Record appends a point at time at for every section of store whose seat
+// counts differ from its last point. It returns how many were written.
+func (h *History) Record(store *data.Store, at time.Time) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	w := bufio.NewWriter(h.f)
+	enc := json.NewEncoder(w)
+	n := 0
+	for _, sec := range store.AllSections() {
+		if sec.Seats == nil {
+			continue
+		}
+		p := pointOf(at, sec.Seats)
+		series := h.bySection[sec.Id]
+		if len(series) > 0 && series[len(series)-1].same(p) {
+			continue
+		}
+		if err := enc.Encode(record{Section: sec.Id, Point: p}); err != nil {
+			return n, err
+		}
+		h.bySection[sec.Id] = append(series, p)
+		n++
+	}
+	if err := w.Flush(); err != nil {
+		return n, err
+	}
+	return n, h.f.Sync()
+}
+
+// Series returns the recorded points for a section, oldest first.
+func (h *History) Series(sectionId string) []Point {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	series := h.bySection[sectionId]
+	out := make([]Point, len(series))
+	copy(out, series)
+	return out
+}
+
+func (h *History) Close() error {
+	return h.f.Close()
+}
@@ -0,0 +1,163 @@
+package share
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"purdue_schedule/internal/data"
+)
+
+var (
+	ErrNotFound = errors.New("share not found")
+	ErrGone     = errors.New("share link has expired or was revoked")
+)
+
+// Options are the display settings captured with a share.
+type Options struct {
+	Title       string `json:"title"`
+	StudentName string `json:"student_name"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Theme       string `json:"theme,omitempty"`
+}
+
+// Share is a read-only snapshot of a schedule. Sections and their courses are
+// copied in full so the link keeps rendering after the catalog is reloaded.
+type Share struct {
+	Id        string                        `json:"id"`
+	OwnerId   string                        `json:"owner_id,omitempty"`
+	CreatedAt time.Time                     `json:"created_at"`
+	ExpiresAt time.Time                     `json:"expires_at,omitzero"`
+	RevokedAt time.Time                     `json:"revoked_at,omitzero"`
+	Options   Options                       `json:"options"`
+	Sections  []data.SectionInfo            `json:"sections"`
+	Courses   map[string]data.CourseSummary `json:"courses"` // by section id
+}
+
+// Active reports whether the share can still be viewed at time now.
+func (s *Share) Active(now time.Time) bool {
+	if !s.RevokedAt.IsZero() {
+		return false
+	}
+	return s.ExpiresAt.IsZero() || now.Before(s.ExpiresAt)
+}
+
+// Store keeps one JSON file per share under dir.
+type Store struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewStore creates dir if needed.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// NewId returns a short, unguessable share id (96 random bits).
+func NewId() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (st *Store) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", ErrNotFound
+	}
+	return filepath.Join(st.dir, id+".json"), nil
+}
+
+// Get loads a share regardless of whether it is still active.
+func (st *Store) Get(id string) (*Share, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	p, err := st.path(id)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Share
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Save creates or replaces a share atomically.
+func (st *Store) Save(s *Share) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	p, err := st.path(s.Id)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+// ByOwner lists a user's shares, newest first.
+func (st *Store) ByOwner(ownerId string) ([]*Share, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	entries, err := os.ReadDir(st.dir)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Share, 0)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(st.dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var s Share
+		if json.Unmarshal(b, &s) != nil || s.OwnerId != ownerId {
+			continue
+		}
+		out = append(out, &s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// Delete removes a share. Deleting one that does not exist is not an error.
+func (st *Store) Delete(id string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	p, err := st.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
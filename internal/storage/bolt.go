@@ -0,0 +1,293 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bucket layout. Records are stored as the same JSON as the file backend;
+// the index buckets map secondary keys to primary keys.
+var (
+	bucketUsers        = []byte("users")         // email -> User
+	bucketUsersById    = []byte("users_by_id")   // id -> email
+	bucketSessions     = []byte("sessions")      // token -> Session
+	bucketSessionsUser = []byte("sessions_user") // userId \x00 token -> nil
+	bucketOTPs         = []byte("otps")          // email -> OTP
+)
+
+// BoltStore keeps all records in a single bbolt database file, with indexes
+// for lookups by user id and sessions by user.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens or creates the database at path.
+func OpenBolt(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{bucketUsers, bucketUsersById, bucketSessions, bucketSessionsUser, bucketOTPs} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (bs *BoltStore) Close() error { return bs.db.Close() }
+
+func get[T any](tx *bolt.Tx, bucket []byte, key string) (*T, error) {
+	if !validKey(key) {
+		return nil, ErrNotFound
+	}
+	raw := tx.Bucket(bucket).Get([]byte(key))
+	if raw == nil {
+		return nil, ErrNotFound
+	}
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func put(tx *bolt.Tx, bucket []byte, key string, v any) error {
+	if !validKey(key) {
+		return ErrNotFound
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucket).Put([]byte(key), raw)
+}
+
+func all[T any](tx *bolt.Tx, bucket []byte) ([]*T, error) {
+	out := make([]*T, 0)
+	err := tx.Bucket(bucket).ForEach(func(_, raw []byte) error {
+		var v T
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		out = append(out, &v)
+		return nil
+	})
+	return out, err
+}
+
+func sessionIndexKey(userId, token string) []byte {
+	return []byte(userId + "\x00" + token)
+}
+
+func (bs *BoltStore) UserByEmail(email string) (u *User, err error) {
+	err = bs.db.View(func(tx *bolt.Tx) error {
+		u, err = get[User](tx, bucketUsers, email)
+		return err
+	})
+	return u, err
+}
+
+func (bs *BoltStore) UserById(id string) (u *User, err error) {
+	err = bs.db.View(func(tx *bolt.Tx) error {
+		email := tx.Bucket(bucketUsersById).Get([]byte(id))
+		if email == nil {
+			return ErrNotFound
+		}
+		u, err = get[User](tx, bucketUsers, string(email))
+		return err
+	})
+	return u, err
+}
+
+func (bs *BoltStore) Users() (us []*User, err error) {
+	err = bs.db.View(func(tx *bolt.Tx) error {
+		us, err = all[User](tx, bucketUsers)
+		return err
+	})
+	return us, err
+}
+
+// saveUser writes a user and keeps the id index pointing at its email.
+func saveUser(tx *bolt.Tx, u *User) error {
+	if old, err := get[User](tx, bucketUsers, u.Email); err == nil && old.Id != u.Id {
+		if err := tx.Bucket(bucketUsersById).Delete([]byte(old.Id)); err != nil {
+			return err
+		}
+	}
+	if err := put(tx, bucketUsers, u.Email, u); err != nil {
+		return err
+	}
+	return tx.Bucket(bucketUsersById).Put([]byte(u.Id), []byte(u.Email))
+}
+
+func (bs *BoltStore) SaveUser(u *User) error {
+	return bs.db.Update(func(tx *bolt.Tx) error { return saveUser(tx, u) })
+}
+
+func (bs *BoltStore) UpdateUser(email string, fn func(*User) error) (u *User, err error) {
+	err = bs.db.Update(func(tx *bolt.Tx) error {
+		u, err = get[User](tx, bucketUsers, email)
+		if err != nil {
+			return err
+		}
+		if err := fn(u); err != nil {
+			return err
+		}
+		if u.Email != email {
+			if tx.Bucket(bucketUsers).Get([]byte(u.Email)) != nil {
+				return ErrExists
+			}
+			if err := tx.Bucket(bucketUsers).Delete([]byte(email)); err != nil {
+				return err
+			}
+		}
+		return saveUser(tx, u)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (bs *BoltStore) DeleteUser(email string) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		u, err := get[User](tx, bucketUsers, email)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketUsersById).Delete([]byte(u.Id)); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketUsers).Delete([]byte(email))
+	})
+}
+
+func (bs *BoltStore) Session(token string) (s *Session, err error) {
+	err = bs.db.View(func(tx *bolt.Tx) error {
+		s, err = get[Session](tx, bucketSessions, token)
+		return err
+	})
+	return s, err
+}
+
+func (bs *BoltStore) SessionsByUser(userId string) ([]*Session, error) {
+	out := make([]*Session, 0)
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		prefix := []byte(userId + "\x00")
+		c := tx.Bucket(bucketSessionsUser).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			s, err := get[Session](tx, bucketSessions, string(k[len(prefix):]))
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			out = append(out, s)
+		}
+		return nil
+	})
+	return out, err
+}
+
+func (bs *BoltStore) Sessions() (ss []*Session, err error) {
+	err = bs.db.View(func(tx *bolt.Tx) error {
+		ss, err = all[Session](tx, bucketSessions)
+		return err
+	})
+	return ss, err
+}
+
+func (bs *BoltStore) SaveSession(s *Session) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		if old, err := get[Session](tx, bucketSessions, s.Token); err == nil && old.UserId != s.UserId {
+			if err := tx.Bucket(bucketSessionsUser).Delete(sessionIndexKey(old.UserId, old.Token)); err != nil {
+				return err
+			}
+		}
+		if err := put(tx, bucketSessions, s.Token, s); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketSessionsUser).Put(sessionIndexKey(s.UserId, s.Token), nil)
+	})
+}
+
+func (bs *BoltStore) UpdateSession(token string, fn func(*Session) error) (s *Session, err error) {
+	err = bs.db.Update(func(tx *bolt.Tx) error {
+		s, err = get[Session](tx, bucketSessions, token)
+		if err != nil {
+			return err
+		}
+		userId := s.UserId
+		if err := fn(s); err != nil {
+			return err
+		}
+		// The token and owner are index keys; fn may not change them
+		s.Token, s.UserId = token, userId
+		return put(tx, bucketSessions, token, s)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (bs *BoltStore) DeleteSession(token string) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		s, err := get[Session](tx, bucketSessions, token)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketSessionsUser).Delete(sessionIndexKey(s.UserId, token)); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketSessions).Delete([]byte(token))
+	})
+}
+
+func (bs *BoltStore) OTP(email string) (o *OTP, err error) {
+	err = bs.db.View(func(tx *bolt.Tx) error {
+		o, err = get[OTP](tx, bucketOTPs, email)
+		return err
+	})
+	return o, err
+}
+
+func (bs *BoltStore) OTPs() (codes []*OTP, err error) {
+	err = bs.db.View(func(tx *bolt.Tx) error {
+		codes, err = all[OTP](tx, bucketOTPs)
+		return err
+	})
+	return codes, err
+}
+
+func (bs *BoltStore) SaveOTP(o *OTP) error {
+	return bs.db.Update(func(tx *bolt.Tx) error { return put(tx, bucketOTPs, o.Email, o) })
+}
+
+func (bs *BoltStore) DeleteOTP(email string) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		if !validKey(email) {
+			return nil
+		}
+		return tx.Bucket(bucketOTPs).Delete([]byte(email))
+	})
+}
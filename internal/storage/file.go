@@ -0,0 +1,331 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileStore keeps one JSON file per record under
+// <root>/users/<email>.json, <root>/sessions/<token>.json and
+// <root>/otps/<email>.json. Users are found by id through an in-memory
+// index that is rebuilt from the directory when it misses, e.g. after
+// another process such as the admin CLI changed the files. Other lookups
+// not by file key scan the directory.
+type FileStore struct {
+	root string
+	mu   sync.RWMutex
+
+	idMu      sync.Mutex        // guards emailById, which readers rebuild
+	emailById map[string]string // user id -> email; nil until first built
+}
+
+// NewFileStore creates the record directories under root if needed.
+func NewFileStore(root string) (*FileStore, error) {
+	for _, dir := range []string{"users", "sessions", "otps"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &FileStore{root: root}, nil
+}
+
+func (fs *FileStore) path(kind, key string) (string, error) {
+	// Keys come from user input (emails) or random tokens; never let them
+	// escape their directory.
+	if !validKey(key) {
+		return "", ErrNotFound
+	}
+	return filepath.Join(fs.root, kind, key+".json"), nil
+}
+
+func (fs *FileStore) read(kind, key string, v any) error {
+	p, err := fs.path(kind, key)
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// write replaces a record atomically so readers never see partial JSON.
+func (fs *FileStore) write(kind, key string, v any) error {
+	p, err := fs.path(kind, key)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (fs *FileStore) remove(kind, key string) error {
+	p, err := fs.path(kind, key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// each decodes every record of a kind, skipping temp files and records that
+// fail to parse.
+func each[T any](fs *FileStore, kind string, fn func(*T)) error {
+	entries, err := os.ReadDir(filepath.Join(fs.root, kind))
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".json") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		var v T
+		if err := fs.read(kind, strings.TrimSuffix(name, ".json"), &v); err != nil {
+			continue
+		}
+		fn(&v)
+	}
+	return nil
+}
+
+func (fs *FileStore) UserByEmail(email string) (*User, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	var u User
+	if err := fs.read("users", email, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (fs *FileStore) UserById(id string) (*User, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	fs.idMu.Lock()
+	email, ok := fs.emailById[id]
+	fs.idMu.Unlock()
+	if ok {
+		var u User
+		if err := fs.read("users", email, &u); err == nil && u.Id == id {
+			return &u, nil
+		}
+	}
+	// Not indexed, or the file moved underneath the index
+	users, err := fs.users()
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		if u.Id == id {
+			return u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (fs *FileStore) Users() ([]*User, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.users()
+}
+
+// users reads every user and rebuilds the id index from them. The caller
+// holds fs.mu.
+func (fs *FileStore) users() ([]*User, error) {
+	out := make([]*User, 0)
+	index := make(map[string]string)
+	err := each(fs, "users", func(u *User) {
+		out = append(out, u)
+		index[u.Id] = u.Email
+	})
+	if err != nil {
+		return nil, err
+	}
+	fs.idMu.Lock()
+	fs.emailById = index
+	fs.idMu.Unlock()
+	return out, nil
+}
+
+// indexUser records where the user with id is now stored; an empty email
+// drops it. The caller holds fs.mu for writing.
+func (fs *FileStore) indexUser(id, email string) {
+	fs.idMu.Lock()
+	defer fs.idMu.Unlock()
+	if fs.emailById == nil {
+		return
+	}
+	if email == "" {
+		delete(fs.emailById, id)
+	} else {
+		fs.emailById[id] = email
+	}
+}
+
+func (fs *FileStore) SaveUser(u *User) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if err := fs.write("users", u.Email, u); err != nil {
+		return err
+	}
+	fs.indexUser(u.Id, u.Email)
+	return nil
+}
+
+func (fs *FileStore) UpdateUser(email string, fn func(*User) error) (*User, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	var u User
+	if err := fs.read("users", email, &u); err != nil {
+		return nil, err
+	}
+	if err := fn(&u); err != nil {
+		return nil, err
+	}
+	if u.Email != email {
+		if _, err := os.Stat(filepath.Join(fs.root, "users", u.Email+".json")); err == nil {
+			return nil, ErrExists
+		}
+	}
+	if err := fs.write("users", u.Email, &u); err != nil {
+		return nil, err
+	}
+	if u.Email != email {
+		if err := fs.remove("users", email); err != nil {
+			return nil, err
+		}
+	}
+	fs.indexUser(u.Id, u.Email)
+	return &u, nil
+}
+
+func (fs *FileStore) DeleteUser(email string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	var u User
+	if err := fs.read("users", email, &u); err == nil {
+		fs.indexUser(u.Id, "")
+	}
+	return fs.remove("users", email)
+}
+
+func (fs *FileStore) Session(token string) (*Session, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	var s Session
+	if err := fs.read("sessions", token, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (fs *FileStore) SessionsByUser(userId string) ([]*Session, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	out := make([]*Session, 0)
+	err := each(fs, "sessions", func(s *Session) {
+		if s.UserId == userId {
+			out = append(out, s)
+		}
+	})
+	return out, err
+}
+
+func (fs *FileStore) Sessions() ([]*Session, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	out := make([]*Session, 0)
+	err := each(fs, "sessions", func(s *Session) { out = append(out, s) })
+	return out, err
+}
+
+func (fs *FileStore) SaveSession(s *Session) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.write("sessions", s.Token, s)
+}
+
+func (fs *FileStore) UpdateSession(token string, fn func(*Session) error) (*Session, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	var s Session
+	if err := fs.read("sessions", token, &s); err != nil {
+		return nil, err
+	}
+	userId := s.UserId
+	if err := fn(&s); err != nil {
+		return nil, err
+	}
+	s.Token, s.UserId = token, userId
+	if err := fs.write("sessions", token, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// DeleteSession removes a session; deleting a missing session is not an error.
+func (fs *FileStore) DeleteSession(token string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.remove("sessions", token)
+}
+
+func (fs *FileStore) OTP(email string) (*OTP, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	var o OTP
+	if err := fs.read("otps", email, &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (fs *FileStore) OTPs() ([]*OTP, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	out := make([]*OTP, 0)
+	err := each(fs, "otps", func(o *OTP) { out = append(out, o) })
+	return out, err
+}
+
+func (fs *FileStore) SaveOTP(o *OTP) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.write("otps", o.Email, o)
+}
+
+func (fs *FileStore) DeleteOTP(email string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.remove("otps", email)
+}
+
+// Close is a no-op; files are closed after every operation.
+func (fs *FileStore) Close() error { return nil }
@@ -0,0 +1,163 @@
+package storage
+
+import "time"
+
+// User mirrors data/users/<email>.json
+type User struct {
+	Id           string          `json:"id"`
+	Email        string          `json:"email"`
+	Name         string          `json:"name"`
+	PasswordHash string          `json:"password_hash"`
+	CreatedAt    time.Time       `json:"created_at"`
+	IsVerified   bool            `json:"is_verified"`
+	Disabled     bool            `json:"disabled,omitempty"`
+	Schedules    []SavedSchedule `json:"schedules"`
+	Friends      []Friend        `json:"friends,omitempty"`
+	Watches      []Watch         `json:"watches,omitempty"`
+	Notify       NotifySettings  `json:"notify,omitzero"`
+	Alerts       []Alert         `json:"alerts,omitempty"`
+	Plan         *Plan           `json:"plan,omitempty"`
+}
+
+// Plan is a multi-term plan of study: the courses the student means to take
+// in each term, in order.
+type Plan struct {
+	Terms []PlanTerm `json:"terms"`
+	// Completed lists courses passed before the first planned term (AP,
+	// transfer or earlier terms), so prerequisites they meet are satisfied.
+	Completed        []string  `json:"completed,omitempty"`
+	CompletedCredits float64   `json:"completed_credits,omitempty"`
+	TargetCredits    float64   `json:"target_credits,omitempty"` // degree total, e.g. 120
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// PlanTerm is one term of a plan. Name matches the term a catalog was
+// loaded for, e.g. "Fall 2025".
+type PlanTerm struct {
+	Name       string          `json:"name"`
+	MinCredits float64         `json:"min_credits,omitempty"` // 0 uses the default
+	MaxCredits float64         `json:"max_credits,omitempty"`
+	Courses    []PlannedCourse `json:"courses"`
+}
+
+// PlannedCourse is a course planned for a term, optionally linked to the
+// sections chosen in that term's catalog.
+type PlannedCourse struct {
+	Course   string   `json:"course"`            // "CS 18000"
+	Credits  float64  `json:"credits,omitempty"` // 0 takes the catalog's credit hours
+	Sections []string `json:"sections,omitempty"`
+}
+
+// Alert tells the user that a catalog reload changed or removed a section
+// in one of their saved schedules.
+type Alert struct {
+	Id        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	SectionId string    `json:"section_id"`
+	Crn       string    `json:"crn"`
+	Course    string    `json:"course"`
+	Schedules []string  `json:"schedules"` // names of the saved schedules holding the section
+	Field     string    `json:"field"`     // "removed", or the changed field
+	Old       string    `json:"old,omitempty"`
+	New       string    `json:"new,omitempty"`
+	Message   string    `json:"message"`
+	Read      bool      `json:"read,omitempty"`
+	Pending   bool      `json:"pending,omitempty"` // still to be emailed or posted
+}
+
+// Watch is a section the user wants to hear about when seats open or the
+// waitlist moves. Remaining and WaitlistCount are the counts last seen, so
+// only changes since then are reported.
+type Watch struct {
+	SectionId     string    `json:"section_id"`
+	Crn           string    `json:"crn"`
+	Course        string    `json:"course"`
+	CreatedAt     time.Time `json:"created_at"`
+	Remaining     int       `json:"remaining"`
+	WaitlistCount int       `json:"waitlist_count"`
+	LastEvent     string    `json:"last_event,omitempty"` // de-duplication key of the last notification
+	NotifiedAt    time.Time `json:"notified_at,omitzero"`
+}
+
+// NotifySettings says how and when the user wants to be notified.
+type NotifySettings struct {
+	Channels   []string `json:"channels,omitempty"` // "email", "webhook"; empty means email
+	WebhookURL string   `json:"webhook_url,omitempty"`
+	QuietStart string   `json:"quiet_start,omitempty"` // "22:00", local to TimeZone
+	QuietEnd   string   `json:"quiet_end,omitempty"`
+	TimeZone   string   `json:"time_zone,omitempty"`
+	// ScheduleAlerts also delivers schedule alerts through Channels; they
+	// are always listed in the app.
+	ScheduleAlerts bool `json:"schedule_alerts,omitempty"`
+}
+
+// Friend is one side of a connection between two users. Both users hold a
+// record pointing at the other.
+type Friend struct {
+	UserId string    `json:"user_id"`
+	Status string    `json:"status"` // incoming, outgoing or accepted
+	Since  time.Time `json:"since"`
+	// Email is the address an outgoing request was sent to; until the
+	// request is accepted only this is shown.
+	Email string `json:"email,omitempty"`
+	// Unmatched marks a request to an address with no verified account.
+	// UserId is then a random id no account has, and only the sender holds
+	// a record.
+	Unmatched bool `json:"unmatched,omitempty"`
+}
+
+// SavedSchedule is a named set of sections stored on the user record.
+type SavedSchedule struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Term    string `json:"term"`
+	Notes   string `json:"notes"`
+	Primary bool   `json:"primary"`
+	// Visibility is "friends" to show the schedule to accepted friends;
+	// anything else keeps it private.
+	Visibility string         `json:"visibility,omitempty"`
+	Sections   []SavedSection `json:"sections"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// SavedSection records a section id together with the labels it had when
+// saved, so a section that later disappears from the catalog can still be
+// described to the user.
+type SavedSection struct {
+	Id     string `json:"id"`
+	Crn    string `json:"crn"`
+	Course string `json:"course"`
+	Type   string `json:"type"`
+}
+
+// Session mirrors data/sessions/<token>.json
+type Session struct {
+	Token      string    `json:"token"`
+	UserId     string    `json:"user_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	RememberMe bool      `json:"remember_me"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at,omitzero"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+}
+
+// OTP mirrors data/otps/<email>.json. Only a hash of the code is kept;
+// records from before hashing carry a plaintext "code" that is ignored, so
+// they can never be redeemed.
+type OTP struct {
+	Email     string    `json:"email"`
+	Purpose   string    `json:"purpose,omitempty"`
+	UserId    string    `json:"user_id,omitempty"`
+	CodeHash  string    `json:"code_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+	SentAt    time.Time `json:"sent_at,omitzero"`
+	Attempts  int       `json:"attempts,omitempty"`
+	Used      bool      `json:"used"`
+
+	// A signup code carries the name and password hash given with that
+	// signup; they reach the account only when the code is redeemed.
+	Name         string `json:"name,omitempty"`
+	PasswordHash string `json:"password_hash,omitempty"`
+}
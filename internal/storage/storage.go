@@ -0,0 +1,106 @@
+// Package storage persists accounts, sessions, one-time codes and the
+// schedules saved on user records. Backends are interchangeable: the file
+// backend reads and writes the data/users|sessions|otps layout, the bolt
+// backend keeps everything in a single embedded database file.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when a record does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrExists is returned when re-keying a record would overwrite another.
+	ErrExists = errors.New("record already exists")
+)
+
+// Store is implemented by every backend. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	UserByEmail(email string) (*User, error)
+	UserById(id string) (*User, error)
+	Users() ([]*User, error)
+	SaveUser(u *User) error
+	// UpdateUser applies fn to a user atomically; the record is written only
+	// when fn returns nil. fn may change the email, which re-keys the record.
+	UpdateUser(email string, fn func(*User) error) (*User, error)
+	DeleteUser(email string) error
+
+	Session(token string) (*Session, error)
+	SessionsByUser(userId string) ([]*Session, error)
+	Sessions() ([]*Session, error)
+	SaveSession(s *Session) error
+	// UpdateSession applies fn to a session atomically. It returns
+	// ErrNotFound rather than recreating a session deleted meanwhile.
+	UpdateSession(token string, fn func(*Session) error) (*Session, error)
+	DeleteSession(token string) error
+
+	OTP(email string) (*OTP, error)
+	OTPs() ([]*OTP, error)
+	SaveOTP(o *OTP) error
+	DeleteOTP(email string) error
+
+	Close() error
+}
+
+// Open opens a backend from a "kind:path" spec, e.g. "file:data" or
+// "bolt:data/boiler.db". A bare path is treated as a file backend directory.
+func Open(spec string) (Store, error) {
+	kind, path, ok := strings.Cut(spec, ":")
+	if !ok {
+		kind, path = "file", spec
+	}
+	switch kind {
+	case "file":
+		return NewFileStore(path)
+	case "bolt":
+		return OpenBolt(path)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", kind)
+	}
+}
+
+// Copy copies every record from src to dst, overwriting records with the
+// same key. It returns how many users, sessions and codes were copied.
+func Copy(dst, src Store) (users, sessions, otps int, err error) {
+	us, err := src.Users()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	for _, u := range us {
+		if err := dst.SaveUser(u); err != nil {
+			return users, sessions, otps, fmt.Errorf("user %s: %w", u.Email, err)
+		}
+		users++
+	}
+	ss, err := src.Sessions()
+	if err != nil {
+		return users, sessions, otps, err
+	}
+	for _, s := range ss {
+		if err := dst.SaveSession(s); err != nil {
+			return users, sessions, otps, fmt.Errorf("session for user %s: %w", s.UserId, err)
+		}
+		sessions++
+	}
+	codes, err := src.OTPs()
+	if err != nil {
+		return users, sessions, otps, err
+	}
+	for _, o := range codes {
+		if err := dst.SaveOTP(o); err != nil {
+			return users, sessions, otps, fmt.Errorf("otp %s: %w", o.Email, err)
+		}
+		otps++
+	}
+	return users, sessions, otps, nil
+}
+
+// validKey rejects keys that could escape a directory or collide with the
+// bolt index separator.
+func validKey(key string) bool {
+	return key != "" && !strings.ContainsAny(key, "/\\\x00") && !strings.Contains(key, "..")
+}
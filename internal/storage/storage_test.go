@@ -0,0 +1,29 @@
+package storage_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"purdue_schedule/internal/storage"
+	"purdue_schedule/internal/storage/storagetest"
+)
+
+func TestFile(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Store {
+		s, err := storage.NewFileStore(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+func TestBolt(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Store {
+		s, err := storage.OpenBolt(filepath.Join(t.TempDir(), "db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
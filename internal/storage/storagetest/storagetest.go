@@ -0,0 +1,322 @@
+// Package storagetest is a conformance suite shared by every storage
+// backend. A backend's tests call Run with a constructor for an empty store:
+//
+//	func TestBolt(t *testing.T) {
+//		storagetest.Run(t, func(t *testing.T) storage.Store {
+//			s, err := storage.OpenBolt(filepath.Join(t.TempDir(), "test.db"))
+//			if err != nil {
+//				t.Fatal(err)
+//			}
+//			return s
+//		})
+//	}
+package storagetest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"purdue_schedule/internal/storage"
+)
+
+// Run exercises open's store through the whole Store interface. Each subtest
+// gets a fresh store, which Run closes when the subtest ends.
+func Run(t *testing.T, open func(t *testing.T) storage.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s storage.Store)
+	}{
+		{"Users", testUsers},
+		{"UpdateUser", testUpdateUser},
+		{"UpdateUserRekey", testUpdateUserRekey},
+		{"Sessions", testSessions},
+		{"OTPs", testOTPs},
+		{"InvalidKeys", testInvalidKeys},
+		{"Copy", func(t *testing.T, s storage.Store) { testCopy(t, s, open(t)) }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := open(t)
+			t.Cleanup(func() { s.Close() })
+			tc.fn(t, s)
+		})
+	}
+}
+
+func newUser(id, email string) *storage.User {
+	return &storage.User{
+		Id:           id,
+		Email:        email,
+		Name:         "Test " + id,
+		PasswordHash: "hash-" + id,
+		CreatedAt:    time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC),
+		Schedules: []storage.SavedSchedule{{
+			Id:       "sched-" + id,
+			Name:     "Fall",
+			Sections: []storage.SavedSection{{Id: "sec1", Crn: "12345", Course: "CS 18000", Type: "Lecture"}},
+		}},
+	}
+}
+
+func mustNotFound(t *testing.T, what string, err error) {
+	t.Helper()
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("%s: got %v, want ErrNotFound", what, err)
+	}
+}
+
+func testUsers(t *testing.T, s storage.Store) {
+	_, err := s.UserByEmail("a@purdue.edu")
+	mustNotFound(t, "missing user by email", err)
+	_, err = s.UserById("u1")
+	mustNotFound(t, "missing user by id", err)
+
+	a, b := newUser("u1", "a@purdue.edu"), newUser("u2", "b@purdue.edu")
+	for _, u := range []*storage.User{a, b} {
+		if err := s.SaveUser(u); err != nil {
+			t.Fatalf("SaveUser(%s): %v", u.Email, err)
+		}
+	}
+
+	got, err := s.UserByEmail("a@purdue.edu")
+	if err != nil {
+		t.Fatalf("UserByEmail: %v", err)
+	}
+	if got.Id != "u1" || got.Name != a.Name || !got.CreatedAt.Equal(a.CreatedAt) || len(got.Schedules) != 1 || got.Schedules[0].Sections[0].Crn != "12345" {
+		t.Fatalf("UserByEmail returned %+v", got)
+	}
+	got, err = s.UserById("u2")
+	if err != nil || got.Email != "b@purdue.edu" {
+		t.Fatalf("UserById(u2) = %+v, %v", got, err)
+	}
+
+	all, err := s.Users()
+	if err != nil {
+		t.Fatalf("Users: %v", err)
+	}
+	var emails []string
+	for _, u := range all {
+		emails = append(emails, u.Email)
+	}
+	slices.Sort(emails)
+	if !slices.Equal(emails, []string{"a@purdue.edu", "b@purdue.edu"}) {
+		t.Fatalf("Users returned %v", emails)
+	}
+
+	if err := s.DeleteUser("a@purdue.edu"); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	_, err = s.UserByEmail("a@purdue.edu")
+	mustNotFound(t, "deleted user by email", err)
+	_, err = s.UserById("u1")
+	mustNotFound(t, "deleted user by id", err)
+	if err := s.DeleteUser("a@purdue.edu"); err != nil {
+		t.Fatalf("deleting a missing user: %v", err)
+	}
+}
+
+func testUpdateUser(t *testing.T, s storage.Store) {
+	_, err := s.UpdateUser("a@purdue.edu", func(*storage.User) error { return nil })
+	mustNotFound(t, "UpdateUser on missing user", err)
+
+	if err := s.SaveUser(newUser("u1", "a@purdue.edu")); err != nil {
+		t.Fatal(err)
+	}
+	u, err := s.UpdateUser("a@purdue.edu", func(u *storage.User) error {
+		u.IsVerified = true
+		u.Schedules = append(u.Schedules, storage.SavedSchedule{Id: "sched-2", Name: "Spring"})
+		return nil
+	})
+	if err != nil || !u.IsVerified || len(u.Schedules) != 2 {
+		t.Fatalf("UpdateUser = %+v, %v", u, err)
+	}
+	got, _ := s.UserByEmail("a@purdue.edu")
+	if !got.IsVerified || len(got.Schedules) != 2 {
+		t.Fatalf("update not persisted: %+v", got)
+	}
+
+	// A failing fn must leave the record untouched
+	boom := errors.New("boom")
+	_, err = s.UpdateUser("a@purdue.edu", func(u *storage.User) error {
+		u.Name = "changed"
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("UpdateUser error = %v, want %v", err, boom)
+	}
+	got, _ = s.UserByEmail("a@purdue.edu")
+	if got.Name == "changed" {
+		t.Fatal("failed update was persisted")
+	}
+}
+
+func testUpdateUserRekey(t *testing.T, s storage.Store) {
+	for _, u := range []*storage.User{newUser("u1", "a@purdue.edu"), newUser("u2", "b@purdue.edu")} {
+		if err := s.SaveUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := s.UpdateUser("a@purdue.edu", func(u *storage.User) error {
+		u.Email = "b@purdue.edu"
+		return nil
+	})
+	if !errors.Is(err, storage.ErrExists) {
+		t.Fatalf("re-key onto an existing email: got %v, want ErrExists", err)
+	}
+	if got, err := s.UserByEmail("b@purdue.edu"); err != nil || got.Id != "u2" {
+		t.Fatalf("existing user overwritten: %+v, %v", got, err)
+	}
+
+	if _, err := s.UpdateUser("a@purdue.edu", func(u *storage.User) error {
+		u.Email = "c@purdue.edu"
+		return nil
+	}); err != nil {
+		t.Fatalf("re-key: %v", err)
+	}
+	_, err = s.UserByEmail("a@purdue.edu")
+	mustNotFound(t, "old email after re-key", err)
+	if got, err := s.UserByEmail("c@purdue.edu"); err != nil || got.Id != "u1" {
+		t.Fatalf("new email after re-key = %+v, %v", got, err)
+	}
+	if got, err := s.UserById("u1"); err != nil || got.Email != "c@purdue.edu" {
+		t.Fatalf("UserById after re-key = %+v, %v", got, err)
+	}
+}
+
+func testSessions(t *testing.T, s storage.Store) {
+	_, err := s.Session("tok1")
+	mustNotFound(t, "missing session", err)
+
+	exp := time.Date(2025, 8, 2, 12, 0, 0, 0, time.UTC)
+	sessions := []*storage.Session{
+		{Token: "tok1", UserId: "u1", ExpiresAt: exp, CreatedAt: exp.Add(-time.Hour)},
+		{Token: "tok2", UserId: "u1", ExpiresAt: exp, RememberMe: true},
+		{Token: "tok3", UserId: "u2", ExpiresAt: exp},
+	}
+	for _, sess := range sessions {
+		if err := s.SaveSession(sess); err != nil {
+			t.Fatalf("SaveSession(%s): %v", sess.Token, err)
+		}
+	}
+
+	got, err := s.Session("tok2")
+	if err != nil || got.UserId != "u1" || !got.RememberMe || !got.ExpiresAt.Equal(exp) {
+		t.Fatalf("Session(tok2) = %+v, %v", got, err)
+	}
+
+	tokens := func(ss []*storage.Session) []string {
+		var out []string
+		for _, s := range ss {
+			out = append(out, s.Token)
+		}
+		slices.Sort(out)
+		return out
+	}
+	byUser, err := s.SessionsByUser("u1")
+	if err != nil || !slices.Equal(tokens(byUser), []string{"tok1", "tok2"}) {
+		t.Fatalf("SessionsByUser(u1) = %v, %v", tokens(byUser), err)
+	}
+	if none, err := s.SessionsByUser("nobody"); err != nil || len(none) != 0 {
+		t.Fatalf("SessionsByUser(nobody) = %v, %v", tokens(none), err)
+	}
+	all, err := s.Sessions()
+	if err != nil || len(all) != 3 {
+		t.Fatalf("Sessions = %v, %v", tokens(all), err)
+	}
+
+	last := exp.Add(-time.Minute)
+	if got, err := s.UpdateSession("tok2", func(sess *storage.Session) error {
+		sess.LastUsedAt = last
+		sess.ExpiresAt = exp.Add(time.Hour)
+		return nil
+	}); err != nil || !got.LastUsedAt.Equal(last) {
+		t.Fatalf("UpdateSession = %+v, %v", got, err)
+	}
+	if got, _ := s.Session("tok2"); !got.LastUsedAt.Equal(last) || !got.ExpiresAt.Equal(exp.Add(time.Hour)) {
+		t.Fatalf("update not persisted: %+v", got)
+	}
+
+	if err := s.DeleteSession("tok1"); err != nil {
+		t.Fatalf("DeleteSession: %v", err)
+	}
+	// Updating a deleted session must not bring it back
+	_, err = s.UpdateSession("tok1", func(*storage.Session) error { return nil })
+	mustNotFound(t, "UpdateSession on deleted session", err)
+	_, err = s.Session("tok1")
+	mustNotFound(t, "deleted session", err)
+	byUser, _ = s.SessionsByUser("u1")
+	if !slices.Equal(tokens(byUser), []string{"tok2"}) {
+		t.Fatalf("SessionsByUser after delete = %v", tokens(byUser))
+	}
+	if err := s.DeleteSession("tok1"); err != nil {
+		t.Fatalf("deleting a missing session: %v", err)
+	}
+}
+
+func testOTPs(t *testing.T, s storage.Store) {
+	_, err := s.OTP("a@purdue.edu")
+	mustNotFound(t, "missing otp", err)
+
+	exp := time.Date(2025, 8, 1, 12, 10, 0, 0, time.UTC)
+	if err := s.SaveOTP(&storage.OTP{Email: "a@purdue.edu", CodeHash: "hash-1", ExpiresAt: exp}); err != nil {
+		t.Fatalf("SaveOTP: %v", err)
+	}
+	// Saving again replaces the pending code
+	if err := s.SaveOTP(&storage.OTP{Email: "a@purdue.edu", CodeHash: "hash-2", ExpiresAt: exp}); err != nil {
+		t.Fatalf("SaveOTP: %v", err)
+	}
+	got, err := s.OTP("a@purdue.edu")
+	if err != nil || got.CodeHash != "hash-2" || !got.ExpiresAt.Equal(exp) {
+		t.Fatalf("OTP = %+v, %v", got, err)
+	}
+	if all, err := s.OTPs(); err != nil || len(all) != 1 {
+		t.Fatalf("OTPs = %d records, %v", len(all), err)
+	}
+	if err := s.DeleteOTP("a@purdue.edu"); err != nil {
+		t.Fatalf("DeleteOTP: %v", err)
+	}
+	_, err = s.OTP("a@purdue.edu")
+	mustNotFound(t, "deleted otp", err)
+}
+
+func testInvalidKeys(t *testing.T, s storage.Store) {
+	for _, key := range []string{"", "../users/a@purdue.edu", "a/b", "a\x00b"} {
+		_, err := s.UserByEmail(key)
+		mustNotFound(t, "UserByEmail("+key+")", err)
+		_, err = s.Session(key)
+		mustNotFound(t, "Session("+key+")", err)
+		if err := s.SaveUser(&storage.User{Id: "x", Email: key}); err == nil {
+			t.Fatalf("SaveUser accepted key %q", key)
+		}
+	}
+}
+
+func testCopy(t *testing.T, src, dst storage.Store) {
+	t.Cleanup(func() { dst.Close() })
+	if err := src.SaveUser(newUser("u1", "a@purdue.edu")); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.SaveSession(&storage.Session{Token: "tok1", UserId: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.SaveOTP(&storage.OTP{Email: "b@purdue.edu", CodeHash: "hash-3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	users, sessions, otps, err := storage.Copy(dst, src)
+	if err != nil || users != 1 || sessions != 1 || otps != 1 {
+		t.Fatalf("Copy = %d, %d, %d, %v", users, sessions, otps, err)
+	}
+	if got, err := dst.UserById("u1"); err != nil || got.Email != "a@purdue.edu" || len(got.Schedules) != 1 {
+		t.Fatalf("copied user = %+v, %v", got, err)
+	}
+	if ss, err := dst.SessionsByUser("u1"); err != nil || len(ss) != 1 {
+		t.Fatalf("copied sessions = %d, %v", len(ss), err)
+	}
+	if o, err := dst.OTP("b@purdue.edu"); err != nil || o.CodeHash != "hash-3" {
+		t.Fatalf("copied otp = %+v, %v", o, err)
+	}
+}